@@ -0,0 +1,188 @@
+package mux
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AccessLogEntry is the record of a request written by the AccessLog
+// middleware.
+type AccessLogEntry struct {
+	// Time is the time the request started.
+	Time time.Time
+	// ClientIP is the IP of the client, see ClientIP.
+	ClientIP string
+	// User is the user name of the basic auth credentials, empty if there
+	// are none.
+	User   string
+	Method string
+	URI    string
+	Proto  string
+	// Pattern is the path template of the matched route, e.g. /users/:id.
+	Pattern string
+	// Route is the name of the matched route, empty if it has none.
+	Route  string
+	Status int
+	// Bytes is the size of the response body.
+	Bytes     int64
+	Duration  time.Duration
+	Referer   string
+	UserAgent string
+}
+
+// AccessLogFormat writes an entry as a line to buf.
+type AccessLogFormat func(buf *bytes.Buffer, entry *AccessLogEntry)
+
+// AccessLogOptions configures the AccessLog middleware.
+type AccessLogOptions struct {
+	// Writer receives the log lines, it defaults to os.Stdout. Each line is
+	// written with a single call.
+	Writer io.Writer
+	// Format formats the entries, it defaults to CommonLogFormat.
+	Format AccessLogFormat
+	// Clock returns the current time, it defaults to time.Now.
+	Clock func() time.Time
+}
+
+// AccessLog returns a middleware which writes a line to the access log for
+// every request, e.g.:
+//
+//     r.Use(mux.AccessLog(mux.AccessLogOptions{Writer: file, Format: mux.JSONLogFormat}))
+//
+// CommonLogFormat and CombinedLogFormat write the formats of Apache and
+// nginx, which most log tools parse. JSONLogFormat writes every field of the
+// entry, including the route pattern and the duration.
+func AccessLog(opts AccessLogOptions) MiddlewareFunc {
+	if opts.Writer == nil {
+		opts.Writer = os.Stdout
+	}
+	if opts.Format == nil {
+		opts.Format = CommonLogFormat
+	}
+	if opts.Clock == nil {
+		opts.Clock = time.Now
+	}
+	var mu sync.Mutex
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			start := opts.Clock()
+			sw := &sizeWriter{statusWriter: statusWriter{ResponseWriter: w, code: http.StatusOK}}
+			next.ServeHTTP(sw, req)
+
+			entry := &AccessLogEntry{
+				Time:      start,
+				ClientIP:  ClientIP(req),
+				Method:    req.Method,
+				URI:       req.RequestURI,
+				Proto:     req.Proto,
+				Status:    sw.code,
+				Bytes:     sw.size,
+				Duration:  opts.Clock().Sub(start),
+				Referer:   req.Referer(),
+				UserAgent: req.UserAgent(),
+			}
+			if entry.URI == "" {
+				entry.URI = req.URL.RequestURI()
+			}
+			if user, _, ok := req.BasicAuth(); ok {
+				entry.User = user
+			}
+			if route := CurrentRoute(req); route != nil {
+				entry.Pattern = route.GetPath()
+				entry.Route = route.GetName()
+			}
+
+			var buf bytes.Buffer
+			opts.Format(&buf, entry)
+			mu.Lock()
+			opts.Writer.Write(buf.Bytes())
+			mu.Unlock()
+		})
+	}
+}
+
+// CommonLogFormat writes the Common Log Format, e.g.:
+//
+//     192.0.2.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326
+//
+func CommonLogFormat(buf *bytes.Buffer, entry *AccessLogEntry) {
+	writeCommonLog(buf, entry)
+	buf.WriteByte('\n')
+}
+
+// CombinedLogFormat writes the Combined Log Format, the Common Log Format
+// with the Referer and User-Agent headers.
+func CombinedLogFormat(buf *bytes.Buffer, entry *AccessLogEntry) {
+	writeCommonLog(buf, entry)
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.Quote(entry.Referer))
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.Quote(entry.UserAgent))
+	buf.WriteByte('\n')
+}
+
+func writeCommonLog(buf *bytes.Buffer, entry *AccessLogEntry) {
+	buf.WriteString(logField(entry.ClientIP))
+	buf.WriteString(" - ")
+	buf.WriteString(logField(entry.User))
+	buf.WriteString(" [")
+	buf.WriteString(entry.Time.Format("02/Jan/2006:15:04:05 -0700"))
+	buf.WriteString("] ")
+	// the request line is quoted, so a crafted URI can't forge fields or
+	// lines
+	buf.WriteString(strconv.Quote(entry.Method + " " + entry.URI + " " + entry.Proto))
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.Itoa(entry.Status))
+	buf.WriteByte(' ')
+	if entry.Bytes > 0 {
+		buf.WriteString(strconv.FormatInt(entry.Bytes, 10))
+	} else {
+		buf.WriteByte('-')
+	}
+}
+
+// logField returns s escaped for an unquoted field of the Common Log
+// Format, - if it is empty.
+func logField(s string) string {
+	if s == "" {
+		return "-"
+	}
+	s = strconv.Quote(s)
+	return strings.ReplaceAll(s[1:len(s)-1], " ", `\x20`)
+}
+
+// JSONLogFormat writes an entry as a JSON object, with the duration in
+// milliseconds, e.g.:
+//
+//     {"time":"2000-10-10T13:55:36-07:00","client_ip":"192.0.2.1","method":"GET","uri":"/users/1","proto":"HTTP/1.1","pattern":"/users/:id","status":200,"bytes":2326,"duration_ms":1.25}
+//
+func JSONLogFormat(buf *bytes.Buffer, entry *AccessLogEntry) {
+	json.NewEncoder(buf).Encode(struct {
+		Time      time.Time `json:"time"`
+		ClientIP  string    `json:"client_ip"`
+		User      string    `json:"user,omitempty"`
+		Method    string    `json:"method"`
+		URI       string    `json:"uri"`
+		Proto     string    `json:"proto"`
+		Pattern   string    `json:"pattern,omitempty"`
+		Route     string    `json:"route,omitempty"`
+		Status    int       `json:"status"`
+		Bytes     int64     `json:"bytes"`
+		Duration  float64   `json:"duration_ms"`
+		Referer   string    `json:"referer,omitempty"`
+		UserAgent string    `json:"user_agent,omitempty"`
+	}{
+		entry.Time, entry.ClientIP, entry.User, entry.Method, entry.URI, entry.Proto,
+		entry.Pattern, entry.Route, entry.Status, entry.Bytes,
+		float64(entry.Duration) / float64(time.Millisecond),
+		entry.Referer, entry.UserAgent,
+	})
+}
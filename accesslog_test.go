@@ -0,0 +1,82 @@
+package mux
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccessLog(t *testing.T) {
+	now := time.Date(2000, time.October, 10, 13, 55, 36, 0, time.FixedZone("", -7*3600))
+	clock := func() time.Time {
+		now = now.Add(1250 * time.Microsecond)
+		return now
+	}
+
+	tests := []struct {
+		format   AccessLogFormat
+		expected string
+	}{
+		{CommonLogFormat, `192.0.2.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /users/1?q=\"x\" HTTP/1.1" 200 5` + "\n"},
+		{CombinedLogFormat, `192.0.2.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /users/1?q=\"x\" HTTP/1.1" 200 5 "https://example.com/" "test agent"` + "\n"},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		r := Classic()
+		r.Use(AccessLog(AccessLogOptions{Writer: &buf, Format: test.format, Clock: clock}))
+		r.Get("/users/:id", func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("alice"))
+		})
+
+		req := httptest.NewRequest(http.MethodGet, `/users/1?q="x"`, nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.SetBasicAuth("frank", "secret")
+		req.Header.Set("Referer", "https://example.com/")
+		req.Header.Set("User-Agent", "test agent")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		if buf.String() != test.expected {
+			t.Errorf("Unexpected log line (%q)", buf.String())
+		}
+	}
+}
+
+func TestAccessLogJSON(t *testing.T) {
+	var buf bytes.Buffer
+	r := Classic()
+	r.Use(AccessLog(AccessLogOptions{Writer: &buf, Format: JSONLogFormat}))
+	r.Get("/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}).Name("user")
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	entry := map[string]interface{}{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Unexpected log line %q (%v)", buf.String(), err)
+	}
+	expected := map[string]interface{}{
+		"client_ip": "192.0.2.1",
+		"method":    "GET",
+		"uri":       "/users/1",
+		"pattern":   "/users/:id",
+		"route":     "user",
+		"status":    float64(204),
+		"bytes":     float64(0),
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("Unexpected %s (%v)", key, entry[key])
+		}
+	}
+	if _, found := entry["duration_ms"]; !found || strings.Count(buf.String(), "\n") != 1 {
+		t.Errorf("Unexpected log line (%q)", buf.String())
+	}
+}
@@ -0,0 +1,86 @@
+package mux
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+)
+
+// BasicAuth returns a middleware which authenticates the requests with HTTP
+// basic authentication, e.g. for an admin group:
+//
+//     admin := r.PathPrefix("/admin").Subrouter()
+//     admin.Use(mux.BasicAuth("admin", mux.BasicAuthUsers(map[string]string{
+//         "alice": os.Getenv("ADMIN_PASSWORD"),
+//     })))
+//
+// validate checks the credentials, it should compare them in constant time,
+// e.g. with BasicAuthUsers or SecureCompare. Requests without valid
+// credentials are answered with 401 Unauthorized and a WWW-Authenticate
+// challenge for realm.
+func BasicAuth(realm string, validate func(user, password string) bool) MiddlewareFunc {
+	challenge := "Basic realm=" + strconv.Quote(realm) + `, charset="UTF-8"`
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			user, password, ok := req.BasicAuth()
+			if !ok || !validate(user, password) {
+				w.Header().Set("WWW-Authenticate", challenge)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// BasicAuthUsers returns a BasicAuth validator for a map of users to
+// passwords. The credentials are compared in constant time, regardless of
+// whether the user exists.
+func BasicAuthUsers(users map[string]string) func(user, password string) bool {
+	hashed := make(map[string][sha256.Size]byte, len(users))
+	for user, password := range users {
+		hashed[user] = sha256.Sum256([]byte(password))
+	}
+
+	return func(user, password string) bool {
+		want, found := hashed[user]
+		got := sha256.Sum256([]byte(password))
+		return subtle.ConstantTimeCompare(got[:], want[:]) == 1 && found
+	}
+}
+
+// SecureCompare compares two secrets in constant time. Unlike
+// subtle.ConstantTimeCompare the time doesn't depend on their lengths
+// either.
+func SecureCompare(given, actual string) bool {
+	a := sha256.Sum256([]byte(given))
+	b := sha256.Sum256([]byte(actual))
+	return subtle.ConstantTimeCompare(a[:], b[:]) == 1
+}
+
+// APIKeyAuth returns a middleware which authenticates the requests with an
+// API key, see Route.APIKey for source and lookup. Requests without a valid
+// key are answered with 401 Unauthorized, e.g. for a group:
+//
+//     partners := r.PathPrefix("/partners").Subrouter()
+//     partners.Use(mux.APIKeyAuth("header:X-API-Key", keys.Valid))
+//
+// It panics if source is invalid.
+func APIKeyAuth(source string, lookup func(key string) bool) MiddlewareFunc {
+	m, err := newAPIKeyMatcher(source, lookup)
+	if err != nil {
+		panic(err)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if !m.Match(req) {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBasicAuth(t *testing.T) {
+	r := Classic()
+	admin := r.PathPrefix("/admin").Subrouter()
+	admin.Use(BasicAuth("admin area", BasicAuthUsers(map[string]string{"alice": "secret"})))
+	admin.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("welcome"))
+	})
+
+	tests := []struct {
+		user, password string
+		code           int
+	}{
+		{"alice", "secret", http.StatusOK},
+		{"alice", "wrong", http.StatusUnauthorized},
+		{"bob", "secret", http.StatusUnauthorized},
+		{"", "", http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/admin/", nil)
+		if test.user != "" {
+			req.SetBasicAuth(test.user, test.password)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != test.code {
+			t.Errorf("Unexpected status for %s (%d)", test.user, w.Code)
+		}
+		challenge := w.Header().Get("WWW-Authenticate")
+		if test.code == http.StatusUnauthorized && challenge != `Basic realm="admin area", charset="UTF-8"` {
+			t.Errorf("Unexpected challenge (%s)", challenge)
+		}
+		if test.code == http.StatusOK && (challenge != "" || w.Body.String() != "welcome") {
+			t.Errorf("Unexpected response (%s, %s)", challenge, w.Body.String())
+		}
+	}
+}
+
+func TestSecureCompare(t *testing.T) {
+	if !SecureCompare("token", "token") {
+		t.Error("Equal secrets don't match")
+	}
+	if SecureCompare("token", "tokens") || SecureCompare("", "token") {
+		t.Error("Different secrets match")
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	valid := func(key string) bool {
+		return SecureCompare(key, "k1")
+	}
+
+	r := Classic()
+	r.Get("/reports", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("reports"))
+	}).APIKey("header:x-api-key", valid)
+	r.Get("/feeds", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("feeds"))
+	}).APIKey("query:api_key", valid)
+
+	tests := []struct {
+		path, header string
+		code         int
+	}{
+		{"/reports", "k1", http.StatusOK},
+		{"/reports", "k2", http.StatusNotFound},
+		{"/reports", "", http.StatusNotFound},
+		{"/feeds?api_key=k1", "", http.StatusOK},
+		{"/feeds?api_key=k2", "", http.StatusNotFound},
+		{"/feeds", "k1", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+		if test.header != "" {
+			req.Header.Set("X-API-Key", test.header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != test.code {
+			t.Errorf("Unexpected status for %s with %q (%d)", test.path, test.header, w.Code)
+		}
+	}
+}
+
+func TestAPIKeyFail(t *testing.T) {
+	valid := func(key string) bool { return true }
+
+	for _, source := range []string{"X-API-Key", "cookie:key", "header:"} {
+		r := Classic()
+		r.Get("/", func(w http.ResponseWriter, req *http.Request) {}).APIKey(source, valid)
+		if ok, _ := r.HasErrors(); !ok {
+			t.Errorf("Missing error for %q", source)
+		}
+	}
+
+	r := Classic()
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {}).APIKey("header:X-API-Key", nil)
+	if ok, _ := r.HasErrors(); !ok {
+		t.Error("Missing error for a nil lookup")
+	}
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	r := Classic()
+	partners := r.PathPrefix("/partners").Subrouter()
+	partners.Use(APIKeyAuth("header:X-API-Key", func(key string) bool {
+		return SecureCompare(key, "k1")
+	}))
+	partners.Get("/orders", func(w http.ResponseWriter, req *http.Request) {})
+
+	for key, code := range map[string]int{"k1": http.StatusOK, "k2": http.StatusUnauthorized, "": http.StatusUnauthorized} {
+		req := httptest.NewRequest(http.MethodGet, "/partners/orders", nil)
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != code {
+			t.Errorf("Unexpected status for %q (%d)", key, w.Code)
+		}
+	}
+}
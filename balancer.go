@@ -0,0 +1,107 @@
+package mux
+
+import (
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+// Upstream is a target of a Proxy.
+type Upstream struct {
+	URL *url.URL
+	// active is the number of requests in flight.
+	active atomic.Int64
+	// failedUntil is the time in unix nanoseconds until the upstream is
+	// unhealthy.
+	failedUntil atomic.Int64
+}
+
+// Active returns the number of requests to the upstream in flight.
+func (u *Upstream) Active() int64 {
+	return u.active.Load()
+}
+
+// Healthy returns false if a request to the upstream failed within the fail
+// timeout of its proxy before now.
+func (u *Upstream) Healthy(now time.Time) bool {
+	return now.UnixNano() >= u.failedUntil.Load()
+}
+
+// fail marks the upstream as unhealthy for d.
+func (u *Upstream) fail(d time.Duration) {
+	u.failedUntil.Store(time.Now().Add(d).UnixNano())
+}
+
+// Balancer selects the upstream of a request. It has to be safe for
+// concurrent use.
+type Balancer interface {
+	// Next returns one of the upstreams, which are never empty.
+	Next(req *http.Request, upstreams []*Upstream) *Upstream
+}
+
+// RoundRobin returns a Balancer which selects the upstreams in turn.
+func RoundRobin() Balancer {
+	return &roundRobin{}
+}
+
+type roundRobin struct {
+	n atomic.Uint64
+}
+
+func (b *roundRobin) Next(req *http.Request, upstreams []*Upstream) *Upstream {
+	return upstreams[(b.n.Add(1)-1)%uint64(len(upstreams))]
+}
+
+// LeastConnections returns a Balancer which selects the upstream with the
+// fewest requests in flight, the first one on ties.
+func LeastConnections() Balancer {
+	return leastConnections{}
+}
+
+type leastConnections struct{}
+
+func (leastConnections) Next(req *http.Request, upstreams []*Upstream) *Upstream {
+	best := upstreams[0]
+	for _, u := range upstreams[1:] {
+		if u.Active() < best.Active() {
+			best = u
+		}
+	}
+	return best
+}
+
+// HashHeader returns a Balancer which selects the upstream by a consistent
+// hash of a request header, e.g. a session or tenant ID, so requests with
+// the same value reach the same upstream. If an upstream becomes unhealthy
+// only its requests move to others (rendezvous hashing). Requests without
+// the header are balanced round-robin.
+func HashHeader(name string) Balancer {
+	return &hashHeader{name: name}
+}
+
+type hashHeader struct {
+	name     string
+	fallback roundRobin
+}
+
+func (b *hashHeader) Next(req *http.Request, upstreams []*Upstream) *Upstream {
+	key := req.Header.Get(b.name)
+	if key == "" {
+		return b.fallback.Next(req, upstreams)
+	}
+
+	var best *Upstream
+	var bestScore uint64
+	for _, u := range upstreams {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write([]byte(u.URL.String()))
+		if score := h.Sum64(); best == nil || score > bestScore {
+			best, bestScore = u, score
+		}
+	}
+	return best
+}
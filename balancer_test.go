@@ -0,0 +1,160 @@
+package mux
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func namedUpstream(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, name)
+	}))
+}
+
+func TestProxyRoundRobin(t *testing.T) {
+	a, b := namedUpstream("a"), namedUpstream("b")
+	defer a.Close()
+	defer b.Close()
+
+	r := Classic()
+	r.Proxy("/", a.URL, b.URL)
+
+	bodies := ""
+	for i := 0; i < 4; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		bodies += w.Body.String()
+	}
+
+	if bodies != "abab" {
+		t.Errorf("Unexpected upstreams (%s)", bodies)
+	}
+}
+
+func TestProxyRoundRobinPerProxy(t *testing.T) {
+	a, b := namedUpstream("a"), namedUpstream("b")
+	defer a.Close()
+	defer b.Close()
+
+	r := Classic()
+	r.Proxy("/one", a.URL, b.URL)
+	r.Proxy("/two", a.URL, b.URL)
+
+	// the proxies don't advance each other's turn
+	bodies := ""
+	for _, path := range []string{"/one", "/two", "/one", "/two"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		bodies += w.Body.String()
+	}
+
+	if bodies != "aabb" {
+		t.Errorf("Unexpected upstreams (%s)", bodies)
+	}
+}
+
+func TestProxyPassiveHealth(t *testing.T) {
+	a, b := namedUpstream("a"), namedUpstream("b")
+	defer b.Close()
+	a.Close()
+
+	p, err := NewProxy(a.URL, b.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.FailTimeout = time.Minute
+
+	r := Classic()
+	r.Get("/", p.ServeHTTP)
+
+	codes := []int{}
+	bodies := ""
+	for i := 0; i < 4; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		codes = append(codes, w.Code)
+		if w.Code == http.StatusOK {
+			bodies += w.Body.String()
+		}
+	}
+
+	if codes[0] != http.StatusBadGateway || bodies != "bbb" {
+		t.Errorf("Unexpected responses (%v, %s)", codes, bodies)
+	}
+	if p.Upstreams[0].Healthy(time.Now()) || !p.Upstreams[1].Healthy(time.Now()) {
+		t.Error("Unexpected health of the upstreams")
+	}
+	if !p.Upstreams[0].Healthy(time.Now().Add(2 * time.Minute)) {
+		t.Error("Upstream is unhealthy after the fail timeout")
+	}
+}
+
+func TestProxyClientCanceled(t *testing.T) {
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-release
+	}))
+	defer upstream.Close()
+	defer close(release)
+
+	p, err := NewProxy(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	p.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+	if !p.Upstreams[0].Healthy(time.Now()) {
+		t.Error("Upstream is unhealthy after the client went away")
+	}
+}
+
+func TestHashHeader(t *testing.T) {
+	upstreams := []*Upstream{}
+	for _, host := range []string{"a", "b", "c", "d"} {
+		upstreams = append(upstreams, &Upstream{URL: &url.URL{Scheme: "http", Host: host}})
+	}
+
+	b := HashHeader("X-Tenant")
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	selected := map[string]*Upstream{}
+	for i := 0; i < 20; i++ {
+		tenant := fmt.Sprint("tenant-", i)
+		req.Header.Set("X-Tenant", tenant)
+		selected[tenant] = b.Next(req, upstreams)
+
+		if b.Next(req, upstreams) != selected[tenant] {
+			t.Fatalf("Tenant %s moved", tenant)
+		}
+	}
+
+	// only the tenants of a removed upstream move
+	for tenant, u := range selected {
+		if u == upstreams[3] {
+			continue
+		}
+		req.Header.Set("X-Tenant", tenant)
+		if b.Next(req, upstreams[:3]) != u {
+			t.Errorf("Tenant %s moved", tenant)
+		}
+	}
+}
+
+func TestLeastConnections(t *testing.T) {
+	upstreams := []*Upstream{{}, {}, {}}
+	upstreams[0].active.Store(2)
+	upstreams[1].active.Store(1)
+	upstreams[2].active.Store(1)
+
+	if u := LeastConnections().Next(nil, upstreams); u != upstreams[1] {
+		t.Errorf("Unexpected upstream (%d)", u.Active())
+	}
+}
@@ -0,0 +1,232 @@
+package mux
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed passes the requests on.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects the requests.
+	BreakerOpen
+	// BreakerHalfOpen passes trial requests on to probe the recovery.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerOptions configures a circuit breaker.
+type BreakerOptions struct {
+	// FailureThreshold is the number of consecutive failures which open the
+	// breaker, it defaults to 5.
+	FailureThreshold int
+	// OpenTimeout is the time the breaker stays open before it lets trial
+	// requests pass, it defaults to 30 seconds.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of successful trial requests which
+	// close the breaker again, it defaults to 1. A failed trial opens it.
+	HalfOpenRequests int
+	// IsFailure decides whether a response status is a failure, it defaults
+	// to the 5xx statuses.
+	IsFailure func(code int) bool
+	// OnStateChange is called when the state changes, e.g. for logging.
+	OnStateChange func(from, to BreakerState)
+	// Clock returns the current time, it defaults to time.Now.
+	Clock func() time.Time
+}
+
+// Breaker is a circuit breaker. Closed, it passes the requests on and counts
+// the consecutive failures. Once they reach the threshold it opens and
+// answers the requests at once with 503 Service Unavailable and a
+// Retry-After header, instead of piling them up on a failing dependency.
+// After the open timeout it becomes half-open and passes trial requests on,
+// which close it if they succeed or open it again if one fails.
+type Breaker struct {
+	opts BreakerOptions
+
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int
+	openedAt  time.Time
+	trials    int
+	successes int
+}
+
+// NewBreaker returns a closed circuit breaker. Its Middleware is attached
+// per route or group, e.g.:
+//
+//     payments := mux.NewBreaker(mux.BreakerOptions{FailureThreshold: 3})
+//     r.Post("/checkout", checkoutHandler).Use(payments.Middleware)
+//
+func NewBreaker(opts BreakerOptions) *Breaker {
+	if opts.FailureThreshold < 1 {
+		opts.FailureThreshold = 5
+	}
+	if opts.OpenTimeout <= 0 {
+		opts.OpenTimeout = 30 * time.Second
+	}
+	if opts.HalfOpenRequests < 1 {
+		opts.HalfOpenRequests = 1
+	}
+	if opts.IsFailure == nil {
+		opts.IsFailure = func(code int) bool {
+			return code >= http.StatusInternalServerError
+		}
+	}
+	if opts.Clock == nil {
+		opts.Clock = time.Now
+	}
+	return &Breaker{opts: opts}
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance()
+	return b.state
+}
+
+// Middleware rejects the requests while the breaker is open and records the
+// outcome of the others. A panic of the handler counts as failure.
+func (b *Breaker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if wait, ok := b.allow(); !ok {
+			seconds := int((wait + time.Second - 1) / time.Second)
+			if seconds < 1 {
+				seconds = 1
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+
+		sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+		failed := true
+		defer func() {
+			b.record(failed)
+		}()
+
+		next.ServeHTTP(sw, req)
+		failed = b.opts.IsFailure(sw.code)
+	})
+}
+
+// allow returns true if a request may pass, otherwise the time until the
+// breaker becomes half-open.
+func (b *Breaker) allow() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance()
+	switch b.state {
+	case BreakerOpen:
+		return b.openedAt.Add(b.opts.OpenTimeout).Sub(b.opts.Clock()), false
+	case BreakerHalfOpen:
+		if b.trials >= b.opts.HalfOpenRequests {
+			return 0, false
+		}
+		b.trials++
+	}
+	return 0, true
+}
+
+// record counts the outcome of a request.
+func (b *Breaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerClosed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.opts.FailureThreshold {
+			b.open()
+		}
+	case BreakerHalfOpen:
+		if failed {
+			b.open()
+			return
+		}
+		b.successes++
+		if b.successes >= b.opts.HalfOpenRequests {
+			b.setState(BreakerClosed)
+		}
+	}
+}
+
+// advance makes an open breaker half-open after the open timeout.
+func (b *Breaker) advance() {
+	if b.state == BreakerOpen && !b.opts.Clock().Before(b.openedAt.Add(b.opts.OpenTimeout)) {
+		b.setState(BreakerHalfOpen)
+	}
+}
+
+func (b *Breaker) open() {
+	b.openedAt = b.opts.Clock()
+	b.setState(BreakerOpen)
+}
+
+// setState changes the state and resets the counters.
+func (b *Breaker) setState(state BreakerState) {
+	from := b.state
+	b.state = state
+	b.failures, b.trials, b.successes = 0, 0, 0
+
+	if b.opts.OnStateChange != nil && from != state {
+		b.opts.OnStateChange(from, state)
+	}
+}
+
+// statusWriter records the status of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader && (code < 100 || code > 199) {
+		w.wroteHeader = true
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends the buffered data to the client if the wrapped ResponseWriter
+// supports it.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped ResponseWriter, e.g. for http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
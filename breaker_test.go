@@ -0,0 +1,100 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2024, time.March, 2, 22, 0, 0, 0, time.UTC)
+	transitions := []string{}
+	failing := true
+
+	breaker := NewBreaker(BreakerOptions{
+		FailureThreshold: 2,
+		OpenTimeout:      10 * time.Second,
+		OnStateChange: func(from, to BreakerState) {
+			transitions = append(transitions, from.String()+">"+to.String())
+		},
+		Clock: func() time.Time {
+			return now
+		},
+	})
+
+	r := Classic()
+	r.Get("/payments", func(w http.ResponseWriter, req *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("ok"))
+	}).Use(breaker.Middleware)
+
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/payments", nil))
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := get(); w.Code != http.StatusBadGateway {
+			t.Fatalf("Unexpected status (%d)", w.Code)
+		}
+	}
+	if breaker.State() != BreakerOpen {
+		t.Fatalf("Unexpected state (%s)", breaker.State())
+	}
+
+	now = now.Add(4 * time.Second)
+	if w := get(); w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "6" {
+		t.Errorf("Unexpected response (%d, %v)", w.Code, w.Header())
+	}
+
+	// a failed trial opens the breaker again
+	now = now.Add(6 * time.Second)
+	if breaker.State() != BreakerHalfOpen {
+		t.Fatalf("Unexpected state (%s)", breaker.State())
+	}
+	if w := get(); w.Code != http.StatusBadGateway {
+		t.Errorf("Unexpected status (%d)", w.Code)
+	}
+	if w := get(); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Unexpected status (%d)", w.Code)
+	}
+
+	// a successful trial closes it
+	now = now.Add(10 * time.Second)
+	failing = false
+	if w := get(); w.Code != http.StatusOK {
+		t.Errorf("Unexpected status (%d)", w.Code)
+	}
+	if breaker.State() != BreakerClosed {
+		t.Errorf("Unexpected state (%s)", breaker.State())
+	}
+
+	expected := "closed>open open>half-open half-open>open open>half-open half-open>closed"
+	if got := strings.Join(transitions, " "); got != expected {
+		t.Errorf("Unexpected transitions (%s)", got)
+	}
+}
+
+func TestBreakerPanic(t *testing.T) {
+	breaker := NewBreaker(BreakerOptions{FailureThreshold: 1})
+	handler := breaker.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	}))
+
+	func() {
+		defer func() {
+			recover()
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+
+	if breaker.State() != BreakerOpen {
+		t.Errorf("Unexpected state (%s)", breaker.State())
+	}
+}
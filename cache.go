@@ -0,0 +1,337 @@
+package mux
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedResponse is a response stored by the Cache middleware.
+type CachedResponse struct {
+	Code   int
+	Header http.Header
+	Body   []byte
+	// Stored is the time the response was stored.
+	Stored time.Time
+	// Expires is the time until the response is fresh.
+	Expires time.Time
+	// StaleUntil is the time until the response may be served stale while
+	// it is revalidated.
+	StaleUntil time.Time
+}
+
+// CacheStore stores the responses of the Cache middleware. It has to be safe
+// for concurrent use. NewLRUCache returns an in-memory store, a shared store
+// (e.g. Redis) can be plugged in by implementing it.
+type CacheStore interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, res *CachedResponse)
+	Delete(key string)
+}
+
+// NewLRUCache returns an in-memory CacheStore holding up to capacity
+// responses, the least recently used response is evicted first.
+func NewLRUCache(capacity int) CacheStore {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &lruCache{capacity: capacity, items: map[string]*list.Element{}, order: list.New()}
+}
+
+// lruCache orders its entries from the most to the least recently used.
+type lruCache struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+}
+
+type lruEntry struct {
+	key string
+	res *CachedResponse
+}
+
+func (c *lruCache) Get(key string) (*CachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, found := c.items[key]
+	if !found {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*lruEntry).res, true
+}
+
+func (c *lruCache) Set(key string, res *CachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, found := c.items[key]; found {
+		e.Value.(*lruEntry).res = res
+		c.order.MoveToFront(e)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&lruEntry{key: key, res: res})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry).key)
+	}
+}
+
+func (c *lruCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, found := c.items[key]; found {
+		c.order.Remove(e)
+		delete(c.items, key)
+	}
+}
+
+// CacheOptions configures the Cache middleware.
+type CacheOptions struct {
+	// Store holds the responses, it defaults to NewLRUCache(1000).
+	Store CacheStore
+	// TTL is the time a response is fresh.
+	TTL time.Duration
+	// StaleWhileRevalidate is the time after the TTL a stale response is
+	// served while it is refreshed in the background.
+	StaleWhileRevalidate time.Duration
+	// Headers are the request headers which select the response besides
+	// the method and the URL, e.g. Accept or Authorization.
+	Headers []string
+	// Clock returns the current time, it defaults to time.Now.
+	Clock func() time.Time
+}
+
+// Cache returns a middleware which caches the 200 OK responses of GET
+// requests, keyed by the method, the URL and the selected headers, e.g.:
+//
+//     r.Get("/reports", reportsHandler).Use(mux.Cache(mux.CacheOptions{
+//         TTL:                  time.Minute,
+//         StaleWhileRevalidate: 10 * time.Minute,
+//         Headers:              []string{"Accept"},
+//     }))
+//
+// A fresh response is served from the store. A stale response within
+// StaleWhileRevalidate is served as well, while a single request refreshes
+// it in the background. The Cache-Control header is set to the TTL unless
+// the handler sets it, and responses with Cache-Control no-store or private
+// or with cookies aren't stored. Responses to requests with an Authorization
+// header are only stored with Cache-Control public, or if Authorization is
+// one of the Headers, so they aren't served to other users. Requests with
+// Cache-Control no-cache or no-store bypass the cache. The X-Cache header of
+// the response is HIT, STALE or MISS.
+func Cache(opts CacheOptions) MiddlewareFunc {
+	if opts.Store == nil {
+		opts.Store = NewLRUCache(1000)
+	}
+	if opts.Clock == nil {
+		opts.Clock = time.Now
+	}
+
+	c := &responseCache{opts: opts, refreshing: map[string]struct{}{}}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Method != http.MethodGet {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			key := c.key(req)
+			bypass := hasCacheDirective(req.Header.Get("Cache-Control"), "no-cache", "no-store")
+
+			if !bypass {
+				if res, found := c.opts.Store.Get(key); found {
+					now := c.opts.Clock()
+					switch {
+					case now.Before(res.Expires):
+						writeCachedResponse(w, res, now, "HIT")
+						return
+					case now.Before(res.StaleUntil):
+						c.refresh(key, next, req)
+						writeCachedResponse(w, res, now, "STALE")
+						return
+					}
+				}
+			}
+
+			buf := newResponseBuffer()
+			next.ServeHTTP(buf, req)
+
+			if !hasCacheDirective(req.Header.Get("Cache-Control"), "no-store") {
+				c.store(key, req, buf)
+			}
+
+			w.Header().Set("X-Cache", "MISS")
+			buf.writeTo(w)
+		})
+	}
+}
+
+// responseCache is the state of a Cache middleware.
+type responseCache struct {
+	opts CacheOptions
+	mu   sync.Mutex
+	// refreshing holds the keys which are refreshed in the background.
+	refreshing map[string]struct{}
+}
+
+// key returns the key of the response to a request.
+func (c *responseCache) key(req *http.Request) string {
+	var b strings.Builder
+	b.WriteString(req.Method)
+	b.WriteString(" ")
+	b.WriteString(req.Host)
+	b.WriteString(req.URL.RequestURI())
+	for _, name := range c.opts.Headers {
+		b.WriteString("\n")
+		b.WriteString(http.CanonicalHeaderKey(name))
+		b.WriteString(": ")
+		b.WriteString(strings.Join(req.Header.Values(name), ", "))
+	}
+	return b.String()
+}
+
+// keyedBy returns true if the header is part of the key.
+func (c *responseCache) keyedBy(name string) bool {
+	for _, header := range c.opts.Headers {
+		if strings.EqualFold(header, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// store stores a response if it is cacheable.
+func (c *responseCache) store(key string, req *http.Request, buf *responseBuffer) {
+	h := buf.Header()
+	if buf.code != http.StatusOK || !sharedResponse(h) {
+		return
+	}
+	if req.Header.Get("Authorization") != "" && !c.keyedBy("Authorization") && !hasCacheDirective(h.Get("Cache-Control"), "public") {
+		return
+	}
+
+	if h.Get("Cache-Control") == "" {
+		value := "max-age=" + strconv.Itoa(int(c.opts.TTL.Seconds()))
+		if c.opts.StaleWhileRevalidate > 0 {
+			value += ", stale-while-revalidate=" + strconv.Itoa(int(c.opts.StaleWhileRevalidate.Seconds()))
+		}
+		h.Set("Cache-Control", value)
+	}
+
+	now := c.opts.Clock()
+	c.opts.Store.Set(key, &CachedResponse{
+		Code:       buf.code,
+		Header:     h.Clone(),
+		Body:       buf.body.Bytes(),
+		Stored:     now,
+		Expires:    now.Add(c.opts.TTL),
+		StaleUntil: now.Add(c.opts.TTL + c.opts.StaleWhileRevalidate),
+	})
+}
+
+// refresh calls next in the background to replace a stale response, unless
+// it is refreshed already.
+func (c *responseCache) refresh(key string, next http.Handler, req *http.Request) {
+	c.mu.Lock()
+	if _, found := c.refreshing[key]; found {
+		c.mu.Unlock()
+		return
+	}
+	c.refreshing[key] = struct{}{}
+	c.mu.Unlock()
+
+	// the request may be canceled once the stale response is written, and
+	// the router reuses its params
+	req = detachParams(req.Clone(context.WithoutCancel(req.Context())))
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.refreshing, key)
+			c.mu.Unlock()
+		}()
+
+		buf := newResponseBuffer()
+		next.ServeHTTP(buf, req)
+		c.store(key, req, buf)
+	}()
+}
+
+// writeCachedResponse writes a stored response with its age.
+func writeCachedResponse(w http.ResponseWriter, res *CachedResponse, now time.Time, status string) {
+	h := w.Header()
+	for key, values := range res.Header {
+		h[key] = append([]string(nil), values...)
+	}
+	h.Set("Age", strconv.Itoa(int(now.Sub(res.Stored).Seconds())))
+	h.Set("X-Cache", status)
+
+	w.WriteHeader(res.Code)
+	w.Write(res.Body)
+}
+
+// hasCacheDirective returns true if a Cache-Control header contains one of
+// the directives.
+func hasCacheDirective(header string, directives ...string) bool {
+	for _, element := range strings.Split(header, ",") {
+		name, _, _ := strings.Cut(element, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		for _, directive := range directives {
+			if name == directive {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// responseBuffer records a response, e.g. to store or share it.
+type responseBuffer struct {
+	header      http.Header
+	code        int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func newResponseBuffer() *responseBuffer {
+	return &responseBuffer{header: http.Header{}, code: http.StatusOK}
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+func (b *responseBuffer) WriteHeader(code int) {
+	if b.wroteHeader || (code >= 100 && code <= 199) {
+		return
+	}
+	b.wroteHeader = true
+	b.code = code
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	b.wroteHeader = true
+	return b.body.Write(p)
+}
+
+// writeTo writes the recorded response to w.
+func (b *responseBuffer) writeTo(w http.ResponseWriter) {
+	h := w.Header()
+	for key, values := range b.header {
+		h[key] = values
+	}
+	w.WriteHeader(b.code)
+	w.Write(b.body.Bytes())
+}
@@ -0,0 +1,244 @@
+package mux
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Date(2024, time.March, 2, 22, 0, 0, 0, time.UTC)
+	var calls int32
+	refreshed := make(chan struct{}, 1)
+
+	r := Classic()
+	r.Get("/reports", func(w http.ResponseWriter, req *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		fmt.Fprintf(w, "report %d %s", n, req.Header.Get("Accept"))
+		if n == 3 {
+			refreshed <- struct{}{}
+		}
+	}).Use(Cache(CacheOptions{
+		TTL:                  time.Minute,
+		StaleWhileRevalidate: time.Hour,
+		Headers:              []string{"Accept"},
+		Clock: func() time.Time {
+			return now
+		},
+	}))
+
+	get := func(accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/reports", nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("text/csv")
+	if w.Body.String() != "report 1 text/csv" || w.Header().Get("X-Cache") != "MISS" || w.Header().Get("Cache-Control") != "max-age=60, stale-while-revalidate=3600" {
+		t.Fatalf("Unexpected response (%s, %v)", w.Body.String(), w.Header())
+	}
+
+	now = now.Add(30 * time.Second)
+	w = get("text/csv")
+	if w.Body.String() != "report 1 text/csv" || w.Header().Get("X-Cache") != "HIT" || w.Header().Get("Age") != "30" {
+		t.Errorf("Unexpected response (%s, %v)", w.Body.String(), w.Header())
+	}
+
+	// the selected headers are part of the key
+	w = get("application/json")
+	if w.Body.String() != "report 2 application/json" {
+		t.Errorf("Unexpected response (%s)", w.Body.String())
+	}
+
+	// a stale response is served while it is refreshed
+	now = now.Add(time.Minute)
+	w = get("text/csv")
+	if w.Body.String() != "report 1 text/csv" || w.Header().Get("X-Cache") != "STALE" {
+		t.Errorf("Unexpected response (%s, %v)", w.Body.String(), w.Header())
+	}
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("Response wasn't refreshed")
+	}
+
+	// the refresh is stored after the handler returned
+	for i := 0; i < 100; i++ {
+		if w = get("text/csv"); w.Header().Get("X-Cache") == "HIT" {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if w.Body.String() != "report 3 text/csv" {
+		t.Errorf("Unexpected response (%s)", w.Body.String())
+	}
+
+	// expired responses are replaced
+	now = now.Add(2 * time.Hour)
+	if w = get("text/csv"); w.Body.String() != "report 4 text/csv" || w.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("Unexpected response (%s, %v)", w.Body.String(), w.Header())
+	}
+}
+
+func TestCacheBypass(t *testing.T) {
+	var calls int32
+
+	r := Classic()
+	r.Use(Cache(CacheOptions{TTL: time.Minute}))
+	r.Get("/private", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Cache-Control", "private")
+		fmt.Fprint(w, atomic.AddInt32(&calls, 1))
+	})
+	r.Get("/cookie", func(w http.ResponseWriter, req *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "1"})
+		fmt.Fprint(w, atomic.AddInt32(&calls, 1))
+	})
+	r.Get("/missing", func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, fmt.Sprint(atomic.AddInt32(&calls, 1)), http.StatusNotFound)
+	})
+	r.Get("/public", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, atomic.AddInt32(&calls, 1))
+	})
+
+	for _, path := range []string{"/private", "/cookie", "/missing"} {
+		atomic.StoreInt32(&calls, 0)
+		for i := 0; i < 2; i++ {
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		}
+		if calls != 2 {
+			t.Errorf("Response of %s was cached", path)
+		}
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/public", nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/public", nil)
+	req.Header.Set("Cache-Control", "no-cache")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if calls != 2 {
+		t.Error("Cache wasn't bypassed")
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	c := NewLRUCache(2)
+	c.Set("a", &CachedResponse{Code: 1})
+	c.Set("b", &CachedResponse{Code: 2})
+	c.Get("a")
+	c.Set("c", &CachedResponse{Code: 3})
+
+	if _, found := c.Get("b"); found {
+		t.Error("Least recently used response wasn't evicted")
+	}
+	if res, found := c.Get("a"); !found || res.Code != 1 {
+		t.Error("Response a is missing")
+	}
+
+	c.Delete("a")
+	if _, found := c.Get("a"); found {
+		t.Error("Response a wasn't deleted")
+	}
+}
+
+func TestCacheAuthorization(t *testing.T) {
+	var calls int32
+
+	r := Classic()
+	r.Get("/me", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, atomic.AddInt32(&calls, 1), req.Header.Get("Authorization"))
+	}).Use(Cache(CacheOptions{TTL: time.Minute}))
+	r.Get("/shared", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		fmt.Fprint(w, atomic.AddInt32(&calls, 1))
+	}).Use(Cache(CacheOptions{TTL: time.Minute}))
+	r.Get("/keyed", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, atomic.AddInt32(&calls, 1), req.Header.Get("Authorization"))
+	}).Use(Cache(CacheOptions{TTL: time.Minute, Headers: []string{"Authorization"}}))
+
+	get := func(path, authorization string) string {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	// an authenticated response isn't served to another user
+	get("/me", "Bearer alice")
+	if body := get("/me", "Bearer mallory"); body != "2Bearer mallory" {
+		t.Errorf("Unexpected response (%s)", body)
+	}
+	if body := get("/me", ""); body != "3" {
+		t.Errorf("Unexpected response (%s)", body)
+	}
+
+	// public responses are shared
+	atomic.StoreInt32(&calls, 0)
+	get("/shared", "Bearer alice")
+	if body := get("/shared", "Bearer mallory"); body != "1" {
+		t.Errorf("Unexpected response (%s)", body)
+	}
+
+	// the credentials select the response
+	atomic.StoreInt32(&calls, 0)
+	get("/keyed", "Bearer alice")
+	if body := get("/keyed", "Bearer alice"); body != "1Bearer alice" {
+		t.Errorf("Unexpected response (%s)", body)
+	}
+	if body := get("/keyed", "Bearer mallory"); body != "2Bearer mallory" {
+		t.Errorf("Unexpected response (%s)", body)
+	}
+}
+
+func TestCacheRefreshParams(t *testing.T) {
+	now := time.Date(2024, time.March, 2, 22, 0, 0, 0, time.UTC)
+	var calls int32
+	proceed := make(chan struct{})
+	refreshed := make(chan struct{})
+
+	r := Classic()
+	r.Get("/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 2 {
+			defer close(refreshed)
+			<-proceed
+		}
+		fmt.Fprint(w, Param(req, "id"))
+	}).Use(Cache(CacheOptions{
+		TTL:                  time.Minute,
+		StaleWhileRevalidate: time.Hour,
+		Clock: func() time.Time {
+			return now
+		},
+	}))
+	r.Get("/other/:name", func(w http.ResponseWriter, req *http.Request) {})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	now = now.Add(2 * time.Minute)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	// reuses the pooled params of the stale request
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/other/bob", nil))
+	close(proceed)
+	<-refreshed
+
+	var w *httptest.ResponseRecorder
+	for i := 0; i < 100; i++ {
+		w = httptest.NewRecorder()
+		if r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/42", nil)); w.Header().Get("X-Cache") == "HIT" {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if w.Body.String() != "42" || w.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("Unexpected response (%s, %v)", w.Body.String(), w.Header())
+	}
+}
@@ -0,0 +1,131 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Coalesce returns a middleware which deduplicates concurrent identical GET
+// requests: the handler runs once and its response is written to every
+// request which arrived while it ran, e.g. for an expensive report:
+//
+//     r.Get("/reports/daily", dailyReportHandler).Use(mux.Coalesce("Accept"))
+//
+// Requests are identical if their host, URL and the headers are equal. The
+// requests with credentials, i.e. a Cookie or Authorization header, aren't
+// coalesced unless the header is one of the headers, so the response of one
+// user isn't served to another. Neither are responses which set cookies or
+// have Cache-Control no-store or private, the waiting requests call the
+// handler on their own then, as they do if it panics. The shared handler
+// call isn't canceled if one of the clients goes away.
+func Coalesce(headers ...string) MiddlewareFunc {
+	g := &callGroup{calls: map[string]*call{}}
+	var credentials []string
+	for _, name := range []string{"Authorization", "Cookie"} {
+		if !containsFold(headers, name) {
+			credentials = append(credentials, name)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Method != http.MethodGet || hasHeader(req, credentials) {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			var b strings.Builder
+			b.WriteString(req.Host)
+			b.WriteString(req.URL.RequestURI())
+			for _, name := range headers {
+				b.WriteString("\n")
+				b.WriteString(strings.Join(req.Header.Values(name), ", "))
+			}
+
+			leader := false
+			buf, ok := g.do(b.String(), func() *responseBuffer {
+				leader = true
+				buf := newResponseBuffer()
+				next.ServeHTTP(buf, req.Clone(context.WithoutCancel(req.Context())))
+				return buf
+			})
+			if !ok || (!leader && !sharedResponse(buf.header)) {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			// the recorded header is shared by the requests
+			h := w.Header()
+			for key, values := range buf.header {
+				h[key] = append([]string(nil), values...)
+			}
+			w.WriteHeader(buf.code)
+			w.Write(buf.body.Bytes())
+		})
+	}
+}
+
+// callGroup runs a function once for concurrent calls with the same key.
+type callGroup struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// call is a running or completed call of a callGroup.
+type call struct {
+	done chan struct{}
+	buf  *responseBuffer
+}
+
+// do calls fn or waits for the running call with the same key and returns
+// its result. ok is false if that call panicked.
+func (g *callGroup) do(key string, fn func() *responseBuffer) (buf *responseBuffer, ok bool) {
+	g.mu.Lock()
+	if c, found := g.calls[key]; found {
+		g.mu.Unlock()
+		<-c.done
+		return c.buf, c.buf != nil
+	}
+
+	c := &call{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+
+	c.buf = fn()
+	return c.buf, true
+}
+
+// sharedResponse reports whether a response may be served to other clients:
+// it doesn't set cookies and isn't private.
+func sharedResponse(h http.Header) bool {
+	return h.Get("Set-Cookie") == "" && !hasCacheDirective(h.Get("Cache-Control"), "no-store", "private")
+}
+
+// hasHeader reports whether the request has one of the headers.
+func hasHeader(req *http.Request, names []string) bool {
+	for _, name := range names {
+		if _, ok := req.Header[http.CanonicalHeaderKey(name)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold reports whether values contains s, ignoring case.
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
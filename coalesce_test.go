@@ -0,0 +1,174 @@
+package mux
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalesce(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+
+	r := Classic()
+	r.Get("/reports/:day", func(w http.ResponseWriter, req *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		<-release
+		w.Header().Set("X-Call", fmt.Sprint(n))
+		fmt.Fprintf(w, "report %s", GetVars(req).Get("day"))
+	}).Use(Coalesce())
+
+	var wg sync.WaitGroup
+	bodies := make([]string, 5)
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports/monday", nil))
+			bodies[i] = w.Body.String() + " " + w.Header().Get("X-Call")
+		}(i)
+	}
+
+	// wait until the first request runs the handler and the others wait
+	for atomic.LoadInt32(&calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for _, body := range bodies {
+		if body != bodies[0] {
+			t.Errorf("Unexpected bodies (%v)", bodies)
+			break
+		}
+	}
+	if n := atomic.LoadInt32(&calls); n >= int32(len(bodies)) {
+		t.Errorf("Requests weren't coalesced (%d calls)", n)
+	}
+
+	// completed calls aren't reused
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports/monday", nil))
+	if w.Header().Get("X-Call") == "1" {
+		t.Error("Completed call was reused")
+	}
+}
+
+func TestCoalescePanic(t *testing.T) {
+	g := &callGroup{calls: map[string]*call{}}
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	go func() {
+		defer func() {
+			recover()
+		}()
+		g.do("key", func() *responseBuffer {
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+
+	<-started
+	done := make(chan bool)
+	go func() {
+		_, ok := g.do("key", nil)
+		done <- ok
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	if ok := <-done; ok {
+		t.Error("Waiter got the result of a panicked call")
+	}
+}
+
+func TestCoalesceCredentials(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		fmt.Fprintf(w, "hello %s", req.Header.Get("Authorization"))
+	}
+
+	// the requests are coalesced only if the Authorization header is keyed
+	for _, headers := range [][]string{nil, {"Cookie"}, {"authorization"}} {
+		atomic.StoreInt32(&calls, 0)
+		release = make(chan struct{})
+
+		r := Classic()
+		r.Get("/me", handler).Use(Coalesce(headers...))
+
+		var wg sync.WaitGroup
+		bodies := make([]string, 2)
+		for i, user := range []string{"alice", "bob"} {
+			wg.Add(1)
+			go func(i int, user string) {
+				defer wg.Done()
+				req := httptest.NewRequest(http.MethodGet, "/me", nil)
+				req.Header.Set("Authorization", user)
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				bodies[i] = w.Body.String()
+			}(i, user)
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if bodies[0] != "hello alice" || bodies[1] != "hello bob" {
+			t.Errorf("Unexpected bodies for %v (%v)", headers, bodies)
+		}
+		if n := atomic.LoadInt32(&calls); n != 2 {
+			t.Errorf("Unexpected calls for %v (%d)", headers, n)
+		}
+	}
+}
+
+func TestCoalescePrivateResponse(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+
+	r := Classic()
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		if n == 1 {
+			<-release
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: fmt.Sprint(n)})
+	}).Use(Coalesce())
+
+	var wg sync.WaitGroup
+	cookies := make([]string, 3)
+	for i := range cookies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			cookies[i] = w.Header().Get("Set-Cookie")
+		}(i)
+	}
+
+	for atomic.LoadInt32(&calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	// the waiting requests get their own cookie
+	if cookies[0] == cookies[1] || cookies[0] == cookies[2] || cookies[1] == cookies[2] {
+		t.Errorf("Cookie was shared (%v)", cookies)
+	}
+}
@@ -0,0 +1,325 @@
+package mux
+
+import (
+	"net/http"
+	"strings"
+)
+
+// The combinators and constructors of this file compose matchers into
+// predicates which can be reused across routes, e.g.:
+//
+//     debug := mux.AnyOf(mux.HeadersPresent("X-Debug"), mux.Queries("debug", ""))
+//     internal := mux.All(mux.RemoteIPs("10.0.0.0/8"), mux.Not(mux.PathPrefix("/public")))
+//
+//     r.Get("/users", debugUsersHandler).AddMatcher(debug)
+//     r.PathPrefix("/").Methods("GET").AddMatcher(internal)
+//
+// A constructor with invalid arguments returns a matcher which never
+// matches. Its error is set on the route it is added to, like the errors of
+// the route methods.
+
+// Not returns a matcher which matches if m doesn't match, e.g. every path
+// except those below /static. It has the rank of m.
+func Not(m Matcher) Matcher {
+	if err := matcherError(m); err != nil {
+		return errMatcher{err: err}
+	}
+	return notMatcher{m: m}
+}
+
+// notMatcher inverts a matcher.
+type notMatcher struct {
+	m Matcher
+}
+
+func (m notMatcher) Match(r *http.Request) bool {
+	return !m.m.Match(r)
+}
+
+func (m notMatcher) Rank() Rank {
+	return m.m.Rank()
+}
+
+func (m notMatcher) Cost() Cost {
+	return costOf(m.m)
+}
+
+// AnyOf returns a matcher which matches if any of the matchers matches,
+// e.g. if a header is present or a query value is set. It matches nothing
+// if there are no matchers. It has the highest rank of the matchers, so it
+// is evaluated after the simpler matchers of a route.
+func AnyOf(matchers ...Matcher) Matcher {
+	if err := matchersError(matchers); err != nil {
+		return errMatcher{err: err}
+	}
+	return anyOfMatcher(matchers)
+}
+
+// Any is an alias of AnyOf.
+func Any(matchers ...Matcher) Matcher {
+	return AnyOf(matchers...)
+}
+
+// anyOfMatcher matches if any of its matchers matches.
+type anyOfMatcher []Matcher
+
+func (m anyOfMatcher) Match(r *http.Request) bool {
+	for _, matcher := range m {
+		if matcher.Match(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m anyOfMatcher) Rank() Rank {
+	return maxRank(m)
+}
+
+func (m anyOfMatcher) Cost() Cost {
+	return maxCost(m)
+}
+
+// All returns a matcher which matches if all of the matchers match. It
+// matches anything if there are no matchers. The matchers are evaluated in
+// order, it has the highest rank of the matchers.
+func All(matchers ...Matcher) Matcher {
+	if err := matchersError(matchers); err != nil {
+		return errMatcher{err: err}
+	}
+	return allMatcher(matchers)
+}
+
+// allMatcher matches if all of its matchers match.
+type allMatcher []Matcher
+
+func (m allMatcher) Match(r *http.Request) bool {
+	for _, matcher := range m {
+		if !matcher.Match(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m allMatcher) Rank() Rank {
+	return maxRank(m)
+}
+
+func (m allMatcher) Cost() Cost {
+	return maxCost(m)
+}
+
+// WithRank returns m with another rank, e.g. to evaluate a MatcherFunc
+// before the headers:
+//
+//     r.Get("/", handler).AddMatcher(mux.WithRank(mux.MatcherFunc(isBeta), mux.RankHost+1))
+//
+func WithRank(m Matcher, rank Rank) Matcher {
+	if err := matcherError(m); err != nil {
+		return m
+	}
+	return rankedMatcher{Matcher: m, rank: rank}
+}
+
+// rankedMatcher overrides the rank of a matcher.
+type rankedMatcher struct {
+	Matcher
+	rank Rank
+}
+
+func (m rankedMatcher) Rank() Rank {
+	return m.rank
+}
+
+func (m rankedMatcher) Cost() Cost {
+	return costOf(m.Matcher)
+}
+
+// maxRank returns the highest rank of the matchers, RankCustom if there are
+// none.
+func maxRank(matchers []Matcher) Rank {
+	if len(matchers) == 0 {
+		return RankCustom
+	}
+
+	rank := matchers[0].Rank()
+	for _, matcher := range matchers[1:] {
+		if matcher.Rank() > rank {
+			rank = matcher.Rank()
+		}
+	}
+	return rank
+}
+
+// maxCost returns the highest cost of the matchers, CostLookup if there are
+// none.
+func maxCost(matchers []Matcher) Cost {
+	cost := CostLookup
+	for _, matcher := range matchers {
+		if c := costOf(matcher); c > cost {
+			cost = c
+		}
+	}
+	return cost
+}
+
+// errMatcher never matches, it carries the error of an invalid matcher.
+type errMatcher struct {
+	err error
+}
+
+func (m errMatcher) Match(r *http.Request) bool {
+	return false
+}
+
+func (m errMatcher) Rank() Rank {
+	return RankCustom
+}
+
+func (m errMatcher) Cost() Cost {
+	return CostLookup
+}
+
+// matcherError returns the error of an invalid matcher. The combinators
+// return an invalid matcher if one of their matchers is invalid.
+func matcherError(m Matcher) error {
+	if m, ok := m.(errMatcher); ok {
+		return m.err
+	}
+	return nil
+}
+
+// matchersError returns the first error of the matchers.
+func matchersError(matchers []Matcher) error {
+	for _, m := range matchers {
+		if err := matcherError(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orErr returns m, or a matcher carrying err if err isn't nil.
+func orErr(m Matcher, err error) Matcher {
+	if err != nil {
+		return errMatcher{err: err}
+	}
+	return m
+}
+
+// Methods returns a matcher for HTTP methods, see Route.Methods.
+func Methods(methods ...string) Matcher {
+	return newMethodMatcher(methods...)
+}
+
+// Host returns a matcher for the host, see Route.Host.
+func Host(host string) Matcher {
+	return orErr(newHostMatcher(host))
+}
+
+// PathPrefix returns a matcher for a static path prefix. Unlike
+// Route.PathPrefix it matches regardless of the case and doesn't support
+// variables.
+func PathPrefix(prefix string) Matcher {
+	return pathPrefixFoldMatcher(strings.ToLower(prefix))
+}
+
+// pathPrefixFoldMatcher matches a path prefix regardless of its case.
+type pathPrefixFoldMatcher string
+
+func (m pathPrefixFoldMatcher) Match(r *http.Request) bool {
+	path := strings.ToLower(r.URL.Path)
+
+	if strings.HasSuffix(string(m), "/") {
+		return strings.HasPrefix(path, string(m))
+	}
+
+	return path == string(m) || strings.HasPrefix(path, string(m)+"/")
+}
+
+func (m pathPrefixFoldMatcher) Rank() Rank {
+	return RankPath
+}
+
+func (m pathPrefixFoldMatcher) Cost() Cost {
+	return CostCompare
+}
+
+// Headers returns a matcher for header values, see Route.Headers.
+func Headers(pairs ...string) Matcher {
+	return orErr(newHeaderMatcher(pairs...))
+}
+
+// HeadersRegex returns a matcher for header values matching regexes, see
+// Route.HeadersRegex.
+func HeadersRegex(pairs ...string) Matcher {
+	return orErr(newHeaderRegexMatcher(pairs...))
+}
+
+// HeadersPresent returns a matcher which requires the headers to be set,
+// see Route.HeadersPresent.
+func HeadersPresent(keys ...string) Matcher {
+	return newHeaderPresentMatcher(keys...)
+}
+
+// HeadersAbsent returns a matcher which requires the headers to be unset,
+// see Route.HeadersAbsent.
+func HeadersAbsent(keys ...string) Matcher {
+	return newHeaderAbsentMatcher(keys...)
+}
+
+// Queries returns a matcher for query values, see Route.Queries.
+func Queries(pairs ...string) Matcher {
+	return orErr(newQueryMatcher(pairs...))
+}
+
+// Schemes returns a matcher for URL schemes, see Route.Schemes.
+func Schemes(schemes ...string) Matcher {
+	return newSchemeMatcher(schemes...)
+}
+
+// ContentTypes returns a matcher for the media type of the Content-Type
+// header, see Route.ContentTypes.
+func ContentTypes(types ...string) Matcher {
+	return orErr(newContentTypeMatcher(types...))
+}
+
+// RemoteIPs returns a matcher for the IP of the client, see
+// Route.RemoteIPs.
+func RemoteIPs(cidrs ...string) Matcher {
+	return orErr(newRemoteIPMatcher(cidrs...))
+}
+
+// UserAgents returns a matcher for the User-Agent header, see
+// Route.UserAgents.
+func UserAgents(patterns ...string) Matcher {
+	return orErr(newUserAgentMatcher(patterns...))
+}
+
+// Flag returns a matcher for a feature flag, see Route.Flag.
+func Flag(name string, enabled func(*http.Request) bool) Matcher {
+	return orErr(newFlagMatcher(name, enabled))
+}
+
+// ClientCerts returns a matcher for the TLS client certificate, see
+// Route.ClientCerts.
+func ClientCerts(patterns ...string) Matcher {
+	return orErr(newClientCertMatcher(patterns...))
+}
+
+// APIKey returns a matcher for an API key, see Route.APIKey.
+func APIKey(source string, lookup func(key string) bool) Matcher {
+	return orErr(newAPIKeyMatcher(source, lookup))
+}
+
+// During returns a matcher which matches during the time windows, see
+// Route.During. It uses time.Now as clock.
+func During(windows ...TimeWindow) Matcher {
+	return orErr(newTimeWindowMatcher(nil, windows...))
+}
+
+// Cookies returns a matcher for cookie values, see Route.Cookies.
+func Cookies(pairs ...string) Matcher {
+	return orErr(newCookieMatcher(pairs...))
+}
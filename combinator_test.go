@@ -0,0 +1,159 @@
+package mux
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestNot(t *testing.T) {
+	prefix, err := newPathPrefixMatcher("/static", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+	matcher := Not(prefix)
+
+	if matcher.Rank() != RankPath {
+		t.Errorf("Unexpected rank (%d)", matcher.Rank())
+	}
+
+	tests := map[string]bool{
+		"/static/app.css": false,
+		"/users":          true,
+	}
+
+	for path, expected := range tests {
+		if matched := matcher.Match(&http.Request{URL: &url.URL{Path: path}}); matched != expected {
+			t.Errorf("Expected matched %v for %s", expected, path)
+		}
+	}
+}
+
+func TestAnyOf(t *testing.T) {
+	query, err := newQueryMatcher("debug", "")
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+	matcher := AnyOf(newHeaderPresentMatcher("X-Debug"), query)
+
+	if matcher.Rank() != RankQuery {
+		t.Errorf("Unexpected rank (%d)", matcher.Rank())
+	}
+
+	tests := []struct {
+		header  bool
+		query   string
+		matched bool
+	}{
+		{true, "", true},
+		{false, "debug=1", true},
+		{true, "debug=1", true},
+		{false, "", false},
+	}
+
+	for _, test := range tests {
+		request := &http.Request{Header: http.Header{}, URL: &url.URL{RawQuery: test.query}}
+		if test.header {
+			request.Header.Set("X-Debug", "1")
+		}
+
+		if matched := matcher.Match(request); matched != test.matched {
+			t.Errorf("Expected matched %v for %+v", test.matched, test)
+		}
+	}
+
+	if AnyOf().Match(&http.Request{}) {
+		t.Error("Unexpected match without matchers")
+	}
+}
+
+func TestAll(t *testing.T) {
+	matcher := All(Methods(http.MethodGet), HeadersPresent("X-Debug"))
+
+	if matcher.Rank() != RankHeader {
+		t.Errorf("Unexpected rank (%d)", matcher.Rank())
+	}
+
+	request := &http.Request{Method: http.MethodGet, Header: http.Header{}}
+	if matcher.Match(request) {
+		t.Error("Unexpected match")
+	}
+
+	request.Header.Set("X-Debug", "1")
+	if !matcher.Match(request) {
+		t.Error("Unexpected mismatch")
+	}
+
+	if !All().Match(request) {
+		t.Error("Unexpected mismatch without matchers")
+	}
+}
+
+func TestMatcherError(t *testing.T) {
+	tests := []Matcher{
+		Host("{tenant"),
+		Not(Queries("page")),
+		AnyOf(HeadersPresent("X-Debug"), Cookies("cohort", "#[a-")),
+		All(Methods(http.MethodGet), Any(RemoteIPs("10.0.0.0/40"))),
+	}
+
+	for _, matcher := range tests {
+		if err := matcherError(matcher); err == nil {
+			t.Errorf("Expected an error for %#v", matcher)
+		}
+		if matcher.Match(&http.Request{Header: http.Header{}, URL: &url.URL{}}) {
+			t.Errorf("Unexpected match for %#v", matcher)
+		}
+	}
+
+	if err := matcherError(All(Methods(http.MethodGet), UserAgents("bot"))); err != nil {
+		t.Errorf("Unexpected error (%v)", err)
+	}
+
+	r := Classic()
+	route := r.Get("/users", nil).AddMatcher(AnyOf(ContentTypes("json")))
+	var bre *BadRouteError
+	if !route.HasError() || !errors.As(route.GetError(), &bre) {
+		t.Errorf("Unexpected error (%v)", route.GetError())
+	}
+}
+
+func TestComposedMatchers(t *testing.T) {
+	r := Classic()
+	internal := All(RemoteIPs("10.0.0.0/8"), Not(PathPrefix("/Public")))
+
+	r.Register(r.PathPrefix("/").Methods(http.MethodGet).AddMatcher(internal).HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "internal")
+	}))
+
+	admin := r.PathPrefix("/admin").AddMatcher(internal).Subrouter()
+	admin.Get("/users", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "admin")
+	})
+
+	tests := []struct {
+		path       string
+		remoteAddr string
+		body       string
+	}{
+		{"/admin/users", "10.0.0.1:1234", "admin"},
+		{"/status", "10.0.0.1:1234", "internal"},
+		{"/public/status", "10.0.0.1:1234", "404 page not found\n"},
+		{"/admin/users", "203.0.113.7:1234", "404 page not found\n"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+		req.RemoteAddr = test.remoteAddr
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Body.String() != test.body {
+			t.Errorf("Expected body %q for %s from %s, got %q", test.body, test.path, test.remoteAddr, w.Body.String())
+		}
+	}
+}
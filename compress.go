@@ -0,0 +1,277 @@
+package mux
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Compressor compresses responses with a content coding. Gzip is built in,
+// other codings (e.g. br) can be added by implementing Compressor with a
+// third-party package.
+type Compressor interface {
+	// Encoding returns the content coding, e.g. "gzip".
+	Encoding() string
+	// NewWriter returns a writer which compresses to w. The response is
+	// complete when the writer is closed.
+	NewWriter(w io.Writer) io.WriteCloser
+}
+
+// Gzip returns a Compressor for gzip with a compression level of
+// compress/gzip, invalid levels fall back to gzip.DefaultCompression.
+func Gzip(level int) Compressor {
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		level = gzip.DefaultCompression
+	}
+	return &gzipCompressor{level: level}
+}
+
+// gzipCompressor reuses its writers.
+type gzipCompressor struct {
+	level int
+	pool  sync.Pool
+}
+
+func (c *gzipCompressor) Encoding() string {
+	return "gzip"
+}
+
+func (c *gzipCompressor) NewWriter(w io.Writer) io.WriteCloser {
+	if gw, ok := c.pool.Get().(*gzip.Writer); ok {
+		gw.Reset(w)
+		return &pooledGzipWriter{Writer: gw, pool: &c.pool}
+	}
+	gw, _ := gzip.NewWriterLevel(w, c.level)
+	return &pooledGzipWriter{Writer: gw, pool: &c.pool}
+}
+
+// pooledGzipWriter returns its writer to the pool when it is closed.
+type pooledGzipWriter struct {
+	*gzip.Writer
+	pool *sync.Pool
+}
+
+func (w *pooledGzipWriter) Close() error {
+	err := w.Writer.Close()
+	w.pool.Put(w.Writer)
+	return err
+}
+
+// compressedTypes are the media types which are compressed already, so
+// compressing them again only costs time.
+var compressedTypes = map[string]struct{}{
+	"application/gzip":             {},
+	"application/x-gzip":           {},
+	"application/zip":              {},
+	"application/zstd":             {},
+	"application/x-bzip2":          {},
+	"application/x-xz":             {},
+	"application/x-7z-compressed":  {},
+	"application/x-rar-compressed": {},
+	"application/pdf":              {},
+	"font/woff":                    {},
+	"font/woff2":                   {},
+}
+
+// isCompressedType returns true if responses of a content type are
+// compressed already, e.g. images, videos and archives.
+func isCompressedType(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+	if _, found := compressedTypes[mediaType]; found {
+		return true
+	}
+	if mediaType == "image/svg+xml" {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "image/") || strings.HasPrefix(mediaType, "video/") || strings.HasPrefix(mediaType, "audio/")
+}
+
+// Compress returns a middleware which compresses responses with the coding
+// of compressors the client prefers according to its Accept-Encoding
+// header, e.g.:
+//
+//     r.Use(mux.Compress(brotliCompressor{}, mux.Gzip(gzip.DefaultCompression)))
+//
+// The first compressor wins if the client accepts several codings equally.
+// It defaults to gzip. Responses which are already encoded, compressed
+// content types (e.g. images and archives), partial content and responses
+// without a body are passed on unchanged. The ResponseWriter of the handler
+// keeps implementing http.Flusher and http.Hijacker if the underlying one
+// does.
+func Compress(compressors ...Compressor) MiddlewareFunc {
+	if len(compressors) == 0 {
+		compressors = []Compressor{Gzip(gzip.DefaultCompression)}
+	}
+
+	offers := make([]string, len(compressors))
+	for k, c := range compressors {
+		offers[k] = c.Encoding()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			header := req.Header.Get("Accept-Encoding")
+			if strings.TrimSpace(header) == "" || req.Method == http.MethodHead {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			encoding := negotiate(header, offers, encodingSpecificity)
+			if encoding == "" {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			var compressor Compressor
+			for _, c := range compressors {
+				if c.Encoding() == encoding {
+					compressor = c
+					break
+				}
+			}
+
+			cw := &compressWriter{ResponseWriter: w, compressor: compressor, code: http.StatusOK}
+			defer cw.close()
+
+			next.ServeHTTP(cw, req)
+		})
+	}
+}
+
+// encodingSpecificity returns how specific a coding of an Accept-Encoding
+// header matches a content coding: 1 if they are equal and 0 for *.
+func encodingSpecificity(rng, offer string) int {
+	switch rng {
+	case offer:
+		return 1
+	case "*":
+		return 0
+	}
+	return -1
+}
+
+// compressWriter compresses the body of a response. It decides whether to
+// compress when the body starts, as the content type may be sniffed from
+// the first bytes.
+type compressWriter struct {
+	http.ResponseWriter
+	compressor  Compressor
+	code        int
+	wroteHeader bool
+	started     bool
+	hijacked    bool
+	// writer compresses the body, it is nil if the response isn't compressed.
+	writer io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+
+	// informational responses are passed on
+	if code >= 100 && code <= 199 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	w.wroteHeader = true
+	w.code = code
+
+	if !bodyAllowed(code) || w.Header().Get("Content-Type") != "" {
+		w.start(nil)
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.started {
+		w.start(b)
+	}
+	if w.writer != nil {
+		return w.writer.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// start writes the header and sets up the compression if the response is
+// compressible. b is the start of the body for sniffing the content type.
+func (w *compressWriter) start(b []byte) {
+	if w.started {
+		return
+	}
+	w.started = true
+
+	h := w.Header()
+	if h.Get("Content-Type") == "" && len(b) != 0 && bodyAllowed(w.code) {
+		h.Set("Content-Type", http.DetectContentType(b))
+	}
+
+	if bodyAllowed(w.code) && w.code != http.StatusPartialContent && h.Get("Content-Encoding") == "" && !isCompressedType(h.Get("Content-Type")) {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", w.compressor.Encoding())
+		w.writer = w.compressor.NewWriter(w.ResponseWriter)
+	}
+
+	w.ResponseWriter.WriteHeader(w.code)
+}
+
+// close completes the compressed body.
+func (w *compressWriter) close() {
+	if w.hijacked || !w.wroteHeader {
+		return
+	}
+	w.start(nil)
+	if w.writer != nil {
+		w.writer.Close()
+	}
+}
+
+// Flush sends the compressed data written so far to the client.
+func (w *compressWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.start(nil)
+
+	if f, ok := w.writer.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection, e.g. for websockets.
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("mux: %T doesn't implement http.Hijacker", w.ResponseWriter)
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the wrapped ResponseWriter, e.g. for http.ResponseController.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// bodyAllowed returns true if a response with the status may have a body.
+func bodyAllowed(code int) bool {
+	return code >= 200 && code != http.StatusNoContent && code != http.StatusNotModified
+}
@@ -0,0 +1,157 @@
+package mux
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// identityCompressor "compresses" by upper-casing, to test the negotiation.
+type identityCompressor struct{}
+
+func (identityCompressor) Encoding() string {
+	return "upper"
+}
+
+func (identityCompressor) NewWriter(w io.Writer) io.WriteCloser {
+	return upperWriter{w}
+}
+
+type upperWriter struct {
+	w io.Writer
+}
+
+func (u upperWriter) Write(b []byte) (int, error) {
+	return u.w.Write([]byte(strings.ToUpper(string(b))))
+}
+
+func (u upperWriter) Close() error {
+	return nil
+}
+
+func TestCompress(t *testing.T) {
+	body := strings.Repeat("hello mux ", 100)
+
+	r := Classic()
+	r.Use(Compress())
+	r.Get("/text", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Length", "1000")
+		io.WriteString(w, body)
+	})
+	r.Get("/image", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		io.WriteString(w, body)
+	})
+	r.Get("/encoded", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Encoding", "br")
+		io.WriteString(w, body)
+	})
+	r.Get("/empty", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/text", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Content-Length") != "" || w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("Unexpected header (%v)", w.Header())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("Unexpected content type (%s)", ct)
+	}
+
+	gr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+	if b, _ := io.ReadAll(gr); string(b) != body {
+		t.Errorf("Unexpected body (%s)", b)
+	}
+
+	tests := []struct {
+		path           string
+		acceptEncoding string
+		code           int
+	}{
+		{"/text", "", http.StatusOK},
+		{"/text", "gzip;q=0, br", http.StatusOK},
+		{"/image", "gzip", http.StatusOK},
+		{"/encoded", "gzip", http.StatusOK},
+		{"/empty", "gzip", http.StatusNoContent},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+		req.Header.Set("Accept-Encoding", test.acceptEncoding)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != test.code || w.Header().Get("Content-Encoding") == "gzip" {
+			t.Errorf("Unexpected response for %s (%q): %d %v", test.path, test.acceptEncoding, w.Code, w.Header())
+		}
+	}
+}
+
+func TestCompressNegotiation(t *testing.T) {
+	r := Classic()
+	r.Use(Compress(identityCompressor{}, Gzip(gzip.BestSpeed)))
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, "hello")
+	})
+
+	tests := []struct {
+		acceptEncoding string
+		encoding       string
+	}{
+		{"gzip, upper", "upper"},
+		{"gzip, upper;q=0.5", "gzip"},
+		{"*", "upper"},
+		{"identity", ""},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", test.acceptEncoding)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if encoding := w.Header().Get("Content-Encoding"); encoding != test.encoding {
+			t.Errorf("Expected %q for %q, got %q", test.encoding, test.acceptEncoding, encoding)
+		}
+	}
+}
+
+func TestCompressFlush(t *testing.T) {
+	var flusher bool
+
+	r := Classic()
+	r.Use(Compress())
+	r.Get("/events", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: 1\n\n")
+		f, ok := w.(http.Flusher)
+		if ok {
+			f.Flush()
+		}
+		flusher = ok
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if !flusher || !w.Flushed {
+		t.Fatal("Response wasn't flushed")
+	}
+
+	if _, ok := interface{}(&compressWriter{}).(http.Hijacker); !ok {
+		t.Error("compressWriter isn't a http.Hijacker")
+	}
+}
@@ -2,8 +2,10 @@ package mux
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 )
 
@@ -13,6 +15,15 @@ const (
 	queriesKey contextKey = iota
 	routeKey
 	varsKey
+	recoveredKey
+	traceKey
+	localeKey
+	clientIPKey
+	claimsKey
+	csrfKey
+	requestIDKey
+	spanKey
+	fallbackKey
 )
 
 // GetQueries returns the query variables for the current request.
@@ -34,6 +45,17 @@ func CurrentRoute(r *http.Request) RouteInterface {
 	return nil
 }
 
+// RouteMeta returns the metadata for key of the matched route for the
+// current request, see Route.WithMeta. It is nil if there is no route or
+// metadata.
+func RouteMeta(r *http.Request, key string) interface{} {
+	if route := CurrentRoute(r); route != nil {
+		value, _ := route.GetMeta(key)
+		return value
+	}
+	return nil
+}
+
 func AddQueries(r *http.Request) *http.Request {
 
 	queries, err := extractQueries(r)
@@ -50,13 +72,130 @@ func AddCurrentRoute(r *http.Request, val interface{}) *http.Request {
 }
 
 // GetVars returns the route variables for the current request, if any.
+// Variables captured by the router are copied into a new map on every call,
+// which is safe to keep after the handler returns.
 func GetVars(r *http.Request) Vars {
-	if rv := contextGet(r, varsKey); rv != nil {
-		return rv.(Vars)
+	switch rv := contextGet(r, varsKey).(type) {
+	case Vars:
+		return rv
+	case *params:
+		return rv.vars()
+	}
+	return nil
+}
+
+// Param returns the value of the route variable with the given name for the
+// current request. An empty string is returned if the variable is not set.
+//
+// Param doesn't allocate: the router captures the variables of a path whose
+// variables take whole segments (e.g. /users/:id or /static/*filepath) into
+// a pooled slice, which is reused once the handler returns. The returned
+// string stays valid, but the request must not be used to read variables
+// after the handler returns (e.g. from a goroutine started by the handler).
+func Param(r *http.Request, name string) string {
+	if p, ok := contextGet(r, varsKey).(*params); ok {
+		value, _ := p.get(name)
+		return value
+	}
+	return GetVars(r).Get(name)
+}
+
+// ParamValue returns the converted value of the route variable with the
+// given name for the current request, e.g. ParamValue(req, ":uuid"). It is
+// nil if the variable isn't set or its type has no parse function, see
+// Router.RegisterParamType.
+func ParamValue(r *http.Request, name string) interface{} {
+	if p, ok := contextGet(r, varsKey).(*params); ok {
+		return p.typed(name)
 	}
 	return nil
 }
 
+// errParamMissing is the cause of a BadParamError for a missing variable.
+var errParamMissing = errors.New("variable is missing")
+
+// lookupParam returns the value of a route variable and whether it is set.
+func lookupParam(r *http.Request, name string) (string, bool) {
+	if p, ok := contextGet(r, varsKey).(*params); ok {
+		return p.get(name)
+	}
+	value, found := GetVars(r)[name]
+	return value, found
+}
+
+// ParamInt returns the route variable with the given name as int. A
+// *BadParamError is returned if the variable is missing or not an int.
+func ParamInt(r *http.Request, name string) (int, error) {
+	value, found := lookupParam(r, name)
+	if !found {
+		return 0, NewBadParamError(name, value, "int", errParamMissing)
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, NewBadParamError(name, value, "int", err)
+	}
+
+	return i, nil
+}
+
+// ParamBool returns the route variable with the given name as bool, see
+// strconv.ParseBool for the accepted values. A *BadParamError is returned if
+// the variable is missing or not a bool.
+func ParamBool(r *http.Request, name string) (bool, error) {
+	value, found := lookupParam(r, name)
+	if !found {
+		return false, NewBadParamError(name, value, "bool", errParamMissing)
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, NewBadParamError(name, value, "bool", err)
+	}
+
+	return b, nil
+}
+
+// ParamUUID returns the route variable with the given name as UUID in its
+// canonical lowercase form (e.g. 123e4567-e89b-12d3-a456-426614174000). A
+// *BadParamError is returned if the variable is missing or not a UUID.
+func ParamUUID(r *http.Request, name string) (string, error) {
+	value, found := lookupParam(r, name)
+	if !found {
+		return "", NewBadParamError(name, value, "uuid", errParamMissing)
+	}
+
+	if !isUUID(value) {
+		return "", NewBadParamError(name, value, "uuid", errors.New("expected the form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"))
+	}
+
+	return strings.ToLower(value), nil
+}
+
+// isUUID returns true if s is a UUID in the form
+// xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx with hex digits.
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			c := s[i]
+			if !('0' <= c && c <= '9') && !('a' <= c && c <= 'f') && !('A' <= c && c <= 'F') {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
 func AddVars(r *http.Request, val interface{}) *http.Request {
 	return contextSet(r, varsKey, val)
 }
@@ -1,6 +1,7 @@
 package mux
 
 import (
+	"errors"
 	"net/http"
 	"net/url"
 	"testing"
@@ -30,6 +31,70 @@ func TestGetVarsFail(t *testing.T) {
 	}
 }
 
+func TestParamFail(t *testing.T) {
+	r := &http.Request{}
+
+	if value := Param(r, ":number"); value != "" {
+		t.Errorf("Unexpected value (%v)", value)
+	}
+}
+
+func TestParam(t *testing.T) {
+	r := &http.Request{}
+	r = AddVars(r, Vars{":number": "2"})
+
+	if value := Param(r, ":number"); value != "2" {
+		t.Errorf("Unexpected value (%v)", value)
+	}
+}
+
+func TestTypedParams(t *testing.T) {
+	r := &http.Request{}
+	r = AddVars(r, Vars{
+		"id":     "42",
+		"name":   "donutloop",
+		"active": "true",
+		"uuid":   "123E4567-E89B-12D3-A456-426614174000",
+	})
+
+	if value, err := ParamInt(r, "id"); err != nil || value != 42 {
+		t.Errorf("Unexpected value (%v, %v)", value, err)
+	}
+
+	if value, err := ParamBool(r, "active"); err != nil || !value {
+		t.Errorf("Unexpected value (%v, %v)", value, err)
+	}
+
+	if value, err := ParamUUID(r, "uuid"); err != nil || value != "123e4567-e89b-12d3-a456-426614174000" {
+		t.Errorf("Unexpected value (%v, %v)", value, err)
+	}
+}
+
+func TestTypedParamsFail(t *testing.T) {
+	r := &http.Request{}
+	r = AddVars(r, Vars{"name": "donutloop", "uuid": "123e4567-e89b-12d3-a456-42661417400g"})
+
+	tests := []struct {
+		name  string
+		param func() error
+	}{
+		{"name", func() error { _, err := ParamInt(r, "name"); return err }},
+		{"missing", func() error { _, err := ParamInt(r, "missing"); return err }},
+		{"name", func() error { _, err := ParamBool(r, "name"); return err }},
+		{"uuid", func() error { _, err := ParamUUID(r, "uuid"); return err }},
+		{"name", func() error { _, err := ParamUUID(r, "name"); return err }},
+	}
+
+	for _, test := range tests {
+		err := test.param()
+
+		var bpe *BadParamError
+		if !errors.As(err, &bpe) || bpe.Name != test.name || bpe.StatusCode() != http.StatusBadRequest {
+			t.Errorf("Unexpected error (%v)", err)
+		}
+	}
+}
+
 func BenchmarkExtractQueries(b *testing.B) {
 	request := &http.Request{
 		URL: &url.URL{
@@ -41,3 +106,11 @@ func BenchmarkExtractQueries(b *testing.B) {
 		extractQueries(request)
 	}
 }
+
+func TestRouteMetaFail(t *testing.T) {
+	r := &http.Request{}
+
+	if value := RouteMeta(r, "scope"); value != nil {
+		t.Errorf("Unexpected value (%v)", value)
+	}
+}
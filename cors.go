@@ -0,0 +1,128 @@
+package mux
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CORSOptions configures the Cross-Origin Resource Sharing of a router, see
+// Router.CORS.
+type CORSOptions struct {
+	// AllowedOrigins are the origins (e.g. https://example.com) which may
+	// access the routes. "*" allows any origin. No origin is allowed if it is
+	// empty.
+	AllowedOrigins []string
+	// AllowedMethods are the methods allowed by preflight requests. If it is
+	// empty, the methods of the routes registered for the path are allowed.
+	AllowedMethods []string
+	// AllowedHeaders are the request headers allowed by preflight requests.
+	// If it is empty, the headers requested by the preflight are allowed.
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers the client may read.
+	ExposedHeaders []string
+	// AllowCredentials allows requests with cookies or authorization.
+	AllowCredentials bool
+	// MaxAge is the number of seconds a preflight response may be cached.
+	// It is omitted if it is 0.
+	MaxAge int
+}
+
+// allowsOrigin returns true if origin is allowed.
+func (o *CORSOptions) allowsOrigin(origin string) bool {
+	for _, allowed := range o.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowOrigin sets the origin headers of a response to an allowed origin.
+// The origin is echoed instead of "*" if credentials are allowed, as
+// browsers reject "*" for them.
+func (o *CORSOptions) allowOrigin(w http.ResponseWriter, origin string) {
+	w.Header().Add("Vary", "Origin")
+
+	if o.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		return
+	}
+
+	for _, allowed := range o.AllowedOrigins {
+		if allowed == "*" {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			return
+		}
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+}
+
+// handleCORS adds the CORS headers to the response of a cross-origin
+// request. It answers preflight requests and returns true for them.
+func (r *Router) handleCORS(w http.ResponseWriter, req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+
+	requestMethod := req.Header.Get("Access-Control-Request-Method")
+	preflight := req.Method == http.MethodOptions && requestMethod != ""
+
+	if !r.CORS.allowsOrigin(origin) {
+		if preflight {
+			w.Header().Add("Vary", "Origin")
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return preflight
+	}
+
+	if !preflight {
+		r.CORS.allowOrigin(w, origin)
+		if len(r.CORS.ExposedHeaders) != 0 {
+			w.Header().Set("Access-Control-Expose-Headers", strings.Join(r.CORS.ExposedHeaders, ", "))
+		}
+		return false
+	}
+
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	methods := r.CORS.AllowedMethods
+	if len(methods) == 0 {
+		methods = r.allowedMethods(r.matchRequest(req))
+	}
+
+	if !containsMethod(methods, requestMethod) {
+		w.Header().Add("Vary", "Origin")
+		w.WriteHeader(http.StatusNoContent)
+		return true
+	}
+
+	r.CORS.allowOrigin(w, origin)
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+
+	if len(r.CORS.AllowedHeaders) != 0 {
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(r.CORS.AllowedHeaders, ", "))
+	} else if headers := req.Header.Get("Access-Control-Request-Headers"); headers != "" {
+		w.Header().Set("Access-Control-Allow-Headers", headers)
+	}
+
+	if r.CORS.MaxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(r.CORS.MaxAge))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// containsMethod returns true if methods contains method.
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSPreflight(t *testing.T) {
+	r := Classic()
+	r.CORS = &CORSOptions{
+		AllowedOrigins: []string{"https://example.com"},
+		MaxAge:         600,
+	}
+	r.Get("/users/:id", nil)
+	r.Put("/users/:id", nil)
+	r.Delete("/users/:id", nil)
+
+	req := httptest.NewRequest(http.MethodOptions, "/users/42", nil)
+	req.Header.Set("Origin", "https://example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+	req.Header.Set("Access-Control-Request-Headers", "Content-Type")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Unexpected status code (%d)", w.Code)
+	}
+
+	expected := map[string]string{
+		"Access-Control-Allow-Origin":  "https://example.com",
+		"Access-Control-Allow-Methods": "DELETE, GET, PUT",
+		"Access-Control-Allow-Headers": "Content-Type",
+		"Access-Control-Max-Age":       "600",
+	}
+	for key, value := range expected {
+		if header := w.Header().Get(key); header != value {
+			t.Errorf("Expected %s %q, got %q", key, value, header)
+		}
+	}
+}
+
+func TestCORSPreflightRejected(t *testing.T) {
+	r := Classic()
+	r.CORS = &CORSOptions{AllowedOrigins: []string{"https://example.com"}}
+	r.Get("/users/:id", nil)
+
+	tests := []struct {
+		origin string
+		method string
+	}{
+		{"https://evil.com", http.MethodGet},
+		{"https://example.com", http.MethodPost},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodOptions, "/users/42", nil)
+		req.Header.Set("Origin", test.origin)
+		req.Header.Set("Access-Control-Request-Method", test.method)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Errorf("Unexpected response (%d, %v)", w.Code, w.Header())
+		}
+	}
+}
+
+func TestCORSRequest(t *testing.T) {
+	r := Classic()
+	r.CORS = &CORSOptions{
+		AllowedOrigins: []string{"*"},
+		ExposedHeaders: []string{"X-Total-Count"},
+	}
+	r.Get("/users", func(w http.ResponseWriter, req *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("Origin", "https://example.com")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Unexpected status code (%d)", w.Code)
+	}
+
+	if origin := w.Header().Get("Access-Control-Allow-Origin"); origin != "*" {
+		t.Errorf("Unexpected origin (%s)", origin)
+	}
+
+	if exposed := w.Header().Get("Access-Control-Expose-Headers"); exposed != "X-Total-Count" {
+		t.Errorf("Unexpected exposed headers (%s)", exposed)
+	}
+}
+
+func TestCORSCredentials(t *testing.T) {
+	r := Classic()
+	r.CORS = &CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true}
+	r.Get("/users", func(w http.ResponseWriter, req *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("Origin", "https://example.com")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if origin := w.Header().Get("Access-Control-Allow-Origin"); origin != "https://example.com" {
+		t.Errorf("Unexpected origin (%s)", origin)
+	}
+
+	if credentials := w.Header().Get("Access-Control-Allow-Credentials"); credentials != "true" {
+		t.Errorf("Unexpected credentials (%s)", credentials)
+	}
+}
+
+func TestCORSWithoutOrigin(t *testing.T) {
+	r := Classic()
+	r.CORS = &CORSOptions{AllowedOrigins: []string{"*"}}
+	r.Get("/users", func(w http.ResponseWriter, req *http.Request) {})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	if origin := w.Header().Get("Access-Control-Allow-Origin"); origin != "" {
+		t.Errorf("Unexpected origin (%s)", origin)
+	}
+}
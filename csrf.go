@@ -0,0 +1,183 @@
+package mux
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+// CSRFOptions configures the CSRF middleware.
+type CSRFOptions struct {
+	// Secret signs the token cookies if it is set, so they can't be forged.
+	// Signing alone doesn't prevent cookie tossing: an attacker controlling
+	// a sibling subdomain can plant their own valid token in a cookie and a
+	// form. Set Session as well to bind the tokens to the session.
+	Secret []byte
+	// Session returns the session identifier of a request, e.g. the value
+	// of the session cookie, empty if it has none. The signature of a token
+	// covers the session, so a token issued for another session isn't
+	// accepted. It requires a Secret.
+	Session func(req *http.Request) string
+	// CookieName is the name of the token cookie, it defaults to _csrf.
+	CookieName string
+	// CookiePath is the path of the token cookie, it defaults to /.
+	CookiePath string
+	// Header is the request header with the token, it defaults to
+	// X-CSRF-Token.
+	Header string
+	// FormField is the form field with the token, it defaults to
+	// csrf_token.
+	FormField string
+	// HeaderOnly only requires the header to be present on unsafe requests,
+	// without a token cookie. It fits APIs called by scripts, as cross-site
+	// forms can't set headers and cross-origin scripts need a CORS
+	// preflight to do so.
+	HeaderOnly bool
+	// ErrorHandler answers the rejected requests, it defaults to a 403
+	// Forbidden response.
+	ErrorHandler http.Handler
+}
+
+// CSRF returns a middleware which protects the unsafe methods (all except
+// GET, HEAD, OPTIONS and TRACE) against cross-site request forgery, e.g.:
+//
+//     account := r.PathPrefix("/account").Subrouter()
+//     account.Use(mux.CSRF(mux.CSRFOptions{Secret: secret}))
+//
+// By default it uses double-submit cookies: every response without a token
+// cookie sets one, and unsafe requests have to send the token of the cookie
+// in the header or the form field as well. Handlers get the token for their
+// forms and scripts with CSRFToken. Rejected requests are answered with 403
+// Forbidden.
+//
+// It panics if Session is set without a Secret.
+func CSRF(opts CSRFOptions) MiddlewareFunc {
+	if opts.Session != nil && opts.Secret == nil {
+		panic("mux: CSRF session binding requires a secret")
+	}
+	if opts.CookieName == "" {
+		opts.CookieName = "_csrf"
+	}
+	if opts.CookiePath == "" {
+		opts.CookiePath = "/"
+	}
+	if opts.Header == "" {
+		opts.Header = "X-CSRF-Token"
+	}
+	if opts.FormField == "" {
+		opts.FormField = "csrf_token"
+	}
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			safe := isSafeMethod(req.Method)
+
+			if opts.HeaderOnly {
+				if !safe && req.Header.Get(opts.Header) == "" {
+					opts.ErrorHandler.ServeHTTP(w, req)
+					return
+				}
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			token := opts.cookieToken(req)
+			if !safe {
+				submitted := req.Header.Get(opts.Header)
+				if submitted == "" {
+					submitted = req.PostFormValue(opts.FormField)
+				}
+				if token == "" || subtle.ConstantTimeCompare([]byte(submitted), []byte(token)) != 1 {
+					opts.ErrorHandler.ServeHTTP(w, req)
+					return
+				}
+			}
+
+			if token == "" {
+				token = newCSRFToken()
+				http.SetCookie(w, &http.Cookie{
+					Name:     opts.CookieName,
+					Value:    opts.sign(token, opts.session(req)),
+					Path:     opts.CookiePath,
+					Secure:   requestScheme(req) == "https",
+					HttpOnly: true,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+
+			next.ServeHTTP(w, contextSet(req, csrfKey, token))
+		})
+	}
+}
+
+// CSRFToken returns the token of the CSRF middleware for the current
+// request, e.g. for a hidden form field or a meta tag read by scripts.
+func CSRFToken(r *http.Request) string {
+	if rv := contextGet(r, csrfKey); rv != nil {
+		return rv.(string)
+	}
+	return ""
+}
+
+// cookieToken returns the token of the cookie, empty if there is none or
+// its signature is invalid.
+func (opts CSRFOptions) cookieToken(req *http.Request) string {
+	cookie, err := req.Cookie(opts.CookieName)
+	if err != nil || cookie.Value == "" {
+		return ""
+	}
+	if opts.Secret == nil {
+		return cookie.Value
+	}
+
+	token, _, found := strings.Cut(cookie.Value, ".")
+	if !found || !hmac.Equal([]byte(opts.sign(token, opts.session(req))), []byte(cookie.Value)) {
+		return ""
+	}
+	return token
+}
+
+// session returns the session identifier of a request, empty without
+// session binding.
+func (opts CSRFOptions) session(req *http.Request) string {
+	if opts.Session == nil {
+		return ""
+	}
+	return opts.Session(req)
+}
+
+// sign appends the signature of token and session if there is a secret.
+func (opts CSRFOptions) sign(token, session string) string {
+	if opts.Secret == nil {
+		return token
+	}
+	mac := hmac.New(sha256.New, opts.Secret)
+	mac.Write([]byte(session))
+	mac.Write([]byte{0})
+	mac.Write([]byte(token))
+	return token + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func newCSRFToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// isSafeMethod returns true for the methods which don't change state.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
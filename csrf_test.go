@@ -0,0 +1,148 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func csrfRouter(opts CSRFOptions) *Router {
+	r := Classic()
+	account := r.PathPrefix("/account").Subrouter()
+	account.Use(CSRF(opts))
+	account.Get("/form", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(CSRFToken(req)))
+	})
+	account.Post("/form", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("saved"))
+	})
+	return r
+}
+
+func TestCSRF(t *testing.T) {
+	r := csrfRouter(CSRFOptions{Secret: []byte("secret")})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/account/form", nil))
+	cookies := w.Result().Cookies()
+	if w.Code != http.StatusOK || len(cookies) != 1 || cookies[0].Name != "_csrf" || !cookies[0].HttpOnly {
+		t.Fatalf("Unexpected token cookie (%d, %v)", w.Code, cookies)
+	}
+	token := w.Body.String()
+	if token == "" || !strings.HasPrefix(cookies[0].Value, token+".") {
+		t.Fatalf("Unexpected token (%s, %s)", token, cookies[0].Value)
+	}
+
+	post := func(cookie, header, field string) int {
+		form := url.Values{}
+		if field != "" {
+			form.Set("csrf_token", field)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/account/form", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if cookie != "" {
+			req.AddCookie(&http.Cookie{Name: "_csrf", Value: cookie})
+		}
+		if header != "" {
+			req.Header.Set("X-CSRF-Token", header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	tests := []struct {
+		name                  string
+		cookie, header, field string
+		code                  int
+	}{
+		{"header", cookies[0].Value, token, "", http.StatusOK},
+		{"form field", cookies[0].Value, "", token, http.StatusOK},
+		{"no token", cookies[0].Value, "", "", http.StatusForbidden},
+		{"no cookie", "", token, "", http.StatusForbidden},
+		{"wrong token", cookies[0].Value, "forged", "", http.StatusForbidden},
+		{"unsigned cookie", token, token, "", http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		if code := post(test.cookie, test.header, test.field); code != test.code {
+			t.Errorf("Unexpected status for %s (%d)", test.name, code)
+		}
+	}
+
+	// a request with a valid cookie keeps it
+	req := httptest.NewRequest(http.MethodGet, "/account/form", nil)
+	req.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Body.String() != token || len(w.Result().Cookies()) != 0 {
+		t.Errorf("Unexpected response (%s, %v)", w.Body.String(), w.Result().Cookies())
+	}
+}
+
+func TestCSRFSession(t *testing.T) {
+	r := csrfRouter(CSRFOptions{
+		Secret: []byte("secret"),
+		Session: func(req *http.Request) string {
+			if cookie, err := req.Cookie("session"); err == nil {
+				return cookie.Value
+			}
+			return ""
+		},
+	})
+
+	// issues a token cookie for a session
+	issue := func(session string) (*http.Cookie, string) {
+		req := httptest.NewRequest(http.MethodGet, "/account/form", nil)
+		req.AddCookie(&http.Cookie{Name: "session", Value: session})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Result().Cookies()[0], w.Body.String()
+	}
+	post := func(session string, cookie *http.Cookie, token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/account/form", nil)
+		req.AddCookie(&http.Cookie{Name: "session", Value: session})
+		req.AddCookie(cookie)
+		req.Header.Set("X-CSRF-Token", token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	victimCookie, victimToken := issue("victim")
+	if code := post("victim", victimCookie, victimToken); code != http.StatusOK {
+		t.Errorf("Unexpected status (%d)", code)
+	}
+
+	// a token of the attacker's session planted in the victim's browser
+	attackerCookie, attackerToken := issue("attacker")
+	if code := post("victim", attackerCookie, attackerToken); code != http.StatusForbidden {
+		t.Errorf("Unexpected status for a tossed cookie (%d)", code)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic for a session without secret")
+		}
+	}()
+	CSRF(CSRFOptions{Session: func(req *http.Request) string { return "" }})
+}
+
+func TestCSRFHeaderOnly(t *testing.T) {
+	r := csrfRouter(CSRFOptions{HeaderOnly: true})
+
+	for header, code := range map[string]int{"1": http.StatusOK, "": http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodPost, "/account/form", nil)
+		if header != "" {
+			req.Header.Set("X-CSRF-Token", header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != code || len(w.Result().Cookies()) != 0 {
+			t.Errorf("Unexpected response for %q (%d, %v)", header, w.Code, w.Result().Cookies())
+		}
+	}
+}
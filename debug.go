@@ -0,0 +1,162 @@
+package mux
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// debugRoute is the JSON representation of a route rendered by DebugHandler.
+type debugRoute struct {
+	Method   string   `json:"method"`
+	Order    int      `json:"order"`
+	Path     string   `json:"path"`
+	Kind     string   `json:"kind"`
+	Name     string   `json:"name,omitempty"`
+	Methods  []string `json:"methods"`
+	Matchers []string `json:"matchers"`
+	Error    string   `json:"error,omitempty"`
+	Disabled bool     `json:"disabled,omitempty"`
+}
+
+// DebugHandler returns a handler which renders the registered routes as
+// JSON, so operators can verify what is actually registered in a running
+// process. The routes are listed per method in matching order, a route
+// registered for multiple methods is listed for each of them.
+//
+// The handler is not registered by default, as the route table shouldn't
+// be public. For example:
+//
+//     admin.Get("/debug/routes", r.DebugHandler().ServeHTTP)
+//
+func (r *Router) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(map[string][]debugRoute{"routes": r.debugRoutes()}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// debugRoutes returns the routes ordered by their methods and in matching
+// order.
+func (r *Router) debugRoutes() []debugRoute {
+	t := r.table()
+	methods := make([]string, 0, len(t.routes))
+	for method := range t.routes {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	list := []debugRoute{}
+	for _, method := range methods {
+		for order, route := range t.routes[method] {
+			dr := debugRoute{
+				Method:   method,
+				Order:    order,
+				Path:     route.GetPath(),
+				Kind:     kindName(route.Kind()),
+				Name:     route.GetName(),
+				Methods:  route.GetMethods(),
+				Matchers: []string{},
+				Disabled: route.IsDisabled(),
+			}
+			for _, m := range route.GetMatchers() {
+				dr.Matchers = append(dr.Matchers, matcherName(m))
+			}
+			if err := route.GetError(); err != nil {
+				dr.Error = err.Error()
+			}
+			list = append(list, dr)
+		}
+	}
+	return list
+}
+
+// kindName returns the name of a kind of route.
+func kindName(kind int) string {
+	switch kind {
+	case kindPrefixPath:
+		return "prefix"
+	case kindNormalPath:
+		return "normal"
+	case kindVarsPath:
+		return "vars"
+	case kindRegexPath:
+		return "regex"
+	}
+	return "unknown"
+}
+
+// TraceHeader is the response header listing the trace of a request, see
+// Router.Trace.
+const TraceHeader = "X-Mux-Trace"
+
+// TraceStep records how a route was evaluated against a request.
+type TraceStep struct {
+	Method string
+	Route  RouteInterface
+	// Matched is true if the route matches the request.
+	Matched bool
+	// Matcher is the first matcher of the route which rejected the request.
+	// It is nil if the route matched, has an error or is disabled.
+	Matcher Matcher
+}
+
+// String returns a line like "GET /users/:id: rejected by pathWithVarsMatcher".
+func (s TraceStep) String() string {
+	route := s.Method + " " + s.Route.GetPath()
+	switch {
+	case s.Matched:
+		return route + ": matched"
+	case s.Matcher != nil:
+		return route + ": rejected by " + matcherName(s.Matcher)
+	case s.Route.HasError():
+		return route + ": route error: " + s.Route.GetError().Error()
+	case s.Route.IsDisabled():
+		return route + ": disabled"
+	}
+	return route + ": rejected"
+}
+
+// trace evaluates every route registered for the method of the request in
+// matching order.
+func (r *Router) trace(req *http.Request) []TraceStep {
+	steps := []TraceStep{}
+	for _, route := range r.table().routes[req.Method] {
+		step := TraceStep{Method: req.Method, Route: route, Matched: route.Match(req) != nil}
+		if !step.Matched && !route.HasError() {
+			for _, m := range route.GetMatchers() {
+				if !m.Match(req) {
+					step.Matcher = m
+					break
+				}
+			}
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// GetTrace returns the trace of the current request if the router has Trace
+// set. Like the response header it lists the routes registered for the
+// method of the request, an empty trace means there are none.
+func GetTrace(r *http.Request) []TraceStep {
+	if rv := contextGet(r, traceKey); rv != nil {
+		return rv.([]TraceStep)
+	}
+	return nil
+}
+
+// matcherName returns the type name of a matcher, or its String if it is a
+// fmt.Stringer.
+func matcherName(m Matcher) string {
+	if s, ok := m.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%T", m), "mux.")
+}
@@ -0,0 +1,176 @@
+package mux
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDebugHandler(t *testing.T) {
+	r := Classic()
+	r.Get("/users", nil).Name("users")
+	r.Get("/users/:id", nil)
+	r.Post("/users", nil)
+	r.Get("/bad/#([0-9]+", nil)
+
+	w := httptest.NewRecorder()
+	r.DebugHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/routes", nil))
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Errorf("Unexpected content type (%s)", ct)
+	}
+
+	var body struct {
+		Routes []debugRoute `json:"routes"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	if len(body.Routes) != 4 {
+		t.Fatalf("Unexpected routes (%v)", body.Routes)
+	}
+
+	expected := debugRoute{
+		Method:   http.MethodGet,
+		Order:    0,
+		Path:     "/users",
+		Kind:     "normal",
+		Name:     "users",
+		Methods:  []string{http.MethodGet},
+		Matchers: []string{"pathMatcher"},
+	}
+	if !reflect.DeepEqual(body.Routes[0], expected) {
+		t.Errorf("Unexpected route (%+v)", body.Routes[0])
+	}
+
+	if route := body.Routes[1]; route.Order != 1 || route.Kind != "vars" {
+		t.Errorf("Unexpected route (%+v)", route)
+	}
+
+	if route := body.Routes[2]; route.Error == "" {
+		t.Errorf("Expected an error (%+v)", route)
+	}
+
+	if route := body.Routes[3]; route.Method != http.MethodPost || route.Order != 0 {
+		t.Errorf("Unexpected route (%+v)", route)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	r := Classic()
+	r.Trace = true
+	r.Get("/users/:id", nil)
+	r.Register(r.NewRoute().Path("/users/me").Methods(http.MethodGet).HeadersPresent("Authorization").HandlerFunc(nil))
+	r.Get("/about", nil)
+	r.Post("/users/me", nil)
+
+	var steps []TraceStep
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		steps = GetTrace(req)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/me/settings", nil))
+
+	expected := []string{
+		"GET /users/:id: rejected by pathWithVarsMatcher",
+		"GET /users/me: rejected by pathMatcher",
+		"GET /about: rejected by pathMatcher",
+	}
+	if header := w.Header()[TraceHeader]; !reflect.DeepEqual(header, expected) {
+		t.Errorf("Unexpected trace header (%q)", header)
+	}
+
+	if len(steps) != 3 || steps[0].Matched || steps[0].Matcher == nil {
+		t.Errorf("Unexpected trace (%v)", steps)
+	}
+}
+
+func TestTraceMatched(t *testing.T) {
+	r := Classic()
+	r.Trace = true
+	r.Register(r.NewRoute().Path("/users/me").Methods(http.MethodGet).HeadersPresent("Authorization").HandlerFunc(nil))
+	r.Get("/users/:id", func(w http.ResponseWriter, req *http.Request) {})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/me", nil))
+
+	expected := []string{
+		"GET /users/me: rejected by headerPresentMatcher",
+		"GET /users/:id: matched",
+	}
+	if header := w.Header()[TraceHeader]; !reflect.DeepEqual(header, expected) {
+		t.Errorf("Unexpected trace header (%q)", header)
+	}
+}
+
+func TestTraceDisabled(t *testing.T) {
+	r := Classic()
+	r.Get("/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		if steps := GetTrace(req); steps != nil {
+			t.Errorf("Unexpected trace (%v)", steps)
+		}
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+	if header := w.Header().Get(TraceHeader); header != "" {
+		t.Errorf("Unexpected trace header (%q)", header)
+	}
+}
+
+func TestFlag(t *testing.T) {
+	r := Classic()
+	r.Trace = true
+	beta := func(req *http.Request) bool {
+		return req.Header.Get("X-Beta") == "1"
+	}
+	r.Get("/search", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("new"))
+	}).Flag("new-search", beta)
+	r.Get("/#(search)", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("old"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/search", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Body.String() != "old" {
+		t.Errorf("Unexpected body (%s)", w.Body.String())
+	}
+	if trace := w.Header().Values(TraceHeader); len(trace) == 0 || trace[0] != "GET /search: rejected by flag(new-search)" {
+		t.Errorf("Unexpected trace (%v)", trace)
+	}
+
+	req.Header.Set("X-Beta", "1")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Body.String() != "new" {
+		t.Errorf("Unexpected body (%s)", w.Body.String())
+	}
+
+	routes := r.debugRoutes()
+	if matchers := routes[0].Matchers; !reflect.DeepEqual(matchers, []string{"pathMatcher", "flag(new-search)"}) {
+		t.Errorf("Unexpected matchers (%v)", matchers)
+	}
+}
+
+func TestFlagFail(t *testing.T) {
+	r := Classic()
+	route := r.Get("/search", nil).Flag("new-search", nil)
+
+	if err := route.GetError(); err == nil || !strings.HasSuffix(err.Error(), `Error: mux: flag "new-search" has no evaluation function`) {
+		t.Errorf("Unexpected error (%v)", err)
+	}
+
+	if err := matcherError(Flag("", func(*http.Request) bool { return true })); err == nil {
+		t.Error("Expected an error for an empty name")
+	}
+}
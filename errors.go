@@ -1,6 +1,19 @@
 package mux
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrNotFound is the reason of Router.MatchRequest if no route matches
+	// the request.
+	ErrNotFound = errors.New("mux: no route matches the request")
+	// ErrMethodMismatch is the reason of Router.MatchRequest if no route
+	// matches the request but routes for other methods match it.
+	ErrMethodMismatch = errors.New("mux: method is not allowed")
+)
 
 // BadRouteError creates error for a bad route
 type BadRouteError struct {
@@ -42,3 +55,34 @@ func (bme *BadPathError) Error() string { return fmt.Sprintf("Path is invaild (%
 func NewBadPathError(text string) error {
 	return &BadPathError{s: text}
 }
+
+// BadParamError is returned by the typed accessors of route variables (e.g.
+// ParamInt) if a variable is missing or can't be converted. As it is caused
+// by the request, it is usually answered with 400 Bad Request, see
+// StatusCode.
+type BadParamError struct {
+	// Name of the variable.
+	Name string
+	// Value of the variable, empty if it is missing.
+	Value string
+	// Type the value was converted to, e.g. "int".
+	Type string
+	// Err is the cause of the error.
+	Err error
+}
+
+func (bpe *BadParamError) Error() string {
+	return fmt.Sprintf("Param is invalid (%s: %q is not a valid %s: %s)", bpe.Name, bpe.Value, bpe.Type, bpe.Err)
+}
+
+// Unwrap returns the cause of the error.
+func (bpe *BadParamError) Unwrap() error { return bpe.Err }
+
+// StatusCode returns the HTTP status code for the error.
+func (bpe *BadParamError) StatusCode() int { return http.StatusBadRequest }
+
+// NewBadParamError returns an error for a variable which can't be converted
+// to typ.
+func NewBadParamError(name, value, typ string, err error) error {
+	return &BadParamError{Name: name, Value: value, Type: typ, Err: err}
+}
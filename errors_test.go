@@ -1,6 +1,7 @@
 package mux
 
 import (
+	"errors"
 	"strings"
 	"testing"
 )
@@ -25,3 +26,10 @@ func TestBadRouteError(t *testing.T) {
 		t.Errorf("Error message is bad (%s)", err.Error())
 	}
 }
+
+func TestBadParamError(t *testing.T) {
+	err := NewBadParamError("id", "abc", "int", errors.New("invalid syntax"))
+	if !strings.Contains(err.Error(), "Param is invalid") || !strings.Contains(err.Error(), `"abc"`) {
+		t.Errorf("Error message is bad (%s)", err.Error())
+	}
+}
@@ -0,0 +1,150 @@
+package mux
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ETag returns a middleware which adds an ETag to the responses of GET
+// requests and answers conditional requests with 304 Not Modified if the
+// If-None-Match header contains the ETag, e.g. for a group of read-heavy API
+// routes:
+//
+//     api := r.PathPrefix("/api").Subrouter()
+//     api.Use(mux.ETag(false))
+//
+// The ETag is a hash of the body, so the response is buffered. It is
+// strong unless weak is set, a weak ETag (W/"...") only claims semantic
+// equivalence, e.g. if the body is compressed afterwards. An ETag set by the
+// handler is kept. Responses except 200 OK and streamed responses (the
+// handler called Flush) are passed on unchanged.
+func ETag(weak bool) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Method != http.MethodGet {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			ew := &etagWriter{ResponseWriter: w, code: http.StatusOK}
+			next.ServeHTTP(ew, req)
+
+			if ew.streaming {
+				return
+			}
+
+			if ew.code != http.StatusOK {
+				ew.flushBuffer()
+				return
+			}
+
+			etag := w.Header().Get("ETag")
+			if etag == "" {
+				sum := sha256.Sum256(ew.buf.Bytes())
+				etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+				if weak {
+					etag = "W/" + etag
+				}
+				w.Header().Set("ETag", etag)
+			}
+
+			if etagMatches(req.Header.Get("If-None-Match"), etag) {
+				h := w.Header()
+				h.Del("Content-Type")
+				h.Del("Content-Length")
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			w.Header().Set("Content-Length", strconv.Itoa(ew.buf.Len()))
+			ew.flushBuffer()
+		})
+	}
+}
+
+// etagMatches returns true if the If-None-Match header contains etag. Tags
+// are compared weakly as defined by RFC 7232 section 3.2.
+func etagMatches(header string, etag string) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// etagWriter buffers a response until the ETag is known.
+type etagWriter struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+	buf         bytes.Buffer
+	// streaming is set when the handler flushes, the response is written
+	// through from then on.
+	streaming bool
+}
+
+func (w *etagWriter) WriteHeader(code int) {
+	if w.streaming {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.wroteHeader {
+		return
+	}
+
+	// informational responses are passed on
+	if code >= 100 && code <= 199 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	w.wroteHeader = true
+	w.code = code
+}
+
+func (w *etagWriter) Write(b []byte) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.Write(b)
+	}
+	w.wroteHeader = true
+	return w.buf.Write(b)
+}
+
+// flushBuffer writes the buffered response.
+func (w *etagWriter) flushBuffer() {
+	w.streaming = true
+	w.ResponseWriter.WriteHeader(w.code)
+	if w.buf.Len() != 0 {
+		w.ResponseWriter.Write(w.buf.Bytes())
+	}
+}
+
+// Flush writes the buffered response and streams the rest of it without an
+// ETag.
+func (w *etagWriter) Flush() {
+	if !w.streaming {
+		w.flushBuffer()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped ResponseWriter, e.g. for http.ResponseController.
+func (w *etagWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
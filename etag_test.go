@@ -0,0 +1,87 @@
+package mux
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestETag(t *testing.T) {
+	r := Classic()
+	r.Use(ETag(false))
+	r.Get("/users", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `[{"id":1}]`)
+	})
+	r.Get("/fixed", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		io.WriteString(w, "fixed")
+	})
+	r.Get("/missing", func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "missing", http.StatusNotFound)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || !strings.HasPrefix(etag, `"`) || w.Body.String() != `[{"id":1}]` || w.Header().Get("Content-Length") != "10" {
+		t.Fatalf("Unexpected response (%d, %s, %v)", w.Code, w.Body.String(), w.Header())
+	}
+
+	tests := []struct {
+		path        string
+		ifNoneMatch string
+		code        int
+	}{
+		{"/users", etag, http.StatusNotModified},
+		{"/users", `"other", W/` + etag, http.StatusNotModified},
+		{"/users", "*", http.StatusNotModified},
+		{"/users", `"other"`, http.StatusOK},
+		{"/fixed", `"v1"`, http.StatusNotModified},
+		{"/missing", "*", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+		req.Header.Set("If-None-Match", test.ifNoneMatch)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != test.code {
+			t.Errorf("Expected %d for %s (%s), got %d", test.code, test.path, test.ifNoneMatch, w.Code)
+		}
+		if test.code == http.StatusNotModified && (w.Body.Len() != 0 || w.Header().Get("ETag") == "") {
+			t.Errorf("Unexpected 304 response for %s (%s, %v)", test.path, w.Body.String(), w.Header())
+		}
+	}
+}
+
+func TestETagWeakAndStreaming(t *testing.T) {
+	r := Classic()
+	r.Use(ETag(true))
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "hello")
+	})
+	r.Get("/events", func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "data: 1\n\n")
+		w.(http.Flusher).Flush()
+		io.WriteString(w, "data: 2\n\n")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if etag := w.Header().Get("ETag"); !strings.HasPrefix(etag, `W/"`) {
+		t.Errorf("Unexpected ETag (%s)", etag)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	if w.Header().Get("ETag") != "" || w.Body.String() != "data: 1\n\ndata: 2\n\n" || !w.Flushed {
+		t.Errorf("Unexpected response (%s, %v)", w.Body.String(), w.Header())
+	}
+}
@@ -0,0 +1,250 @@
+package mux
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// SetTrustedProxies configures the proxies (IPs or CIDRs, e.g. 10.0.0.0/8)
+// whose forwarding headers are trusted. If the peer of a request is a
+// trusted proxy, the client IP (see ClientIP), the scheme and the host are
+// taken from the RFC 7239 Forwarded header or the X-Forwarded-For,
+// X-Forwarded-Proto and X-Forwarded-Host headers before the routes are
+// matched, so the matchers and the handler see the values of the client.
+//
+// The client IP is the last address of the forwarding chain which isn't a
+// trusted proxy, so clients can't spoof it by prepending addresses. The
+// scheme and the host are the values appended by the proxy which received
+// the request of the client, the proxies have to append their values to the
+// headers or overwrite them.
+func (r *Router) SetTrustedProxies(proxies ...string) error {
+	nets, err := parseNetworks(proxies...)
+	if err != nil {
+		return err
+	}
+
+	r.trustedProxies = nets
+	return nil
+}
+
+// parseNetworks parses IPs and CIDRs, an IP is a network of one address.
+func parseNetworks(values ...string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("mux: network %q is invalid", value)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("mux: network %q is invalid: %v", value, err)
+		}
+		nets = append(nets, ipNet)
+	}
+
+	return nets, nil
+}
+
+// isTrustedProxy returns true if ip is a trusted proxy.
+func (r *Router) isTrustedProxy(ip string) bool {
+	return containsIP(r.trustedProxies, ip)
+}
+
+// containsIP returns true if one of the networks contains ip.
+func containsIP(nets []*net.IPNet, ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range nets {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedRequest returns a copy of the request with the client IP, scheme
+// and host of the forwarding headers if the peer is a trusted proxy.
+func (r *Router) forwardedRequest(req *http.Request) *http.Request {
+	if !r.isTrustedProxy(remoteIP(req)) {
+		return req
+	}
+
+	var fwdReq *http.Request
+	// hops is the number of proxies from the client to the router
+	hops := 1
+	if chain := forwardedFor(req); len(chain) != 0 {
+		// the last address which isn't a trusted proxy is the client
+		k := len(chain) - 1
+		for k > 0 && r.isTrustedProxy(chain[k]) {
+			k--
+		}
+		hops = len(chain) - k
+		fwdReq = contextSet(req, clientIPKey, chain[k])
+	} else {
+		fwdReq = new(http.Request)
+		*fwdReq = *req
+	}
+
+	u := *req.URL
+	if proto := forwardedProto(req, hops); proto != "" {
+		u.Scheme = proto
+	}
+	fwdReq.URL = &u
+
+	if host := forwardedHost(req, hops); host != "" {
+		fwdReq.Host = host
+	}
+
+	return fwdReq
+}
+
+// forwardedFor returns the addresses of the forwarding chain, from the
+// client to the last proxy. The Forwarded header is preferred over
+// X-Forwarded-For.
+func forwardedFor(r *http.Request) []string {
+	chain := []string{}
+
+	if header := strings.Join(r.Header.Values("Forwarded"), ","); header != "" {
+		for _, element := range strings.Split(header, ",") {
+			if ip := forwardedNodeIP(forwardedParam(element, "for")); ip != "" {
+				chain = append(chain, ip)
+			}
+		}
+		return chain
+	}
+
+	for _, ip := range strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",") {
+		if ip := forwardedNodeIP(ip); ip != "" {
+			chain = append(chain, ip)
+		}
+	}
+	return chain
+}
+
+// forwardedNodeIP returns the IP of a node of a forwarding header, e.g.
+// 2001:db8:cafe::17 of "[2001:db8:cafe::17]:4711". Obfuscated and unknown
+// nodes are ignored.
+func forwardedNodeIP(node string) string {
+	node = strings.Trim(strings.TrimSpace(node), `"`)
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		node = host
+	}
+	node = strings.Trim(node, "[]")
+	if net.ParseIP(node) == nil {
+		return ""
+	}
+	return node
+}
+
+// forwardedHost returns the host the client requested according to the
+// Forwarded or X-Forwarded-Host header of the proxy hops proxies away (see
+// forwardedValue).
+func forwardedHost(r *http.Request, hops int) string {
+	if host := forwardedParam(forwardedValue(headerList(r, "Forwarded"), hops), "host"); host != "" {
+		return host
+	}
+	return forwardedValue(headerList(r, "X-Forwarded-Host"), hops)
+}
+
+// remoteIP returns the IP of the peer of a request.
+func remoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// ClientIP returns the IP of the client of a request: the IP of the
+// forwarding headers if the peer is a trusted proxy of the router (see
+// Router.SetTrustedProxies), otherwise the IP of the peer.
+func ClientIP(r *http.Request) string {
+	if rv := contextGet(r, clientIPKey); rv != nil {
+		return rv.(string)
+	}
+	return remoteIP(r)
+}
+
+// requestScheme returns the scheme of a request: the scheme of its URL if
+// it is set (e.g. for client requests), otherwise https for TLS connections
+// and http.
+func requestScheme(r *http.Request) string {
+	if r.URL.Scheme != "" {
+		return strings.ToLower(r.URL.Scheme)
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// forwardedProto returns the scheme the client used according to the
+// X-Forwarded-Proto or the RFC 7239 Forwarded header of the proxy hops
+// proxies away (see forwardedValue), or an empty string if neither is set.
+func forwardedProto(r *http.Request, hops int) string {
+	if values := headerList(r, "X-Forwarded-Proto"); len(values) != 0 {
+		return validScheme(forwardedValue(values, hops))
+	}
+
+	return validScheme(forwardedParam(forwardedValue(headerList(r, "Forwarded"), hops), "proto"))
+}
+
+// forwardedValue returns the value of a forwarding header appended by the
+// proxy hops proxies away, as each proxy appends its value. Values before
+// it can be spoofed by the client. If there are fewer values than proxies,
+// the proxies overwrote the header and the first value is used.
+func forwardedValue(values []string, hops int) string {
+	if len(values) == 0 {
+		return ""
+	}
+	i := len(values) - hops
+	if i < 0 {
+		i = 0
+	}
+	return strings.TrimSpace(values[i])
+}
+
+// headerList returns the comma separated values of a header, which may be
+// repeated.
+func headerList(r *http.Request, name string) []string {
+	header := strings.Join(r.Header.Values(name), ",")
+	if header == "" {
+		return nil
+	}
+	return strings.Split(header, ",")
+}
+
+// forwardedParam returns the value of a parameter of the first element of a
+// Forwarded header, e.g. "https" of proto in
+// `for=192.0.2.60;proto=https, for=198.51.100.17`.
+func forwardedParam(header string, name string) string {
+	element, _, _ := strings.Cut(header, ",")
+	for _, pair := range strings.Split(element, ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if found && strings.EqualFold(key, name) {
+			return strings.Trim(value, `"`)
+		}
+	}
+	return ""
+}
+
+// validScheme returns the lowercased scheme if it is http or https.
+func validScheme(scheme string) string {
+	scheme = strings.ToLower(strings.TrimSpace(scheme))
+	if scheme == "http" || scheme == "https" {
+		return scheme
+	}
+	return ""
+}
@@ -0,0 +1,141 @@
+package mux
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestScheme(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if scheme := requestScheme(req); scheme != "http" {
+		t.Errorf("Unexpected scheme (%s)", scheme)
+	}
+
+	req.TLS = &tls.ConnectionState{}
+	if scheme := requestScheme(req); scheme != "https" {
+		t.Errorf("Unexpected scheme (%s)", scheme)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "HTTPS://example.com/", nil)
+	if scheme := requestScheme(req); scheme != "https" {
+		t.Errorf("Unexpected scheme (%s)", scheme)
+	}
+}
+
+func TestForwardedProto(t *testing.T) {
+	tests := []struct {
+		header   string
+		value    string
+		expected string
+	}{
+		{"X-Forwarded-Proto", "https", "https"},
+		// the value of the client is spoofed, the proxy appended http
+		{"X-Forwarded-Proto", "https, HTTP", "http"},
+		{"X-Forwarded-Proto", "ftp", ""},
+		{"Forwarded", `for=192.0.2.60;proto=https;by=203.0.113.43`, "https"},
+		{"Forwarded", `proto=https, For="[2001:db8:cafe::17]:4711";Proto="http"`, "http"},
+		{"Forwarded", "proto=https, for=192.0.2.60", ""},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(test.header, test.value)
+
+		if proto := forwardedProto(req, 1); proto != test.expected {
+			t.Errorf("Expected %q for %s %q, got %q", test.expected, test.header, test.value, proto)
+		}
+	}
+}
+
+func TestSetTrustedProxiesFail(t *testing.T) {
+	r := Classic()
+
+	for _, proxy := range []string{"10.0.0.300", "10.0.0.0/33", "proxy"} {
+		if err := r.SetTrustedProxies(proxy); err == nil {
+			t.Errorf("Expected an error for %q", proxy)
+		}
+	}
+}
+
+func TestTrustedProxies(t *testing.T) {
+	r := Classic()
+	if err := r.SetTrustedProxies("10.0.0.0/8", "::1"); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	var clientIP, scheme, host string
+	r.Get("/whoami", func(w http.ResponseWriter, req *http.Request) {
+		clientIP, scheme, host = ClientIP(req), requestScheme(req), req.Host
+	}).Host("example.com").Schemes("https")
+
+	tests := []struct {
+		remoteAddr string
+		headers    map[string]string
+		clientIP   string
+		matched    bool
+	}{
+		{"10.0.0.1:1234", map[string]string{
+			"X-Forwarded-For":   "203.0.113.7, 10.0.0.2",
+			"X-Forwarded-Proto": "https",
+			"X-Forwarded-Host":  "example.com",
+		}, "203.0.113.7", true},
+		// spoofed addresses before the client are ignored
+		{"[::1]:1234", map[string]string{
+			"X-Forwarded-For":   "198.51.100.1, 203.0.113.7",
+			"X-Forwarded-Proto": "https",
+			"X-Forwarded-Host":  "example.com",
+		}, "203.0.113.7", true},
+		{"10.0.0.1:1234", map[string]string{
+			"Forwarded": `for=192.0.2.60;proto=http;host=internal, for="[2001:db8::1]";proto=https;host=example.com`,
+		}, "2001:db8::1", true},
+		// the values appended by the proxy of the client are used
+		{"10.0.0.1:1234", map[string]string{
+			"X-Forwarded-For":   "198.51.100.1, 203.0.113.7, 10.0.0.2",
+			"X-Forwarded-Proto": "http, https, http",
+			"X-Forwarded-Host":  "internal, example.com, internal",
+		}, "203.0.113.7", true},
+		{"10.0.0.1:1234", map[string]string{
+			"X-Forwarded-For":   "203.0.113.7",
+			"X-Forwarded-Proto": "https, http",
+			"X-Forwarded-Host":  "example.com, internal",
+		}, "", false},
+		// headers of untrusted peers are ignored
+		{"192.0.2.1:1234", map[string]string{
+			"X-Forwarded-For":   "203.0.113.7",
+			"X-Forwarded-Proto": "https",
+			"X-Forwarded-Host":  "example.com",
+		}, "", false},
+	}
+
+	for _, test := range tests {
+		clientIP = ""
+		req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+		req.Host = "internal"
+		req.RemoteAddr = test.remoteAddr
+		for key, value := range test.headers {
+			req.Header.Set(key, value)
+		}
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if matched := w.Code == http.StatusOK; matched != test.matched {
+			t.Errorf("Expected matched %v for %s, got %d", test.matched, test.remoteAddr, w.Code)
+		}
+
+		if test.matched && (clientIP != test.clientIP || scheme != "https" || host != "example.com") {
+			t.Errorf("Unexpected client (%s, %s, %s)", clientIP, scheme, host)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+
+	if ip := ClientIP(req); ip != "192.0.2.1" {
+		t.Errorf("Unexpected client IP (%s)", ip)
+	}
+}
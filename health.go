@@ -0,0 +1,230 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HealthOptions configures the health checks of NewHealth.
+type HealthOptions struct {
+	// Timeout limits the duration of each check, it defaults to 5 seconds.
+	// The context of a check is canceled after it.
+	Timeout time.Duration
+}
+
+// Health runs named health checks and serves their aggregated result.
+//
+// It distinguishes liveness and readiness, e.g. for the probes of
+// Kubernetes: the liveness checks (see RegisterLiveness) tell whether the
+// process works at all and has to be restarted otherwise, so they shouldn't
+// check dependencies. The readiness checks (see Register) tell whether it
+// can serve traffic, which also requires the ready flag (see SetReady).
+type Health struct {
+	opts  HealthOptions
+	ready atomic.Bool
+
+	mu     sync.RWMutex
+	checks map[string]healthCheck
+}
+
+type healthCheck struct {
+	check    func(ctx context.Context) error
+	liveness bool
+}
+
+// HealthReport is the aggregated result of the health checks.
+type HealthReport struct {
+	// Status is pass if all checks passed, otherwise fail.
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// CheckResult is the result of a health check.
+type CheckResult struct {
+	// Status is pass or fail.
+	Status  string  `json:"status"`
+	Latency float64 `json:"latency_ms"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// NewHealth returns health checks without checks, which aren't ready yet.
+// Its handlers serve the health endpoints, e.g.:
+//
+//     health := mux.NewHealth(mux.HealthOptions{Timeout: 2 * time.Second})
+//     health.Register("database", db.PingContext)
+//     r.Get("/health", health.Handler().ServeHTTP)
+//     r.Get("/livez", health.LivenessHandler().ServeHTTP)
+//     r.Get("/readyz", health.ReadinessHandler().ServeHTTP)
+//     // once the application is initialized
+//     health.SetReady(true)
+//
+func NewHealth(opts HealthOptions) *Health {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	return &Health{opts: opts, checks: map[string]healthCheck{}}
+}
+
+// Register registers a readiness check, replacing a check with the same
+// name. A check fails if it returns an error, panics or exceeds the
+// timeout.
+func (h *Health) Register(name string, check func(ctx context.Context) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = healthCheck{check: check}
+}
+
+// RegisterLiveness registers a liveness check, replacing a check with the
+// same name, e.g. to detect a deadlock.
+func (h *Health) RegisterLiveness(name string, check func(ctx context.Context) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = healthCheck{check: check, liveness: true}
+}
+
+// Unregister removes the check with name.
+func (h *Health) Unregister(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.checks, name)
+}
+
+// SetReady sets the ready flag. The application sets it once it is
+// initialized and clears it when it shuts down, so load balancers stop
+// sending requests before the server stops accepting them.
+func (h *Health) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+// Ready returns the ready flag, see SetReady.
+func (h *Health) Ready() bool {
+	return h.ready.Load()
+}
+
+// Check runs all checks concurrently and returns their result.
+func (h *Health) Check(ctx context.Context) HealthReport {
+	return h.run(ctx, func(healthCheck) bool { return true })
+}
+
+// CheckLiveness runs the liveness checks concurrently and returns their
+// result.
+func (h *Health) CheckLiveness(ctx context.Context) HealthReport {
+	return h.run(ctx, func(c healthCheck) bool { return c.liveness })
+}
+
+// CheckReadiness runs the readiness checks concurrently and returns their
+// result. It fails without running them if the ready flag isn't set, with
+// the result of a check named ready.
+func (h *Health) CheckReadiness(ctx context.Context) HealthReport {
+	if !h.Ready() {
+		return HealthReport{
+			Status: "fail",
+			Checks: map[string]CheckResult{"ready": {Status: "fail", Error: "mux: not ready"}},
+		}
+	}
+	return h.run(ctx, func(c healthCheck) bool { return !c.liveness })
+}
+
+// run runs the checks selected by filter concurrently.
+func (h *Health) run(ctx context.Context, filter func(healthCheck) bool) HealthReport {
+	h.mu.RLock()
+	checks := make(map[string]func(context.Context) error, len(h.checks))
+	for name, c := range h.checks {
+		if filter(c) {
+			checks[name] = c.check
+		}
+	}
+	h.mu.RUnlock()
+
+	report := HealthReport{Status: "pass", Checks: make(map[string]CheckResult, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) error) {
+			defer wg.Done()
+			result := h.runCheck(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != "pass" {
+				report.Status = "fail"
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// runCheck runs a check with the timeout.
+func (h *Health) runCheck(ctx context.Context, check func(context.Context) error) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if rv := recover(); rv != nil {
+				done <- fmt.Errorf("mux: health check panicked: %v", rv)
+			}
+		}()
+		done <- check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// a check ignoring its context doesn't block the report
+		err = ctx.Err()
+	}
+
+	result := CheckResult{Status: "pass", Latency: float64(time.Since(start)) / float64(time.Millisecond)}
+	if err != nil {
+		result.Status, result.Error = "fail", err.Error()
+	}
+	return result
+}
+
+// Handler returns a handler which runs the checks and renders the report as
+// JSON, with the status 200 OK if all passed, otherwise 503 Service
+// Unavailable.
+func (h *Health) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeHealthReport(w, h.Check(req.Context()))
+	})
+}
+
+// LivenessHandler returns a handler like Handler for the liveness checks.
+func (h *Health) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeHealthReport(w, h.CheckLiveness(req.Context()))
+	})
+}
+
+// ReadinessHandler returns a handler like Handler for the readiness checks,
+// which answers 503 Service Unavailable while the ready flag isn't set.
+func (h *Health) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeHealthReport(w, h.CheckReadiness(req.Context()))
+	})
+}
+
+func writeHealthReport(w http.ResponseWriter, report HealthReport) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status == "pass" {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
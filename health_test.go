@@ -0,0 +1,116 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealth(t *testing.T) {
+	health := NewHealth(HealthOptions{Timeout: 50 * time.Millisecond})
+	health.Register("database", func(ctx context.Context) error { return nil })
+	health.Register("cache", func(ctx context.Context) error { return nil })
+
+	r := Classic()
+	r.Get("/health", health.Handler().ServeHTTP)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	var report HealthReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || report.Status != "pass" || len(report.Checks) != 2 || report.Checks["database"].Status != "pass" {
+		t.Errorf("Unexpected report %d (%+v)", w.Code, report)
+	}
+	if w.Header().Get("Content-Type") != "application/json; charset=utf-8" || w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("Unexpected headers (%v)", w.Header())
+	}
+
+	health.Register("queue", func(ctx context.Context) error { return errors.New("connection refused") })
+	health.Register("search", func(ctx context.Context) error { panic("boom") })
+	health.Register("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	report = HealthReport{}
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusServiceUnavailable || report.Status != "fail" || report.Checks["cache"].Status != "pass" {
+		t.Errorf("Unexpected report %d (%+v)", w.Code, report)
+	}
+	expected := map[string]string{
+		"queue":  "connection refused",
+		"search": "mux: health check panicked: boom",
+		"slow":   "context deadline exceeded",
+	}
+	for name, message := range expected {
+		if result := report.Checks[name]; result.Status != "fail" || result.Error != message {
+			t.Errorf("Unexpected result of %s (%+v)", name, result)
+		}
+	}
+	if latency := report.Checks["slow"].Latency; latency < 50 || latency > 500 {
+		t.Errorf("Unexpected latency (%v)", latency)
+	}
+
+	health.Unregister("queue")
+	if _, found := health.Check(context.Background()).Checks["queue"]; found {
+		t.Error("Unexpected check after Unregister")
+	}
+}
+
+func TestHealthReadiness(t *testing.T) {
+	health := NewHealth(HealthOptions{})
+	health.RegisterLiveness("goroutines", func(ctx context.Context) error { return nil })
+	health.Register("database", func(ctx context.Context) error { return errors.New("down") })
+
+	r := Classic()
+	r.Get("/livez", health.LivenessHandler().ServeHTTP)
+	r.Get("/readyz", health.ReadinessHandler().ServeHTTP)
+
+	get := func(path string) (int, HealthReport) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var report HealthReport
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+			t.Fatal(err)
+		}
+		return w.Code, report
+	}
+
+	// liveness doesn't depend on the readiness checks and the ready flag
+	if code, report := get("/livez"); code != http.StatusOK || len(report.Checks) != 1 || report.Checks["goroutines"].Status != "pass" {
+		t.Errorf("Unexpected liveness %d (%+v)", code, report)
+	}
+
+	// not ready on startup, the checks aren't run
+	if code, report := get("/readyz"); code != http.StatusServiceUnavailable || len(report.Checks) != 1 || report.Checks["ready"].Error != "mux: not ready" {
+		t.Errorf("Unexpected readiness %d (%+v)", code, report)
+	}
+
+	health.SetReady(true)
+	if code, report := get("/readyz"); code != http.StatusServiceUnavailable || len(report.Checks) != 1 || report.Checks["database"].Error != "down" {
+		t.Errorf("Unexpected readiness %d (%+v)", code, report)
+	}
+
+	health.Register("database", func(ctx context.Context) error { return nil })
+	if code, report := get("/readyz"); code != http.StatusOK || report.Status != "pass" {
+		t.Errorf("Unexpected readiness %d (%+v)", code, report)
+	}
+
+	// draining on shutdown
+	health.SetReady(false)
+	if code, _ := get("/readyz"); code != http.StatusServiceUnavailable || health.Ready() {
+		t.Errorf("Unexpected readiness %d", code)
+	}
+}
@@ -3,8 +3,10 @@ package mux
 // checkPairs returns the count of strings passed in, and an error if
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -19,6 +21,20 @@ func isEvenPairs(pairs ...string) (int, error) {
 
 // convertStringsToMap converts variadic string parameters to a
 // string to string map.
+func convertStringsToMap(iep func(pairs ...string) (int, error), pairs ...string) (map[string]string, error) {
+	length, err := iep(pairs...)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]string, length/2)
+	for i := 0; i < length; i += 2 {
+		m[pairs[i]] = pairs[i+1]
+	}
+	return m, nil
+}
+
+// convertStringsToMapString converts variadic string parameters to a
+// string to comparison map.
 func convertStringsToMapString(iep func(pairs ...string) (int, error), pairs ...string) (map[string]comparison, error) {
 
 	buildComparator := func(pair string) (comparison, error) {
@@ -46,6 +62,28 @@ func convertStringsToMapRegex(iep func(pairs ...string) (int, error), pairs ...s
 	return genericConvertStringsToMap(iep, buildComparator, pairs...)
 }
 
+// convertStringsToMapStringOrRegex converts variadic string paramers to a
+// string to string or regex map. A value is a regex if it starts with a "#".
+func convertStringsToMapStringOrRegex(iep func(pairs ...string) (int, error), pairs ...string) (map[string]comparison, error) {
+
+	buildComparator := func(pair string) (comparison, error) {
+		if !strings.HasPrefix(pair, "#") {
+			return stringComparison(pair), nil
+		}
+
+		regex, err := regexp.Compile(pair[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		return regexComparsion{
+			r: regex,
+		}, nil
+	}
+
+	return genericConvertStringsToMap(iep, buildComparator, pairs...)
+}
+
 // genericConvertStringsToMap converts variadic string paramers to a
 // string to whatever.
 func genericConvertStringsToMap(
@@ -86,6 +124,33 @@ func (sc stringComparison) isNotEmpty() bool {
 	return string(sc) != ""
 }
 
+// substringComparison compares if a value contains the string, regardless
+// of its case. The string is lowercased.
+type substringComparison string
+
+func (sc substringComparison) compare(value string) bool {
+	return strings.Contains(strings.ToLower(value), string(sc))
+}
+
+func (sc substringComparison) isNotEmpty() bool {
+	return string(sc) != ""
+}
+
+// wildcardComparison compares if a value is a single label followed by the
+// suffix (e.g. ".example.com"), regardless of its case. The suffix is
+// lowercased.
+type wildcardComparison string
+
+func (wc wildcardComparison) compare(value string) bool {
+	value = strings.ToLower(value)
+	label := strings.TrimSuffix(value, string(wc))
+	return len(label) < len(value) && label != "" && !strings.Contains(label, ".")
+}
+
+func (wc wildcardComparison) isNotEmpty() bool {
+	return string(wc) != ""
+}
+
 type regexComparsion struct {
 	r *regexp.Regexp
 }
@@ -138,5 +203,99 @@ func containsRegex(path string) bool {
 
 // containsRegexPath returns true if the path contains vars
 func containsVars(path string) bool {
-	return strings.Contains(path, ":")
+	return strings.ContainsAny(path, ":{") || strings.Contains(path, "/*")
+}
+
+// extractVarsIndexies returns the url segment of each var in path.
+// A segment is a var if it starts with prefix, if name is set the var is
+// stored under name instead of the segment itself.
+func extractVarsIndexies(prefix string, path string, name string) map[string]int {
+
+	urlSeg := strings.Split(path, "/")
+
+	indexies := map[string]int{}
+	var count int
+	for k, v := range urlSeg {
+		if strings.HasPrefix(v, prefix) {
+
+			if name != "" {
+				v = name
+			}
+
+			if _, found := indexies[v]; !found {
+				indexies[v] = k
+				continue
+			}
+
+			count++
+			indexies[v+strconv.Itoa(count)] = k
+		}
+	}
+
+	return indexies
+}
+
+// stripHostPort returns host without port.
+func stripHostPort(host string) string {
+	if !strings.Contains(host, ":") {
+		return host
+	}
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+
+	return host
+}
+
+// stripPatternPort returns a host pattern without a trailing port, the
+// colons of variable patterns (e.g. {id:[0-9]+}) are kept.
+func stripPatternPort(pattern string) string {
+	i := strings.LastIndexByte(pattern, ':')
+	if i < 0 || i == len(pattern)-1 || strings.Count(pattern[:i], "{") != strings.Count(pattern[:i], "}") {
+		return pattern
+	}
+	for _, c := range pattern[i+1:] {
+		if c < '0' || c > '9' {
+			return pattern
+		}
+	}
+	return pattern[:i]
+}
+
+// stripSegmentsHandler removes the first n segments from the path of the
+// request before it is passed to h.
+func stripSegmentsHandler(n int, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		u := *req.URL
+		u.Path = stripSegments(u.Path, n)
+		if u.RawPath != "" {
+			u.RawPath = stripSegments(u.RawPath, n)
+		}
+
+		stripped := new(http.Request)
+		*stripped = *req
+		stripped.URL = &u
+
+		h.ServeHTTP(w, stripped)
+	})
+}
+
+// stripSegments removes the first n segments from path. The result starts
+// with a slash.
+func stripSegments(path string, n int) string {
+	i := 0
+	for k := 0; k < n && i < len(path); k++ {
+		next := strings.IndexByte(path[i+1:], '/')
+		if next < 0 {
+			i = len(path)
+			break
+		}
+		i += next + 1
+	}
+
+	if i >= len(path) {
+		return "/"
+	}
+	return path[i:]
 }
@@ -56,7 +56,28 @@ func TestConvertStringsToMapRegex(t *testing.T) {
 	m, _ := convertStringsToMapRegex(isEvenPairs, pairs...)
 
 	if value, ok := m["content-type"]; !ok || !value.compare("application/json") {
-		t.Errorf("Unexpected pair (%s)", value.(regexComparsion))
+		t.Errorf("Unexpected pair (%v)", value.(regexComparsion))
+	}
+}
+
+func TestConvertStringsToMapStringOrRegex(t *testing.T) {
+	pairs := []string{"format", "json", "page", "#^[0-9]+$"}
+
+	m, err := convertStringsToMapStringOrRegex(isEvenPairs, pairs...)
+	if err != nil {
+		t.Fatalf("Unexpected error (%s)", err.Error())
+	}
+
+	if value, ok := m["format"].(stringComparison); !ok || !value.compare("json") {
+		t.Errorf("Unexpected pair (%v)", m["format"])
+	}
+
+	if value, ok := m["page"].(regexComparsion); !ok || !value.compare("12") || value.compare("12a") {
+		t.Errorf("Unexpected pair (%v)", m["page"])
+	}
+
+	if _, err := convertStringsToMapStringOrRegex(isEvenPairs, "page", "#[0-9"); err == nil {
+		t.Error("Expected a error")
 	}
 }
 
@@ -107,6 +128,27 @@ func TestMatchMap(t *testing.T) {
 	}
 }
 
+func TestStripSegments(t *testing.T) {
+	tests := []struct {
+		path     string
+		n        int
+		expected string
+	}{
+		{"/static/css/app.css", 1, "/css/app.css"},
+		{"/static/css/app.css", 2, "/app.css"},
+		{"/static", 1, "/"},
+		{"/static/", 1, "/"},
+		{"/static/css/app.css", 0, "/static/css/app.css"},
+		{"/static", 3, "/"},
+	}
+
+	for _, test := range tests {
+		if actual := stripSegments(test.path, test.n); actual != test.expected {
+			t.Errorf("Unexpected path (Path: %s, N: %d, Expected: %s, Actual: %s)", test.path, test.n, test.expected, actual)
+		}
+	}
+}
+
 func BenchmarkMatchMap(b *testing.B) {
 
 	tests := []struct {
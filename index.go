@@ -0,0 +1,196 @@
+package mux
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// routeIndex is a trie of path segments over the routes of a method. It
+// resolves the candidate routes of a path in O(path length), so only the
+// candidates have their matchers evaluated. Routes which can't be indexed
+// (regex paths, path prefixes, optional or braced variables and variables
+// inside a segment) are candidates for every path.
+//
+// Routes are referenced by their position, so the candidates are matched in
+// the same order as the routes are registered (or sorted).
+//
+// Static paths are looked up first, so their routes win over routes with
+// variables. A route which only matches the path is returned without
+// evaluating any matcher.
+type routeIndex struct {
+	root     *indexNode
+	routes   routes
+	fallback []int
+	// exact holds the routes of each static path.
+	exact map[string][]int
+}
+
+// indexNode is a segment of the trie.
+type indexNode struct {
+	// static children by their segment.
+	static map[string]*indexNode
+	// param child matches any non-empty segment.
+	param *indexNode
+	// routes ending at this node.
+	routes []int
+	// catchAll routes match any remainder below this node.
+	catchAll []int
+}
+
+func newRouteIndex(rs routes) *routeIndex {
+	idx := &routeIndex{root: &indexNode{}, exact: map[string][]int{}}
+	for _, route := range rs {
+		idx.insert(route)
+	}
+	return idx
+}
+
+// insert appends a route to the index.
+func (idx *routeIndex) insert(route RouteInterface) {
+	pos := len(idx.routes)
+	idx.routes = append(idx.routes, route)
+
+	if route.Kind() == kindNormalPath {
+		path := indexPath(route)
+		idx.exact[path] = append(idx.exact[path], pos)
+	}
+
+	segments, ok := indexSegments(route)
+	if !ok {
+		idx.fallback = append(idx.fallback, pos)
+		return
+	}
+
+	node := idx.root
+	for k, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, "*") && k == len(segments)-1:
+			node.catchAll = append(node.catchAll, pos)
+			return
+		case strings.HasPrefix(segment, ":"):
+			if node.param == nil {
+				node.param = &indexNode{}
+			}
+			node = node.param
+		default:
+			if node.static == nil {
+				node.static = map[string]*indexNode{}
+			}
+			child, found := node.static[segment]
+			if !found {
+				child = &indexNode{}
+				node.static[segment] = child
+			}
+			node = child
+		}
+	}
+	node.routes = append(node.routes, pos)
+}
+
+// indexSegments splits the path of a route into its segments. It returns
+// false if the route can't be indexed.
+func indexSegments(route RouteInterface) ([]string, bool) {
+	path := indexPath(route)
+	if path == "" {
+		return nil, false
+	}
+
+	switch route.Kind() {
+	case kindNormalPath:
+		return strings.Split(path, "/"), true
+	case kindVarsPath:
+	default:
+		return nil, false
+	}
+
+	segments := strings.Split(path, "/")
+	for k, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			for i := 1; i < len(segment); i++ {
+				if !isVarNameChar(segment[i]) {
+					return nil, false
+				}
+			}
+		case strings.HasPrefix(segment, "*"):
+			if k != len(segments)-1 {
+				return nil, false
+			}
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+			// only a var without a pattern matches any segment
+			for i := 1; i < len(segment)-1; i++ {
+				if !isVarNameChar(segment[i]) {
+					return nil, false
+				}
+			}
+			segments[k] = ":" + segment[1:len(segment)-1]
+		case strings.ContainsAny(segment, ":{*"):
+			return nil, false
+		}
+	}
+	return segments, true
+}
+
+// indexPath returns the path of a route in the case it is matched with.
+func indexPath(route RouteInterface) string {
+	if router := route.GetRouter(); router != nil && !router.CaseSensitiveURL {
+		return strings.ToLower(route.GetPath())
+	}
+	return route.GetPath()
+}
+
+// isStaticRoute returns true if the route only matches a static path. It is
+// checked on a hit as matchers may be added after registration.
+func isStaticRoute(route RouteInterface) bool {
+	return route.Kind() == kindNormalPath && len(route.GetMatchers()) == 1 && !route.HasError() && !route.IsDisabled()
+}
+
+// match returns the first route of the static path or the first candidate
+// route which matches the request.
+func (idx *routeIndex) match(req *http.Request) RouteInterface {
+	for _, pos := range idx.exact[req.URL.Path] {
+		route := idx.routes[pos]
+		if isStaticRoute(route) {
+			return route
+		}
+		if route := route.Match(req); route != nil {
+			return route
+		}
+	}
+
+	candidates := idx.root.collect(req.URL.Path, append([]int{}, idx.fallback...))
+	sort.Ints(candidates)
+
+	for _, pos := range candidates {
+		if route := idx.routes[pos].Match(req); route != nil {
+			return route
+		}
+	}
+	return nil
+}
+
+// collect appends the routes matching the remaining path to candidates.
+// The path starts with the segment which is resolved by a child of n.
+func (n *indexNode) collect(path string, candidates []int) []int {
+	candidates = append(candidates, n.catchAll...)
+
+	segment, rest, more := strings.Cut(path, "/")
+
+	if child, found := n.static[segment]; found {
+		candidates = child.resolve(rest, more, candidates)
+	}
+	if n.param != nil && segment != "" {
+		candidates = n.param.resolve(rest, more, candidates)
+	}
+	return candidates
+}
+
+// resolve appends the routes of n if the path ends here, otherwise it
+// descends with the rest of the path.
+func (n *indexNode) resolve(rest string, more bool, candidates []int) []int {
+	if !more {
+		return append(candidates, n.routes...)
+	}
+	return n.collect(rest, candidates)
+}
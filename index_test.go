@@ -0,0 +1,168 @@
+package mux
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouteIndex(t *testing.T) {
+	r := Classic()
+	handler := func(key string) func(w http.ResponseWriter, r *http.Request) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, key)
+		}
+	}
+
+	r.Get("/users", handler("users"))
+	r.Get("/users/:id", handler("user"))
+	r.Get("/users/new", handler("new"))
+	r.Get("/users/:id/posts/:number", handler("post"))
+	r.Get("/files/{name:[a-z]+\\.txt}", handler("braced"))
+	r.Get("/static/*filepath", handler("static"))
+	r.Get("/reports/:year/:month?", handler("report"))
+	r.Get("/regex/#([0-9]+)", handler("regex"))
+	r.Register(r.PathPrefix("/api").Methods(http.MethodGet).HandlerFunc(handler("api")))
+	r.Get("/a-:id", handler("inside"))
+	r.Get("/articles/{id}/comments", handler("comments"))
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/users", "users"},
+		{"/users/", "404 page not found\n"},
+		{"/users/42", "user"},
+		// static paths win over vars
+		{"/users/new", "new"},
+		{"/users/42/posts/7", "post"},
+		{"/users/42/posts/seven", "404 page not found\n"},
+		{"/files/notes.txt", "braced"},
+		{"/static/css/app.css", "static"},
+		{"/static/", "static"},
+		{"/static", "404 page not found\n"},
+		{"/reports/2020", "report"},
+		{"/reports/2020/05", "report"},
+		{"/regex/123", "regex"},
+		{"/api/users", "api"},
+		{"/a-1", "inside"},
+		{"/articles/7/comments", "comments"},
+		{"/unknown", "404 page not found\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+			if w.Body.String() != test.body {
+				t.Errorf("Expected body %q, got %q", test.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouteIndexAfterSort(t *testing.T) {
+	r := Classic()
+	handler := func(key string) func(w http.ResponseWriter, r *http.Request) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, key)
+		}
+	}
+
+	r.Get("/users/new", handler("new"))
+	r.Get("/users/:id", handler("user"))
+	r.Get("#/users/([a-z]+)", handler("regex"))
+	r.SortRoutes()
+
+	// routes with vars are sorted before normal paths
+	tests := map[string]string{
+		"/users/new": "new",
+		"/users/42":  "user",
+		"/users/abc": "user",
+	}
+
+	for path, body := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if w.Body.String() != body {
+			t.Errorf("Expected body %q, got %q", body, w.Body.String())
+		}
+	}
+}
+
+func TestRouteIndexExactPath(t *testing.T) {
+	r := Classic()
+	handler := func(key string) func(w http.ResponseWriter, r *http.Request) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, key)
+		}
+	}
+
+	r.Get("/users/:id", handler("user"))
+	r.Register(r.NewRoute().Path("/users/me").Methods(http.MethodGet).HeadersPresent("Authorization").HandlerFunc(handler("me")))
+	r.Get("/users/me", handler("anonymous"))
+	r.Get("/about", handler("about")).Name("about")
+
+	tests := []struct {
+		path          string
+		authorization bool
+		body          string
+	}{
+		{"/users/me", true, "me"},
+		{"/users/me", false, "anonymous"},
+		{"/about", false, "about"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+		if test.authorization {
+			req.Header.Set("Authorization", "Bearer token")
+		}
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Body.String() != test.body {
+			t.Errorf("Expected body %q, got %q", test.body, w.Body.String())
+		}
+	}
+}
+
+func TestRouteIndexCandidates(t *testing.T) {
+	r := Classic()
+	r.Get("/a/b", nil)
+	r.Get("/a/:x", nil)
+	r.Get("/a/#(.*)", nil)
+	r.Get("/a/*rest", nil)
+	r.Get("/c/:x", nil)
+
+	idx := r.table().index(http.MethodGet)
+	candidates := idx.root.collect("/a/b", append([]int{}, idx.fallback...))
+
+	expected := map[int]bool{0: true, 1: true, 2: true, 3: true}
+	if len(candidates) != len(expected) {
+		t.Fatalf("Expected %d candidates, got %v", len(expected), candidates)
+	}
+	for _, pos := range candidates {
+		if !expected[pos] {
+			t.Errorf("Unexpected candidate %d", pos)
+		}
+	}
+}
+
+func BenchmarkRouteIndex(b *testing.B) {
+	r := Classic()
+	for i := 0; i < 500; i++ {
+		r.Get(fmt.Sprintf("/resource%d/:id", i), func(w http.ResponseWriter, r *http.Request) {})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/resource499/42", nil)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.triggerMatching(req)
+	}
+}
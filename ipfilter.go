@@ -0,0 +1,45 @@
+package mux
+
+import (
+	"net/http"
+)
+
+// AllowIPs returns a middleware which answers the requests of clients
+// outside the networks (IPs or CIDRs) with 403 Forbidden, e.g. for an admin
+// panel:
+//
+//     admin := r.PathPrefix("/admin").Subrouter()
+//     admin.Use(mux.AllowIPs("10.0.0.0/8", "192.168.1.17"))
+//
+// The client IP is taken from the forwarding headers of trusted proxies,
+// see ClientIP and Router.SetTrustedProxies. Unlike Route.RemoteIPs, the
+// route still matches, so other clients get 403 instead of 404. It panics
+// if a network is invalid.
+func AllowIPs(networks ...string) MiddlewareFunc {
+	return ipFilter(true, networks)
+}
+
+// DenyIPs returns a middleware which answers the requests of clients inside
+// the networks (IPs or CIDRs) with 403 Forbidden, see AllowIPs.
+func DenyIPs(networks ...string) MiddlewareFunc {
+	return ipFilter(false, networks)
+}
+
+// ipFilter passes the requests whose client IP is in the networks on if
+// allow is set, otherwise the requests whose client IP isn't.
+func ipFilter(allow bool, networks []string) MiddlewareFunc {
+	nets, err := parseNetworks(networks...)
+	if err != nil {
+		panic(err)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if containsIP(nets, ClientIP(req)) != allow {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
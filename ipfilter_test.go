@@ -0,0 +1,56 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAllowDenyIPs(t *testing.T) {
+	r := Classic()
+	if err := r.SetTrustedProxies("10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	admin := r.PathPrefix("/admin").Subrouter()
+	admin.Use(AllowIPs("192.168.0.0/16", "2001:db8::1"))
+	admin.Use(DenyIPs("192.168.66.0/24"))
+	admin.Get("/", func(w http.ResponseWriter, req *http.Request) {})
+
+	tests := []struct {
+		remoteAddr, forwardedFor string
+		code                     int
+	}{
+		{"192.168.1.5:1234", "", http.StatusOK},
+		{"[2001:db8::1]:1234", "", http.StatusOK},
+		{"203.0.113.9:1234", "", http.StatusForbidden},
+		{"192.168.66.7:1234", "", http.StatusForbidden},
+		// the client of a trusted proxy is checked
+		{"10.0.0.1:1234", "192.168.1.5", http.StatusOK},
+		{"10.0.0.1:1234", "203.0.113.9", http.StatusForbidden},
+		// an untrusted peer can't spoof its address
+		{"203.0.113.9:1234", "192.168.1.5", http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/admin/", nil)
+		req.RemoteAddr = test.remoteAddr
+		if test.forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", test.forwardedFor)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != test.code {
+			t.Errorf("Unexpected status for %s (%s) (%d)", test.remoteAddr, test.forwardedFor, w.Code)
+		}
+	}
+}
+
+func TestAllowIPsInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic")
+		}
+	}()
+	AllowIPs("10.0.0.0/33")
+}
@@ -0,0 +1,461 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Claims are the claims of a JSON Web Token.
+type Claims map[string]interface{}
+
+// Subject returns the sub claim.
+func (c Claims) Subject() string {
+	s, _ := c["sub"].(string)
+	return s
+}
+
+// KeyProvider returns the key which verifies the signature of a token,
+// selected by the algorithm and the key ID (kid) of its header: a []byte for
+// HS256, HS384 and HS512, an *rsa.PublicKey for RS256, RS384, RS512, PS256,
+// PS384 and PS512, an *ecdsa.PublicKey for ES256, ES384 and ES512, and an
+// ed25519.PublicKey for EdDSA. It has to be safe for concurrent use.
+type KeyProvider interface {
+	Key(ctx context.Context, alg, kid string) (interface{}, error)
+}
+
+// KeyProviderFunc is an adapter to use a function as KeyProvider.
+type KeyProviderFunc func(ctx context.Context, alg, kid string) (interface{}, error)
+
+// Key calls f(ctx, alg, kid).
+func (f KeyProviderFunc) Key(ctx context.Context, alg, kid string) (interface{}, error) {
+	return f(ctx, alg, kid)
+}
+
+// StaticKey returns a KeyProvider which returns key for every token.
+func StaticKey(key interface{}) KeyProvider {
+	return KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+		return key, nil
+	})
+}
+
+// JWTOptions configures the JWT middleware.
+type JWTOptions struct {
+	// Keys provides the keys which verify the signatures.
+	Keys KeyProvider
+	// Algorithms are the accepted signature algorithms, they default to
+	// the ones matching the type of the key.
+	Algorithms []string
+	// Issuer is the required iss claim, if set.
+	Issuer string
+	// Audience is a required element of the aud claim, if set.
+	Audience string
+	// Leeway is the tolerated clock skew for the exp and nbf claims.
+	Leeway time.Duration
+	// Clock returns the current time, it defaults to time.Now.
+	Clock func() time.Time
+}
+
+// JWT returns a middleware which authenticates the requests with a JSON Web
+// Token in the Authorization header (Bearer scheme), e.g.:
+//
+//     api := r.PathPrefix("/api").Subrouter()
+//     api.Use(mux.JWT(mux.JWTOptions{
+//         Keys:     mux.NewJWKS("https://auth.example.com/.well-known/jwks.json", time.Hour),
+//         Issuer:   "https://auth.example.com/",
+//         Audience: "api",
+//     }))
+//
+// The signature, the exp and nbf claims and the configured issuer and
+// audience are verified before the handler runs, which gets the claims with
+// JWTClaims. Requests without a valid token are answered with 401
+// Unauthorized and a WWW-Authenticate challenge.
+func JWT(opts JWTOptions) MiddlewareFunc {
+	if opts.Clock == nil {
+		opts.Clock = time.Now
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			scheme, token, _ := strings.Cut(req.Header.Get("Authorization"), " ")
+			if !strings.EqualFold(scheme, "Bearer") || token == "" {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			claims, err := verifyJWT(req.Context(), strings.TrimSpace(token), opts)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, contextSet(req, claimsKey, claims))
+		})
+	}
+}
+
+// JWTClaims returns the claims of the token verified by the JWT middleware,
+// nil if there are none.
+func JWTClaims(r *http.Request) Claims {
+	if rv := contextGet(r, claimsKey); rv != nil {
+		return rv.(Claims)
+	}
+	return nil
+}
+
+// verifyJWT returns the claims of a valid token.
+func verifyJWT(ctx context.Context, token string, opts JWTOptions) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("mux: malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if opts.Algorithms != nil && !containsString(opts.Algorithms, header.Alg) {
+		return nil, fmt.Errorf("mux: algorithm %q isn't accepted", header.Alg)
+	}
+
+	key, err := opts.Keys.Key(ctx, header.Alg, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("mux: malformed signature")
+	}
+	if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if claims == nil {
+		return nil, errors.New("mux: malformed token")
+	}
+	return claims, verifyClaims(claims, opts)
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return errors.New("mux: malformed token")
+	}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(v); err != nil {
+		return errors.New("mux: malformed token")
+	}
+	return nil
+}
+
+var errSignature = errors.New("mux: invalid signature")
+
+// verifySignature verifies the signature of the signed data with a key,
+// which has to be of the type of the algorithm.
+func verifySignature(alg string, key interface{}, signed, signature []byte) error {
+	var hash crypto.Hash
+	var curveBits int
+	switch {
+	case strings.HasSuffix(alg, "256"):
+		hash, curveBits = crypto.SHA256, 256
+	case strings.HasSuffix(alg, "384"):
+		hash, curveBits = crypto.SHA384, 384
+	case strings.HasSuffix(alg, "512"):
+		hash, curveBits = crypto.SHA512, 521
+	}
+	digest := func() []byte {
+		h := hash.New()
+		h.Write(signed)
+		return h.Sum(nil)
+	}
+
+	switch {
+	case alg != "EdDSA" && len(alg) != len("HS256"):
+		return fmt.Errorf("mux: algorithm %q isn't supported", alg)
+	case alg == "EdDSA":
+		k, ok := key.(ed25519.PublicKey)
+		if !ok || !ed25519.Verify(k, signed, signature) {
+			return errSignature
+		}
+	case hash == 0:
+		return fmt.Errorf("mux: algorithm %q isn't supported", alg)
+	case strings.HasPrefix(alg, "HS"):
+		k, ok := key.([]byte)
+		if !ok {
+			return errSignature
+		}
+		mac := hmac.New(hash.New, k)
+		mac.Write(signed)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return errSignature
+		}
+	case strings.HasPrefix(alg, "RS"):
+		k, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPKCS1v15(k, hash, digest(), signature) != nil {
+			return errSignature
+		}
+	case strings.HasPrefix(alg, "PS"):
+		k, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPSS(k, hash, digest(), signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) != nil {
+			return errSignature
+		}
+	case strings.HasPrefix(alg, "ES"):
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok || k.Curve.Params().BitSize != curveBits {
+			return errSignature
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errSignature
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, digest(), r, s) {
+			return errSignature
+		}
+	default:
+		return fmt.Errorf("mux: algorithm %q isn't supported", alg)
+	}
+	return nil
+}
+
+// verifyClaims verifies the time, issuer and audience claims.
+func verifyClaims(claims Claims, opts JWTOptions) error {
+	now := opts.Clock()
+
+	if exp, found := claims["exp"]; found {
+		t, ok := numericDate(exp)
+		if !ok || !now.Before(t.Add(opts.Leeway)) {
+			return errors.New("mux: token is expired")
+		}
+	}
+	if nbf, found := claims["nbf"]; found {
+		t, ok := numericDate(nbf)
+		if !ok || now.Add(opts.Leeway).Before(t) {
+			return errors.New("mux: token isn't valid yet")
+		}
+	}
+
+	if opts.Issuer != "" && claims["iss"] != opts.Issuer {
+		return errors.New("mux: unexpected issuer")
+	}
+	if opts.Audience != "" {
+		var audience []string
+		switch aud := claims["aud"].(type) {
+		case string:
+			audience = []string{aud}
+		case []interface{}:
+			for _, v := range aud {
+				if s, ok := v.(string); ok {
+					audience = append(audience, s)
+				}
+			}
+		}
+		if !containsString(audience, opts.Audience) {
+			return errors.New("mux: unexpected audience")
+		}
+	}
+	return nil
+}
+
+// numericDate converts a JSON number of seconds since the epoch to a time.
+// Numbers outside the range of int64 seconds are invalid.
+func numericDate(v interface{}) (time.Time, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	f, err := n.Float64()
+	if err != nil || !(f >= math.MinInt64 && f < math.MaxInt64) {
+		return time.Time{}, false
+	}
+	sec := math.Floor(f)
+	return time.Unix(int64(sec), int64((f-sec)*float64(time.Second))), true
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// NewJWKS returns a KeyProvider which fetches the keys from a JSON Web Key
+// Set URL. The keys are fetched again after refresh, or earlier when a token
+// has an unknown key ID, at most once per minute.
+func NewJWKS(url string, refresh time.Duration) KeyProvider {
+	return &jwks{url: url, refresh: refresh, client: http.DefaultClient}
+}
+
+type jwks struct {
+	url     string
+	refresh time.Duration
+	client  *http.Client
+
+	mu      sync.Mutex
+	keys    map[string]interface{}
+	err     error
+	fetched time.Time
+	// fetching is closed when the running fetch completes, nil if none runs
+	fetching chan struct{}
+}
+
+// jwksMinInterval is the minimum interval between two fetches of a key set.
+const jwksMinInterval = time.Minute
+
+func (s *jwks) Key(ctx context.Context, alg, kid string) (interface{}, error) {
+	s.mu.Lock()
+	since := time.Since(s.fetched)
+	_, known := s.keys[kid]
+	if s.keys == nil || since >= s.refresh || (!known && since >= jwksMinInterval) {
+		if s.fetching == nil {
+			// the keys are fetched without the lock, so the requests with
+			// known keys aren't blocked by a slow key server
+			done := make(chan struct{})
+			s.fetching = done
+			s.mu.Unlock()
+
+			keys, err := s.fetch(ctx)
+
+			s.mu.Lock()
+			if err == nil {
+				s.keys = keys
+			}
+			s.err = err
+			s.fetched = time.Now()
+			s.fetching = nil
+			close(done)
+		} else if !known {
+			// wait for the running fetch
+			done := s.fetching
+			s.mu.Unlock()
+			select {
+			case <-done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			s.mu.Lock()
+		}
+	}
+	defer s.mu.Unlock()
+
+	if key, found := s.keys[kid]; found {
+		return key, nil
+	}
+	if s.keys == nil && s.err != nil {
+		return nil, s.err
+	}
+	return nil, fmt.Errorf("mux: key %q not found", kid)
+}
+
+// fetch downloads and parses the key set.
+func (s *jwks) fetch(ctx context.Context) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("mux: fetching %s: %s", s.url, res.Status)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("mux: fetching %s: %v", s.url, err)
+	}
+
+	keys := map[string]interface{}{}
+	for _, k := range set.Keys {
+		// keys of other types or uses are skipped, as are symmetric keys
+		// which mustn't be public
+		if key, err := k.publicKey(); err == nil && (k.Use == "" || k.Use == "sig") {
+			keys[k.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+// jsonWebKey is a key of a JSON Web Key Set (RFC 7517).
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	b64 := base64.RawURLEncoding
+	switch k.Kty {
+	case "RSA":
+		n, err1 := b64.DecodeString(k.N)
+		e, err2 := b64.DecodeString(k.E)
+		if err := errors.Join(err1, err2); err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("mux: curve %q isn't supported", k.Crv)
+		}
+		x, err1 := b64.DecodeString(k.X)
+		y, err2 := b64.DecodeString(k.Y)
+		if err := errors.Join(err1, err2); err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		x, err := b64.DecodeString(k.X)
+		if err != nil || k.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("mux: invalid OKP key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("mux: key type %q isn't supported", k.Kty)
+}
@@ -0,0 +1,267 @@
+package mux
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// signJWT returns a token with the header and claims signed by sign.
+func signJWT(header, claims map[string]interface{}, sign func(signed []byte) []byte) string {
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+func hs256(key []byte) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		mac := hmac.New(sha256.New, key)
+		mac.Write(signed)
+		return mac.Sum(nil)
+	}
+}
+
+func jwtRouter(opts JWTOptions) *Router {
+	r := Classic()
+	api := r.PathPrefix("/api").Subrouter()
+	api.Use(JWT(opts))
+	api.Get("/me", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, JWTClaims(req).Subject())
+	})
+	return r
+}
+
+func serveJWT(r *Router, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestJWT(t *testing.T) {
+	key := []byte("secret")
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	r := jwtRouter(JWTOptions{
+		Keys:     StaticKey(key),
+		Issuer:   "auth",
+		Audience: "api",
+		Leeway:   time.Minute,
+		Clock:    func() time.Time { return now },
+	})
+
+	header := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+	claims := func(changes map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{"sub": "alice", "iss": "auth", "aud": []string{"web", "api"}, "exp": now.Unix() + 60}
+		for k, v := range changes {
+			c[k] = v
+		}
+		return c
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		code  int
+	}{
+		{"valid", signJWT(header, claims(nil), hs256(key)), http.StatusOK},
+		{"missing", "", http.StatusUnauthorized},
+		{"malformed", "abc.def", http.StatusUnauthorized},
+		{"wrong key", signJWT(header, claims(nil), hs256([]byte("other"))), http.StatusUnauthorized},
+		{"expired", signJWT(header, claims(map[string]interface{}{"exp": now.Unix() - 120}), hs256(key)), http.StatusUnauthorized},
+		{"expired within leeway", signJWT(header, claims(map[string]interface{}{"exp": now.Unix() - 30}), hs256(key)), http.StatusOK},
+		{"not yet valid", signJWT(header, claims(map[string]interface{}{"nbf": now.Unix() + 120}), hs256(key)), http.StatusUnauthorized},
+		{"expires after 2262", signJWT(header, claims(map[string]interface{}{"exp": 1e10}), hs256(key)), http.StatusOK},
+		{"valid after 2262", signJWT(header, claims(map[string]interface{}{"nbf": 1e10}), hs256(key)), http.StatusUnauthorized},
+		{"expiry out of range", signJWT(header, claims(map[string]interface{}{"exp": 1e19}), hs256(key)), http.StatusUnauthorized},
+		{"wrong issuer", signJWT(header, claims(map[string]interface{}{"iss": "other"}), hs256(key)), http.StatusUnauthorized},
+		{"wrong audience", signJWT(header, claims(map[string]interface{}{"aud": "web"}), hs256(key)), http.StatusUnauthorized},
+		{"none", signJWT(map[string]interface{}{"alg": "none"}, claims(nil), func([]byte) []byte { return nil }), http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		w := serveJWT(r, test.token)
+		if w.Code != test.code {
+			t.Errorf("Unexpected status for %s (%d)", test.name, w.Code)
+		}
+		if test.code == http.StatusOK && w.Body.String() != "alice" {
+			t.Errorf("Unexpected claims for %s (%s)", test.name, w.Body.String())
+		}
+		if test.code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("Missing challenge for %s", test.name)
+		}
+	}
+}
+
+func TestJWTAlgorithms(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPublic, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	claims := map[string]interface{}{"sub": "alice"}
+
+	rs256 := signJWT(map[string]interface{}{"alg": "RS256"}, claims, func(signed []byte) []byte {
+		sum := sha256.Sum256(signed)
+		sig, _ := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, sum[:])
+		return sig
+	})
+	ps256 := signJWT(map[string]interface{}{"alg": "PS256"}, claims, func(signed []byte) []byte {
+		sum := sha256.Sum256(signed)
+		sig, _ := rsa.SignPSS(rand.Reader, rsaKey, crypto.SHA256, sum[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		return sig
+	})
+	es256 := signJWT(map[string]interface{}{"alg": "ES256"}, claims, func(signed []byte) []byte {
+		sum := sha256.Sum256(signed)
+		r, s, _ := ecdsa.Sign(rand.Reader, ecKey, sum[:])
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig
+	})
+	eddsa := signJWT(map[string]interface{}{"alg": "EdDSA"}, claims, func(signed []byte) []byte {
+		return ed25519.Sign(edPrivate, signed)
+	})
+	// an HMAC signed with the public key mustn't be accepted
+	confused := signJWT(map[string]interface{}{"alg": "HS256"}, claims, hs256(rsaKey.PublicKey.N.Bytes()))
+
+	tests := []struct {
+		key   interface{}
+		token string
+		code  int
+	}{
+		{&rsaKey.PublicKey, rs256, http.StatusOK},
+		{&rsaKey.PublicKey, ps256, http.StatusOK},
+		{&ecKey.PublicKey, es256, http.StatusOK},
+		{edPublic, eddsa, http.StatusOK},
+		{&rsaKey.PublicKey, confused, http.StatusUnauthorized},
+		{&ecKey.PublicKey, rs256, http.StatusUnauthorized},
+	}
+
+	for i, test := range tests {
+		w := serveJWT(jwtRouter(JWTOptions{Keys: StaticKey(test.key)}), test.token)
+		if w.Code != test.code || (test.code == http.StatusOK && w.Body.String() != "alice") {
+			t.Errorf("Unexpected response for test %d (%d, %s)", i, w.Code, w.Body.String())
+		}
+	}
+
+	r := jwtRouter(JWTOptions{Keys: StaticKey(&rsaKey.PublicKey), Algorithms: []string{"PS256"}})
+	if w := serveJWT(r, rs256); w.Code != http.StatusUnauthorized {
+		t.Errorf("Unexpected status of an algorithm which isn't accepted (%d)", w.Code)
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fetches++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "k1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(rsaKey.PublicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.PublicKey.E)).Bytes()),
+			}},
+		})
+	}))
+	defer server.Close()
+
+	sign := func(kid string) string {
+		return signJWT(map[string]interface{}{"alg": "RS256", "kid": kid}, map[string]interface{}{"sub": "alice"}, func(signed []byte) []byte {
+			sum := sha256.Sum256(signed)
+			sig, _ := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, sum[:])
+			return sig
+		})
+	}
+
+	r := jwtRouter(JWTOptions{Keys: NewJWKS(server.URL, time.Hour)})
+
+	for i := 0; i < 2; i++ {
+		if w := serveJWT(r, sign("k1")); w.Code != http.StatusOK || w.Body.String() != "alice" {
+			t.Errorf("Unexpected response (%d, %s)", w.Code, w.Body.String())
+		}
+	}
+	if w := serveJWT(r, sign("k2")); w.Code != http.StatusUnauthorized {
+		t.Errorf("Unexpected status for an unknown key (%d)", w.Code)
+	}
+	if fetches != 1 {
+		t.Errorf("Unexpected number of fetches (%d)", fetches)
+	}
+}
+
+func TestJWKSConcurrentFetch(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	var fetches int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&fetches, 1) > 1 {
+			<-release
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "k1",
+				"n":   base64.RawURLEncoding.EncodeToString(rsaKey.PublicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.PublicKey.E)).Bytes()),
+			}},
+		})
+	}))
+	defer server.Close()
+	defer close(release)
+
+	keys := NewJWKS(server.URL, time.Hour).(*jwks)
+	if _, err := keys.Key(context.Background(), "RS256", "k1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a refresh hanging on the key server doesn't block the known keys
+	keys.mu.Lock()
+	keys.fetched = time.Time{}
+	keys.mu.Unlock()
+	go keys.Key(context.Background(), "RS256", "k1")
+	for atomic.LoadInt32(&fetches) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := keys.Key(context.Background(), "RS256", "k1")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Unexpected error (%v)", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Key is blocked by the running fetch")
+	}
+
+	// unknown keys wait for the running fetch instead of fetching again
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := keys.Key(ctx, "RS256", "k2"); err != context.DeadlineExceeded {
+		t.Errorf("Unexpected error (%v)", err)
+	}
+	if n := atomic.LoadInt32(&fetches); n != 2 {
+		t.Errorf("Unexpected number of fetches (%d)", n)
+	}
+}
@@ -0,0 +1,87 @@
+package mux
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ConcurrencyOptions configures the ConcurrencyLimit middleware.
+type ConcurrencyOptions struct {
+	// Limit is the number of requests handled at once, it defaults to 1.
+	Limit int
+	// Queue is the number of requests which may wait for a free slot, none
+	// by default.
+	Queue int
+	// QueueTimeout is the time a request waits in the queue, zero means
+	// until the client goes away.
+	QueueTimeout time.Duration
+}
+
+// ConcurrencyLimit returns a middleware which limits the number of requests
+// in flight, e.g. to keep a slow export route from starving the others:
+//
+//     r.Get("/export", exportHandler).Use(mux.ConcurrencyLimit(mux.ConcurrencyOptions{
+//         Limit:        4,
+//         Queue:        16,
+//         QueueTimeout: 5 * time.Second,
+//     }))
+//
+// Requests over the limit wait in the queue. If the queue is full or the
+// queue timeout expires, they are answered with 503 Service Unavailable.
+// The requests of all routes using the middleware share the limit.
+func ConcurrencyLimit(opts ConcurrencyOptions) MiddlewareFunc {
+	if opts.Limit < 1 {
+		opts.Limit = 1
+	}
+	if opts.Queue < 0 {
+		opts.Queue = 0
+	}
+
+	slots := make(chan struct{}, opts.Limit)
+	var waiting atomic.Int64
+
+	acquire := func(req *http.Request) bool {
+		select {
+		case slots <- struct{}{}:
+			return true
+		default:
+		}
+
+		if waiting.Add(1) > int64(opts.Queue) {
+			waiting.Add(-1)
+			return false
+		}
+		defer waiting.Add(-1)
+
+		var timeout <-chan time.Time
+		if opts.QueueTimeout > 0 {
+			timer := time.NewTimer(opts.QueueTimeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+
+		select {
+		case slots <- struct{}{}:
+			return true
+		case <-timeout:
+			return false
+		case <-req.Context().Done():
+			return false
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if !acquire(req) {
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+				return
+			}
+			defer func() {
+				<-slots
+			}()
+
+			next.ServeHTTP(w, req)
+		})
+	}
+}
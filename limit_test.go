@@ -0,0 +1,96 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+
+	r := Classic()
+	r.Get("/export", func(w http.ResponseWriter, req *http.Request) {
+		started <- struct{}{}
+		<-release
+	}).Use(ConcurrencyLimit(ConcurrencyOptions{Limit: 1}))
+
+	done := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export", nil))
+		done <- w.Code
+	}()
+	<-started
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Unexpected status when saturated (%d)", w.Code)
+	}
+
+	close(release)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("Unexpected status (%d)", code)
+	}
+
+	// the slot is free again
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Unexpected status after the release (%d)", w.Code)
+	}
+}
+
+func TestConcurrencyLimitQueue(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+
+	r := Classic()
+	r.Get("/export", func(w http.ResponseWriter, req *http.Request) {
+		started <- struct{}{}
+		<-release
+	}).Use(ConcurrencyLimit(ConcurrencyOptions{Limit: 1, Queue: 1}))
+
+	done := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export", nil))
+			done <- w.Code
+		}()
+	}
+	<-started
+
+	time.AfterFunc(20*time.Millisecond, func() {
+		close(release)
+	})
+	for i := 0; i < 2; i++ {
+		if code := <-done; code != http.StatusOK {
+			t.Errorf("Unexpected status of a queued request (%d)", code)
+		}
+	}
+}
+
+func TestConcurrencyLimitQueueTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+
+	r := Classic()
+	r.Get("/slow", func(w http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+	}).Use(ConcurrencyLimit(ConcurrencyOptions{Limit: 1, Queue: 1, QueueTimeout: 20 * time.Millisecond}))
+
+	go r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	<-started
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Unexpected status after the queue timeout (%d)", w.Code)
+	}
+}
@@ -0,0 +1,105 @@
+package mux
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// StructuredLogger logs the events of the router, see Router.Log. It is
+// implemented by *slog.Logger.
+type StructuredLogger interface {
+	LogAttrs(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr)
+}
+
+// logger returns the structured logger of the router, slog.Default() if it
+// has none.
+func (r *Router) logger() StructuredLogger {
+	if r != nil && r.Log != nil {
+		return r.Log
+	}
+	return slog.Default()
+}
+
+// requestLogger returns the structured logger of the router of the matched
+// route, slog.Default() if there is none.
+func requestLogger(req *http.Request) StructuredLogger {
+	if route := CurrentRoute(req); route != nil {
+		return route.GetRouter().logger()
+	}
+	return slog.Default()
+}
+
+// RouteAttrs returns the log attributes of the current request: the method,
+// the path and, if a route matched, its name and pattern, e.g. for the log
+// entries of a handler:
+//
+//     logger.LogAttrs(req.Context(), slog.LevelInfo, "order created", mux.RouteAttrs(req)...)
+//
+func RouteAttrs(r *http.Request) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+	}
+	if route := CurrentRoute(r); route != nil {
+		attrs = append(attrs, slog.String("pattern", route.GetPath()))
+		if name := route.GetName(); name != "" {
+			attrs = append(attrs, slog.String("route", name))
+		}
+	}
+	return attrs
+}
+
+// LogRequests returns a middleware which logs every request to the
+// structured logger of the router (see Router.Log) at level, with the
+// attributes of RouteAttrs, the status, the size of the body and the
+// duration, e.g.:
+//
+//     r.Log = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+//     r.Use(mux.LogRequests(slog.LevelInfo))
+//
+// Responses with a 5xx status are logged at slog.LevelError at least. Like
+// every middleware it only wraps matched routes, the requests without a
+// route are logged by the router at slog.LevelDebug.
+func LogRequests(level slog.Level) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			sw := &sizeWriter{statusWriter: statusWriter{ResponseWriter: w, code: http.StatusOK}}
+			next.ServeHTTP(sw, req)
+
+			entryLevel := level
+			if sw.code >= http.StatusInternalServerError && entryLevel < slog.LevelError {
+				entryLevel = slog.LevelError
+			}
+			attrs := append(RouteAttrs(req),
+				slog.Int("status", sw.code),
+				slog.Int64("bytes", sw.size),
+				slog.Duration("duration", time.Since(start)),
+			)
+			requestLogger(req).LogAttrs(req.Context(), entryLevel, "mux: request", attrs...)
+		})
+	}
+}
+
+// logUnmatched logs a request without a matching route at debug level.
+func (r *Router) logUnmatched(req *http.Request, code int) {
+	r.logger().LogAttrs(req.Context(), slog.LevelDebug, "mux: no route",
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.Int("status", code),
+	)
+}
+
+// sizeWriter records the status and the size of the body of a response.
+type sizeWriter struct {
+	statusWriter
+	size int64
+}
+
+func (w *sizeWriter) Write(b []byte) (int, error) {
+	n, err := w.statusWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
@@ -0,0 +1,90 @@
+package mux
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// logEntries returns the entries of a JSON log.
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	entries := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		entry := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Unexpected log line %q (%v)", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestLogRequests(t *testing.T) {
+	var buf bytes.Buffer
+	r := Classic()
+	r.Log = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	r.Use(LogRequests(slog.LevelInfo))
+	r.Get("/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("alice"))
+	}).Name("user")
+	r.Get("/fail", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	for _, path := range []string{"/users/1", "/fail", "/missing"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	entries := logEntries(t, &buf)
+	if len(entries) != 3 {
+		t.Fatalf("Unexpected log entries (%s)", buf.String())
+	}
+
+	expected := []map[string]interface{}{
+		{"level": "INFO", "msg": "mux: request", "method": "GET", "path": "/users/1", "pattern": "/users/:id", "route": "user", "status": float64(200), "bytes": float64(5)},
+		{"level": "ERROR", "msg": "mux: request", "pattern": "/fail", "status": float64(502)},
+		{"level": "DEBUG", "msg": "mux: no route", "path": "/missing", "status": float64(404)},
+	}
+	for k, attrs := range expected {
+		for key, value := range attrs {
+			if entries[k][key] != value {
+				t.Errorf("Unexpected %s of entry %d (%v)", key, k, entries[k][key])
+			}
+		}
+	}
+	if _, found := entries[0]["duration"]; !found {
+		t.Error("Missing duration")
+	}
+}
+
+func TestRecoveryStructuredLog(t *testing.T) {
+	var buf bytes.Buffer
+	r := Classic()
+	r.Log = slog.New(slog.NewJSONHandler(&buf, nil))
+	api := r.PathPrefix("/api").Subrouter()
+	api.Use(Recovery(nil, nil))
+	api.Get("/boom", func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/boom", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Unexpected status (%d)", w.Code)
+	}
+
+	entries := logEntries(t, &buf)
+	if len(entries) != 1 || entries[0]["msg"] != "mux: panic" || entries[0]["panic"] != "boom" || entries[0]["pattern"] != "/api/boom" {
+		t.Errorf("Unexpected log (%s)", buf.String())
+	}
+	if stack, _ := entries[0]["stack"].(string); !strings.Contains(stack, "goroutine") {
+		t.Errorf("Missing stack (%v)", entries[0]["stack"])
+	}
+}
@@ -1,21 +1,101 @@
 package mux
 
 import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
 	"net/http"
 	"regexp"
 	"strings"
+	"time"
 )
 
+// Rank orders the matchers of a route. The matchers are evaluated in
+// ascending rank, so a route is rejected by the cheap and selective matchers
+// (e.g. the method) before the expensive ones run. The routes and their
+// matchers are sorted by Router.SortRoutes, which ListenAndServe calls.
+//
+// The ranks of the built-in matchers are spaced, so a custom matcher can
+// declare a rank between them, e.g. RankHost + 1 to run after the host but
+// before the headers are matched.
+type Rank int
+
 const (
-	rankAny = iota
-	rankPath
-	rankScheme
+	// RankMethod is the rank of the method matcher.
+	RankMethod Rank = 0
+	// RankHost is the rank of the host matcher.
+	RankHost Rank = 10
+	// RankHeader is the rank of the matchers of headers and other request
+	// properties, e.g. cookies or the client IP.
+	RankHeader Rank = 20
+	// RankCustom is the rank of MatcherFunc.
+	RankCustom Rank = 30
+	// RankPath is the rank of the path matchers.
+	RankPath Rank = 40
+	// RankQuery is the rank of the query matcher.
+	RankQuery Rank = 50
+	// RankScheme is the rank of the scheme matcher.
+	RankScheme Rank = 60
 )
 
 // Matcher types try to match a request.
 type Matcher interface {
 	Match(*http.Request) bool
-	Rank() int
+	Rank() Rank
+}
+
+// Cost estimates how expensive a matcher is to evaluate. Matchers of equal
+// rank are evaluated in ascending cost, so cheap matchers short-circuit the
+// expensive ones.
+type Cost int
+
+const (
+	// CostLookup is the cost of a map lookup, e.g. of the method.
+	CostLookup Cost = 10
+	// CostCompare is the cost of comparing strings, e.g. of a static path.
+	CostCompare Cost = 20
+	// CostRegex is the cost of matching a regex, e.g. of a path with vars.
+	CostRegex Cost = 30
+	// CostCustom is the cost of a matcher which doesn't report its cost.
+	CostCustom Cost = 40
+)
+
+// Coster is implemented by matchers which report the cost of their
+// evaluation, see Cost. All built-in matchers implement it.
+type Coster interface {
+	Cost() Cost
+}
+
+// costOf returns the cost of a matcher, CostCustom if it doesn't report it.
+func costOf(m Matcher) Cost {
+	if c, ok := m.(Coster); ok {
+		return c.Cost()
+	}
+	return CostCustom
+}
+
+// comparisonsCost returns CostRegex if one of the comparisons is a regex,
+// otherwise CostCompare.
+func comparisonsCost(comparisons []comparison) Cost {
+	for _, cmp := range comparisons {
+		if _, ok := cmp.(regexComparsion); ok {
+			return CostRegex
+		}
+	}
+	return CostCompare
+}
+
+// comparisonValues returns the comparisons of a map.
+func comparisonValues(m map[string]comparison) []comparison {
+	comparisons := make([]comparison, 0, len(m))
+	for _, cmp := range m {
+		comparisons = append(comparisons, cmp)
+	}
+	return comparisons
 }
 
 // headerMatcher matches the request against header values.
@@ -34,8 +114,12 @@ func (m headerMatcher) Match(r *http.Request) bool {
 	return matchMap(m, r.Header, true)
 }
 
-func (m headerMatcher) Rank() int {
-	return rankAny
+func (m headerMatcher) Rank() Rank {
+	return RankHeader
+}
+
+func (m headerMatcher) Cost() Cost {
+	return CostCompare
 }
 
 // headerRegexMatcher matches the request against header values.
@@ -54,8 +138,387 @@ func (m headerRegexMatcher) Match(r *http.Request) bool {
 	return matchMap(m, r.Header, true)
 }
 
-func (m headerRegexMatcher) Rank() int {
-	return rankAny
+func (m headerRegexMatcher) Rank() Rank {
+	return RankHeader
+}
+
+func (m headerRegexMatcher) Cost() Cost {
+	return CostRegex
+}
+
+// headerPresentMatcher matches if all header keys are set.
+type headerPresentMatcher []string
+
+func newHeaderPresentMatcher(keys ...string) headerPresentMatcher {
+	matcher := make(headerPresentMatcher, len(keys))
+
+	for k, v := range keys {
+		matcher[k] = http.CanonicalHeaderKey(v)
+	}
+
+	return matcher
+}
+
+func (m headerPresentMatcher) Match(r *http.Request) bool {
+	for _, key := range m {
+		if _, found := r.Header[key]; !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (m headerPresentMatcher) Rank() Rank {
+	return RankHeader
+}
+
+func (m headerPresentMatcher) Cost() Cost {
+	return CostLookup
+}
+
+// headerAbsentMatcher matches if none of the header keys is set.
+type headerAbsentMatcher []string
+
+func newHeaderAbsentMatcher(keys ...string) headerAbsentMatcher {
+	matcher := make(headerAbsentMatcher, len(keys))
+
+	for k, v := range keys {
+		matcher[k] = http.CanonicalHeaderKey(v)
+	}
+
+	return matcher
+}
+
+func (m headerAbsentMatcher) Match(r *http.Request) bool {
+	for _, key := range m {
+		if _, found := r.Header[key]; found {
+			return false
+		}
+	}
+	return true
+}
+
+func (m headerAbsentMatcher) Rank() Rank {
+	return RankHeader
+}
+
+func (m headerAbsentMatcher) Cost() Cost {
+	return CostLookup
+}
+
+// contentTypeMatcher matches the media type of the Content-Type header
+// regardless of its parameters (e.g. "; charset=utf-8"). A "type/*" entry
+// matches any subtype.
+type contentTypeMatcher map[string]struct{}
+
+func newContentTypeMatcher(types ...string) (contentTypeMatcher, error) {
+	matcher := contentTypeMatcher{}
+
+	for _, v := range types {
+		mediaType, _, err := mime.ParseMediaType(v)
+		if err == nil && !strings.Contains(mediaType, "/") {
+			err = errors.New("expected the form type/subtype")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("mux: content type %q is invalid: %v", v, err)
+		}
+		matcher[mediaType] = struct{}{}
+	}
+
+	return matcher, nil
+}
+
+func (m contentTypeMatcher) Match(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+
+	if _, found := m[mediaType]; found {
+		return true
+	}
+
+	if k := strings.IndexByte(mediaType, '/'); k != -1 {
+		_, found := m[mediaType[:k]+"/*"]
+		return found
+	}
+
+	return false
+}
+
+func (m contentTypeMatcher) Rank() Rank {
+	return RankHeader
+}
+
+func (m contentTypeMatcher) Cost() Cost {
+	return CostCompare
+}
+
+// remoteIPMatcher matches the client IP (see ClientIP) against networks.
+type remoteIPMatcher []*net.IPNet
+
+func newRemoteIPMatcher(cidrs ...string) (remoteIPMatcher, error) {
+	nets, err := parseNetworks(cidrs...)
+	if err != nil {
+		return nil, err
+	}
+
+	return remoteIPMatcher(nets), nil
+}
+
+func (m remoteIPMatcher) Match(r *http.Request) bool {
+	return containsIP(m, ClientIP(r))
+}
+
+func (m remoteIPMatcher) Rank() Rank {
+	return RankHeader
+}
+
+func (m remoteIPMatcher) Cost() Cost {
+	return CostCompare
+}
+
+// userAgentMatcher matches if the User-Agent header contains one of its
+// strings, regardless of their case, or matches one of its regexes.
+type userAgentMatcher []comparison
+
+func newUserAgentMatcher(patterns ...string) (userAgentMatcher, error) {
+	matcher := make(userAgentMatcher, 0, len(patterns))
+
+	for _, v := range patterns {
+		if !strings.HasPrefix(v, "#") {
+			matcher = append(matcher, substringComparison(strings.ToLower(v)))
+			continue
+		}
+
+		regex, err := regexp.Compile(v[1:])
+		if err != nil {
+			return nil, err
+		}
+		matcher = append(matcher, regexComparsion{r: regex})
+	}
+
+	return matcher, nil
+}
+
+func (m userAgentMatcher) Match(r *http.Request) bool {
+	userAgent := r.UserAgent()
+	for _, cmp := range m {
+		if cmp.compare(userAgent) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m userAgentMatcher) Rank() Rank {
+	return RankHeader
+}
+
+func (m userAgentMatcher) Cost() Cost {
+	return comparisonsCost(m)
+}
+
+// clientCertMatcher matches the client certificate of a TLS request by its
+// names or its SHA-256 fingerprint.
+type clientCertMatcher struct {
+	// names match the subject common name and the DNS names
+	names        []comparison
+	emails       []string
+	uris         []string
+	ips          []net.IP
+	fingerprints [][sha256.Size]byte
+}
+
+func newClientCertMatcher(patterns ...string) (clientCertMatcher, error) {
+	var matcher clientCertMatcher
+	if len(patterns) == 0 {
+		return matcher, fmt.Errorf("mux: no client certificate patterns")
+	}
+
+	for _, v := range patterns {
+		prefix, value, _ := strings.Cut(v, ":")
+		switch strings.ToLower(prefix) {
+		case "sha256":
+			fingerprint, err := hex.DecodeString(strings.ReplaceAll(value, ":", ""))
+			if err != nil || len(fingerprint) != sha256.Size {
+				return clientCertMatcher{}, fmt.Errorf("mux: invalid certificate fingerprint %q", v)
+			}
+			matcher.fingerprints = append(matcher.fingerprints, [sha256.Size]byte(fingerprint))
+			continue
+		case "email":
+			matcher.emails = append(matcher.emails, value)
+			continue
+		case "uri":
+			matcher.uris = append(matcher.uris, value)
+			continue
+		case "ip":
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return clientCertMatcher{}, fmt.Errorf("mux: invalid certificate IP address %q", v)
+			}
+			matcher.ips = append(matcher.ips, ip)
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(v, "#"):
+			regex, err := regexp.Compile(v[1:])
+			if err != nil {
+				return clientCertMatcher{}, err
+			}
+			matcher.names = append(matcher.names, regexComparsion{r: regex})
+		case strings.HasPrefix(v, "*."):
+			matcher.names = append(matcher.names, wildcardComparison(strings.ToLower(v[1:])))
+		default:
+			matcher.names = append(matcher.names, stringComparison(v))
+		}
+	}
+
+	return matcher, nil
+}
+
+func (m clientCertMatcher) Match(r *http.Request) bool {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return false
+	}
+	cert := r.TLS.PeerCertificates[0]
+
+	if len(m.fingerprints) != 0 {
+		sum := sha256.Sum256(cert.Raw)
+		for _, fingerprint := range m.fingerprints {
+			if sum == fingerprint {
+				return true
+			}
+		}
+	}
+
+	// the names are only trusted if the chain was verified
+	if len(r.TLS.VerifiedChains) == 0 {
+		return false
+	}
+	for _, name := range certNames(cert) {
+		for _, cmp := range m.names {
+			if cmp.compare(name) {
+				return true
+			}
+		}
+	}
+	for _, email := range cert.EmailAddresses {
+		if containsString(m.emails, email) {
+			return true
+		}
+	}
+	for _, uri := range cert.URIs {
+		if containsString(m.uris, uri.String()) {
+			return true
+		}
+	}
+	for _, ip := range cert.IPAddresses {
+		for _, expected := range m.ips {
+			if ip.Equal(expected) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (m clientCertMatcher) Rank() Rank {
+	return RankCustom
+}
+
+func (m clientCertMatcher) Cost() Cost {
+	return comparisonsCost(m.names)
+}
+
+// certNames returns the subject common name and the DNS names of a
+// certificate, which are matched by the name patterns. Email addresses, IP
+// addresses and URIs are other identities, they are only matched by their
+// typed patterns.
+func certNames(cert *x509.Certificate) []string {
+	names := []string{}
+	if cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName)
+	}
+	return append(names, cert.DNSNames...)
+}
+
+// cookieMatcher matches the request against cookie values. An empty value
+// matches any value and a value which starts with a "#" is a regex.
+type cookieMatcher map[string]comparison
+
+func newCookieMatcher(pairs ...string) (cookieMatcher, error) {
+	cookies, err := convertStringsToMapStringOrRegex(isEvenPairs, pairs...)
+	if err != nil {
+		return nil, err
+	}
+
+	return cookieMatcher(cookies), nil
+}
+
+func (m cookieMatcher) Match(r *http.Request) bool {
+	for name, cmp := range m {
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return false
+		}
+
+		if cmp.isNotEmpty() && !cmp.compare(cookie.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m cookieMatcher) Rank() Rank {
+	return RankHeader
+}
+
+func (m cookieMatcher) Cost() Cost {
+	return comparisonsCost(comparisonValues(m))
+}
+
+// queryMatcher matches the request against query values. A value which
+// starts with a "#" is a regex, the matched value is available as variable.
+type queryMatcher map[string]comparison
+
+func newQueryMatcher(pairs ...string) (queryMatcher, error) {
+	queries, err := convertStringsToMapStringOrRegex(isEvenPairs, pairs...)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryMatcher(queries), nil
+}
+
+func (m queryMatcher) Match(r *http.Request) bool {
+	return matchMap(m, r.URL.Query(), false)
+}
+
+func (m queryMatcher) Rank() Rank {
+	return RankQuery
+}
+
+func (m queryMatcher) Cost() Cost {
+	return comparisonsCost(comparisonValues(m))
+}
+
+func (m queryMatcher) extractVars(r *http.Request, p *params) {
+	queries := r.URL.Query()
+
+	for k, v := range m {
+		if _, ok := v.(regexComparsion); !ok {
+			continue
+		}
+
+		for _, value := range queries[k] {
+			if v.compare(value) {
+				p.set(k, value)
+				break
+			}
+		}
+	}
 }
 
 // MatcherFunc is the function signature used by custom Matchers.
@@ -66,11 +529,156 @@ func (m MatcherFunc) Match(r *http.Request) bool {
 	return m(r)
 }
 
-func (m MatcherFunc) Rank() int {
-	return rankAny
+func (m MatcherFunc) Rank() Rank {
+	return RankCustom
+}
+
+func (m MatcherFunc) Cost() Cost {
+	return CostCustom
+}
+
+// flagMatcher matches if its feature flag is enabled for the request. Unlike
+// a MatcherFunc it has a name, which is shown by DebugHandler and in traces.
+type flagMatcher struct {
+	name    string
+	enabled func(*http.Request) bool
+}
+
+func newFlagMatcher(name string, enabled func(*http.Request) bool) (flagMatcher, error) {
+	if name == "" {
+		return flagMatcher{}, fmt.Errorf("mux: flag name is empty")
+	}
+	if enabled == nil {
+		return flagMatcher{}, fmt.Errorf("mux: flag %q has no evaluation function", name)
+	}
+	return flagMatcher{name: name, enabled: enabled}, nil
+}
+
+func (m flagMatcher) Match(r *http.Request) bool {
+	return m.enabled(r)
+}
+
+func (m flagMatcher) Rank() Rank {
+	return RankCustom
+}
+
+func (m flagMatcher) Cost() Cost {
+	return CostCustom
+}
+
+// String returns the name of the matcher with its flag, e.g. flag(beta).
+func (m flagMatcher) String() string {
+	return "flag(" + m.name + ")"
 }
 
-// schemeMatcher matches the request against URL schemes.
+// TimeWindow is a period of time from Start until End, End excluded.
+type TimeWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// timeWindowMatcher matches while the current time is in one of its windows.
+type timeWindowMatcher struct {
+	windows []TimeWindow
+	// route whose router provides the clock, nil for time.Now.
+	route *Route
+}
+
+func newTimeWindowMatcher(route *Route, windows ...TimeWindow) (timeWindowMatcher, error) {
+	if len(windows) == 0 {
+		return timeWindowMatcher{}, fmt.Errorf("mux: no time windows")
+	}
+	for _, w := range windows {
+		if !w.End.After(w.Start) {
+			return timeWindowMatcher{}, fmt.Errorf("mux: time window %s - %s doesn't end after its start", w.Start.Format(time.RFC3339), w.End.Format(time.RFC3339))
+		}
+	}
+	return timeWindowMatcher{windows: windows, route: route}, nil
+}
+
+func (m timeWindowMatcher) Match(r *http.Request) bool {
+	now := m.now()
+	for _, w := range m.windows {
+		if !now.Before(w.Start) && now.Before(w.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// now returns the time of the router's Clock if it is set.
+func (m timeWindowMatcher) now() time.Time {
+	if m.route != nil && m.route.router != nil && m.route.router.Clock != nil {
+		return m.route.router.Clock()
+	}
+	return time.Now()
+}
+
+func (m timeWindowMatcher) Rank() Rank {
+	return RankCustom
+}
+
+func (m timeWindowMatcher) Cost() Cost {
+	return CostCompare
+}
+
+// apiKeyMatcher matches if the API key of the request passes its lookup.
+type apiKeyMatcher struct {
+	// source is header:<name> or query:<name>.
+	source string
+	header string
+	query  string
+	lookup func(key string) bool
+}
+
+func newAPIKeyMatcher(source string, lookup func(key string) bool) (apiKeyMatcher, error) {
+	m := apiKeyMatcher{source: source, lookup: lookup}
+	kind, name, _ := strings.Cut(source, ":")
+	switch {
+	case name == "":
+		return apiKeyMatcher{}, fmt.Errorf("mux: API key source %q isn't header:<name> or query:<name>", source)
+	case kind == "header":
+		m.header = http.CanonicalHeaderKey(name)
+	case kind == "query":
+		m.query = name
+	default:
+		return apiKeyMatcher{}, fmt.Errorf("mux: API key source %q isn't header:<name> or query:<name>", source)
+	}
+	if lookup == nil {
+		return apiKeyMatcher{}, fmt.Errorf("mux: API key source %q has no lookup function", source)
+	}
+	return m, nil
+}
+
+func (m apiKeyMatcher) Match(r *http.Request) bool {
+	key := m.key(r)
+	return key != "" && m.lookup(key)
+}
+
+// key returns the API key of a request, empty if there is none.
+func (m apiKeyMatcher) key(r *http.Request) string {
+	if m.header != "" {
+		return r.Header.Get(m.header)
+	}
+	return r.URL.Query().Get(m.query)
+}
+
+func (m apiKeyMatcher) Rank() Rank {
+	return RankCustom
+}
+
+func (m apiKeyMatcher) Cost() Cost {
+	return CostCustom
+}
+
+// String returns the name of the matcher with its source, e.g.
+// apikey(header:X-API-Key).
+func (m apiKeyMatcher) String() string {
+	return "apikey(" + m.source + ")"
+}
+
+// schemeMatcher matches the request against URL schemes. A server request
+// has no scheme in its URL, so TLS connections are https and others http.
 type schemeMatcher map[string]struct{}
 
 func newSchemeMatcher(schemes ...string) schemeMatcher {
@@ -84,15 +692,79 @@ func newSchemeMatcher(schemes ...string) schemeMatcher {
 }
 
 func (m schemeMatcher) Match(r *http.Request) bool {
-	if _, found := m[r.URL.Scheme]; found {
+	if _, found := m[requestScheme(r)]; found {
 		return true
 	}
 
 	return false
 }
 
-func (m schemeMatcher) Rank() int {
-	return rankScheme
+func (m schemeMatcher) Rank() Rank {
+	return RankScheme
+}
+
+func (m schemeMatcher) Cost() Cost {
+	return CostLookup
+}
+
+// methodMatcher matches the request against HTTP methods.
+type methodMatcher map[string]struct{}
+
+func newMethodMatcher(methods ...string) methodMatcher {
+	methodMatcher := methodMatcher{}
+
+	for _, v := range methods {
+		methodMatcher[strings.ToUpper(v)] = struct{}{}
+	}
+
+	return methodMatcher
+}
+
+func (m methodMatcher) Match(r *http.Request) bool {
+	_, found := m[r.Method]
+	return found
+}
+
+func (m methodMatcher) Rank() Rank {
+	return RankMethod
+}
+
+func (m methodMatcher) Cost() Cost {
+	return CostLookup
+}
+
+// hostMatcher matches the request against the host. The port of the host
+// is ignored, a "*" label matches any single label (e.g. *.example.com) and
+// variables capture parts of the host (e.g. {tenant}.example.com).
+type hostMatcher struct {
+	template *pathTemplate
+}
+
+func newHostMatcher(host string) (hostMatcher, error) {
+	template, err := compileHostTemplate(stripPatternPort(host))
+	if err != nil {
+		return hostMatcher{}, err
+	}
+
+	return hostMatcher{
+		template: template,
+	}, nil
+}
+
+func (m hostMatcher) Match(r *http.Request) bool {
+	return m.template.match(stripHostPort(strings.ToLower(r.Host)))
+}
+
+func (m hostMatcher) Rank() Rank {
+	return RankHost
+}
+
+func (m hostMatcher) Cost() Cost {
+	return CostRegex
+}
+
+func (m hostMatcher) extractVars(r *http.Request, p *params) {
+	m.template.extractVars(stripHostPort(strings.ToLower(r.Host)), p)
 }
 
 // pathMatcher matches the request against a URL path.
@@ -102,63 +774,178 @@ func (m pathMatcher) Match(r *http.Request) bool {
 	return strings.Compare(string(m), r.URL.Path) == 0
 }
 
-func (m pathMatcher) Rank() int {
-	return rankPath
+func (m pathMatcher) Rank() Rank {
+	return RankPath
 }
 
-// pathWithVarsMatcher matches the request against a URL path.
-type pathWithVarsMatcher struct {
-	regex *regexp.Regexp
+func (m pathMatcher) Cost() Cost {
+	return CostCompare
 }
 
-func newPathWithVarsMatcher(path string) pathWithVarsMatcher {
+// pathPrefixMatcher matches the request against a URL path prefix. It
+// matches the prefix itself and all paths below it.
+type pathPrefixMatcher struct {
+	prefix string
+	// template is only set if the prefix contains vars
+	template *pathTemplate
+}
 
-Loop:
-	for {
-		switch {
-		case strings.Contains(path, ":number"):
-			path = strings.Replace(path, ":number", "([0-9]{1,})", -1)
-			continue
-		case strings.Contains(path, ":string"):
-			path = strings.Replace(path, ":string", "([a-zA-Z]{1,})", -1)
-			continue
-		default:
+func newPathPrefixMatcher(prefix string, types paramTypes) (pathPrefixMatcher, error) {
+	if !containsVars(prefix) {
+		return pathPrefixMatcher{prefix: prefix}, nil
+	}
 
-			break Loop
-		}
+	template, err := compilePathPrefixTemplate(prefix, types)
+	if err != nil {
+		return pathPrefixMatcher{}, err
 	}
 
-	return pathWithVarsMatcher{
-		regex: regexp.MustCompile(`^` + path + `$`),
+	return pathPrefixMatcher{
+		prefix:   prefix,
+		template: template,
+	}, nil
+}
+
+func (m pathPrefixMatcher) Match(r *http.Request) bool {
+	if m.template != nil {
+		return m.template.match(r.URL.Path)
+	}
+
+	if strings.HasSuffix(m.prefix, "/") {
+		return strings.HasPrefix(r.URL.Path, m.prefix)
+	}
+
+	return r.URL.Path == m.prefix || strings.HasPrefix(r.URL.Path, m.prefix+"/")
+}
+
+func (m pathPrefixMatcher) Rank() Rank {
+	return RankPath
+}
+
+func (m pathPrefixMatcher) Cost() Cost {
+	if m.template != nil {
+		return CostRegex
+	}
+	return CostCompare
+}
+
+func (m pathPrefixMatcher) extractVars(r *http.Request, p *params) {
+	if m.template != nil {
+		m.template.extractVars(r.URL.Path, p)
 	}
 }
 
-func (m pathWithVarsMatcher) Rank() int {
-	return rankPath
+// varsMatcher is implemented by matchers which capture variables
+// out of the request.
+type varsMatcher interface {
+	Matcher
+	extractVars(r *http.Request, p *params)
+}
+
+// pathWithVarsMatcher matches the request against a URL path.
+type pathWithVarsMatcher struct {
+	template *pathTemplate
+}
+
+func newPathWithVarsMatcher(path string, types paramTypes) (pathWithVarsMatcher, error) {
+	template, err := compilePathTemplate(path, types)
+	if err != nil {
+		return pathWithVarsMatcher{}, err
+	}
+
+	return pathWithVarsMatcher{
+		template: template,
+	}, nil
+}
+
+func (m pathWithVarsMatcher) Rank() Rank {
+	return RankPath
+}
+
+func (m pathWithVarsMatcher) Cost() Cost {
+	return CostRegex
 }
 
 func (m pathWithVarsMatcher) Match(r *http.Request) bool {
-	return m.regex.MatchString(r.URL.Path)
+	return m.template.match(r.URL.Path)
+}
+
+func (m pathWithVarsMatcher) extractVars(r *http.Request, p *params) {
+	m.template.extractVars(r.URL.Path, p)
 }
 
 //pathWithVarsMatcher matches the request against a URL path.
+//
+// The segment of each regex is captured as var (e.g. "var", "var1"), named
+// capture groups like (?P<id>[0-9]+) are captured under their name.
 type pathRegexMatcher struct {
 	regex *regexp.Regexp
+	// varIndexies holds the url segment of each var
+	varIndexies map[string]int
+	// named is set if the regex has named capture groups
+	named bool
 }
 
-func newPathRegexMatcher(path string) pathRegexMatcher {
+func newPathRegexMatcher(path string) (pathRegexMatcher, error) {
+	varIndexies := extractVarsIndexies("#", path, "var")
 	path = strings.Replace(path, "#", "", -1)
-	return pathRegexMatcher{
-		regex: regexp.MustCompile(`^` + path + `$`),
+
+	regex, err := regexp.Compile(`^` + path + `$`)
+	if err != nil {
+		return pathRegexMatcher{}, err
 	}
+
+	named := false
+	for _, name := range regex.SubexpNames() {
+		if name != "" {
+			named = true
+			break
+		}
+	}
+
+	return pathRegexMatcher{
+		regex:       regex,
+		varIndexies: varIndexies,
+		named:       named,
+	}, nil
 }
 
 func (m pathRegexMatcher) Match(r *http.Request) bool {
 	return m.regex.MatchString(r.URL.Path)
 }
 
-func (m pathRegexMatcher) Rank() int {
-	return rankPath
+func (m pathRegexMatcher) Rank() Rank {
+	return RankPath
+}
+
+func (m pathRegexMatcher) Cost() Cost {
+	return CostRegex
+}
+
+func (m pathRegexMatcher) extractVars(r *http.Request, p *params) {
+	urlSeg := strings.Split(r.URL.Path, "/")
+
+	for k, v := range m.varIndexies {
+		if v < len(urlSeg) {
+			p.set(k, urlSeg[v])
+		}
+	}
+
+	if !m.named {
+		return
+	}
+
+	match := m.regex.FindStringSubmatchIndex(r.URL.Path)
+	if match == nil {
+		return
+	}
+
+	for k, name := range m.regex.SubexpNames() {
+		if name == "" || match[2*k] < 0 {
+			continue
+		}
+		p.set(name, r.URL.Path[match[2*k]:match[2*k+1]])
+	}
 }
 
 // Matchers implements the sort interface (len, swap, less)
@@ -173,6 +960,10 @@ func (m Matchers) Swap(i, j int) {
 	m[i], m[j] = m[j], m[i]
 }
 
+// Less orders the matchers by rank, matchers of equal rank by cost.
 func (m Matchers) Less(i, j int) bool {
-	return m[i].Rank() < m[j].Rank()
+	if m[i].Rank() != m[j].Rank() {
+		return m[i].Rank() < m[j].Rank()
+	}
+	return costOf(m[i]) < costOf(m[j])
 }
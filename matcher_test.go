@@ -1,9 +1,18 @@
 package mux
 
 import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
 	"fmt"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -46,6 +55,22 @@ func TestSchemeMatcher(t *testing.T) {
 	}
 }
 
+func TestSchemeMatcherTLS(t *testing.T) {
+	matcher := newSchemeMatcher("https")
+	request := &http.Request{
+		URL: &url.URL{},
+	}
+
+	if matcher.Match(request) {
+		t.Error("Unexpected match without TLS")
+	}
+
+	request.TLS = &tls.ConnectionState{}
+	if !matcher.Match(request) {
+		t.Error("Unexpected mismatch with TLS")
+	}
+}
+
 func BenchmarkSchemeMatcher(b *testing.B) {
 	matcher := newSchemeMatcher("https", "http", "HTTP", "HTTPS")
 	request := &http.Request{
@@ -74,6 +99,209 @@ func TestSchemeMatcherFail(t *testing.T) {
 	}
 }
 
+func TestMethodMatcher(t *testing.T) {
+	matcher := newMethodMatcher("get", http.MethodPost)
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		if !matcher.Match(&http.Request{Method: method}) {
+			t.Errorf("Method not matched (%v)", method)
+		}
+	}
+}
+
+func TestMethodMatcherFail(t *testing.T) {
+	matcher := newMethodMatcher(http.MethodGet)
+
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		if matcher.Match(&http.Request{Method: method}) {
+			t.Errorf("Method matched (%v)", method)
+		}
+	}
+}
+
+func TestHostMatcher(t *testing.T) {
+
+	tests := []struct {
+		pattern string
+		host    string
+	}{
+		{pattern: "api.example.com", host: "api.example.com"},
+		{pattern: "api.example.com", host: "API.example.com:8080"},
+		{pattern: "api.example.com:8080", host: "api.example.com"},
+		{pattern: "*.example.com", host: "tenant.example.com"},
+		{pattern: "*.example.com", host: "tenant.example.com:443"},
+		{pattern: "127.0.0.1", host: "127.0.0.1:8080"},
+		{pattern: "{tenant}.example.com", host: "acme.example.com"},
+		{pattern: "{tenant:[a-z]+}-api.Example.com", host: "acme-api.example.com"},
+		{pattern: `{id:\d+}.example.com`, host: "42.example.com"},
+		{pattern: `{id:\d{2}}.example.com:8080`, host: "42.example.com:443"},
+		{pattern: "[::1]:8080", host: "[::1]"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Pattern: %s, Host: %s", test.pattern, test.host), func(t *testing.T) {
+			matcher, err := newHostMatcher(test.pattern)
+			if err != nil {
+				t.Fatalf("Unexpected error (%s)", err.Error())
+			}
+
+			if !matcher.Match(&http.Request{Host: test.host}) {
+				t.Errorf("Host not matched")
+			}
+		})
+	}
+}
+
+func TestHostMatcherFail(t *testing.T) {
+
+	tests := []struct {
+		pattern string
+		host    string
+	}{
+		{pattern: "api.example.com", host: "www.example.com"},
+		{pattern: "api.example.com", host: "apixexample.com"},
+		{pattern: "*.example.com", host: "example.com"},
+		{pattern: "*.example.com", host: "a.b.example.com"},
+		{pattern: "{tenant}.example.com", host: "a.b.example.com"},
+		{pattern: "{tenant:[a-z]+}.example.com", host: "acme1.example.com"},
+		{pattern: `{id:\d+}.example.com`, host: "acme.example.com"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Pattern: %s, Host: %s", test.pattern, test.host), func(t *testing.T) {
+			matcher, _ := newHostMatcher(test.pattern)
+
+			if matcher.Match(&http.Request{Host: test.host}) {
+				t.Errorf("Host matched")
+			}
+		})
+	}
+}
+
+func TestHostMatcherVars(t *testing.T) {
+	matcher, _ := newHostMatcher("{tenant}.{region}.example.com")
+	p := params{}
+	matcher.extractVars(&http.Request{Host: "acme.eu.example.com:8080"}, &p)
+	vars := p.vars()
+
+	if !reflect.DeepEqual(vars, Vars{"tenant": "acme", "region": "eu"}) {
+		t.Errorf("Unexpected vars (%v)", vars)
+	}
+}
+
+func TestHostRouteVars(t *testing.T) {
+	r := Classic()
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, GetVars(req).Get("id"))
+	}).Host(`{id:\d+}.example.com`)
+
+	req := httptest.NewRequest(http.MethodGet, "http://42.example.com/", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Body.String() != "42" {
+		t.Errorf("Unexpected response (%d, %s)", w.Code, w.Body.String())
+	}
+}
+
+func TestQueryMatcher(t *testing.T) {
+
+	tests := []struct {
+		pairs    []string
+		rawQuery string
+		match    bool
+	}{
+		{pairs: []string{"format", "json"}, rawQuery: "format=json", match: true},
+		{pairs: []string{"format", "json"}, rawQuery: "format=xml&format=json", match: true},
+		{pairs: []string{"format", "json"}, rawQuery: "format=xml", match: false},
+		{pairs: []string{"format", ""}, rawQuery: "format=xml", match: true},
+		{pairs: []string{"format", ""}, rawQuery: "page=1", match: false},
+		{pairs: []string{"format", "json", "page", ""}, rawQuery: "format=json&page=1", match: true},
+		{pairs: []string{"format", "json", "page", ""}, rawQuery: "format=json", match: false},
+		{pairs: []string{"page", "#^[0-9]+$"}, rawQuery: "page=12", match: true},
+		{pairs: []string{"page", "#^[0-9]+$"}, rawQuery: "page=last", match: false},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Pairs: %v, Query: %s", test.pairs, test.rawQuery), func(t *testing.T) {
+			matcher, err := newQueryMatcher(test.pairs...)
+			if err != nil {
+				t.Fatalf("Unexpected error (%s)", err.Error())
+			}
+
+			request := &http.Request{
+				URL: &url.URL{
+					RawQuery: test.rawQuery,
+				},
+			}
+
+			if matcher.Match(request) != test.match {
+				t.Errorf("Unexpected match result (%v)", !test.match)
+			}
+		})
+	}
+}
+
+func TestQueryMatcherFail(t *testing.T) {
+	if _, err := newQueryMatcher("format"); err == nil {
+		t.Error("Expected a error")
+	}
+
+	if _, err := newQueryMatcher("page", "#[0-9"); err == nil {
+		t.Error("Expected a error")
+	}
+}
+
+func TestQueryMatcherVars(t *testing.T) {
+	matcher, _ := newQueryMatcher("page", "#^[0-9]+$", "format", "json")
+	request := &http.Request{
+		URL: &url.URL{
+			RawQuery: "page=last&page=12&format=json",
+		},
+	}
+
+	p := params{}
+	matcher.extractVars(request, &p)
+	vars := p.vars()
+
+	if !reflect.DeepEqual(vars, Vars{"page": "12"}) {
+		t.Errorf("Unexpected vars (%v)", vars)
+	}
+}
+
+func TestPathRegexMatcherVars(t *testing.T) {
+	tests := []struct {
+		path string
+		url  string
+		vars Vars
+	}{
+		{"/users/#([0-9]+)", "/users/42", Vars{"var": "42"}},
+		{"/users/#(?P<id>[0-9]+)", "/users/42", Vars{"var": "42", "id": "42"}},
+		{"/archive/#(?P<year>[0-9]{4})-(?P<month>[0-9]{2})(?:-(?P<day>[0-9]{2}))?", "/archive/2024-05", Vars{"var": "2024-05", "year": "2024", "month": "05"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			matcher, err := newPathRegexMatcher(test.path)
+			if err != nil {
+				t.Fatalf("Unexpected error (%s)", err.Error())
+			}
+
+			request := &http.Request{URL: &url.URL{Path: test.url}}
+			if !matcher.Match(request) {
+				t.Fatal("Unexpected not matched path")
+			}
+
+			p := params{}
+			matcher.extractVars(request, &p)
+
+			if !reflect.DeepEqual(p.vars(), test.vars) {
+				t.Errorf("Unexpected vars (Expected: %v, Actual: %v)", test.vars, p.vars())
+			}
+		})
+	}
+}
+
 func TestPathMatchers(t *testing.T) {
 
 	tests := []struct {
@@ -95,7 +323,8 @@ func TestPathMatchers(t *testing.T) {
 			pathToMatch: "/user/:number",
 			pathRaw:     "/user/1",
 			buildMatcher: func(path string) Matcher {
-				return newPathWithVarsMatcher(path)
+				matcher, _ := newPathWithVarsMatcher(path, nil)
+				return matcher
 			},
 		},
 		{
@@ -103,7 +332,8 @@ func TestPathMatchers(t *testing.T) {
 			pathToMatch: "/user/:number/comment/:number",
 			pathRaw:     "/user/1/comment/99",
 			buildMatcher: func(path string) Matcher {
-				return newPathWithVarsMatcher(path)
+				matcher, _ := newPathWithVarsMatcher(path, nil)
+				return matcher
 			},
 		},
 		{
@@ -111,7 +341,8 @@ func TestPathMatchers(t *testing.T) {
 			pathToMatch: "/article/:string",
 			pathRaw:     "/article/golang",
 			buildMatcher: func(path string) Matcher {
-				return newPathWithVarsMatcher(path)
+				matcher, _ := newPathWithVarsMatcher(path, nil)
+				return matcher
 			},
 		},
 		{
@@ -119,7 +350,8 @@ func TestPathMatchers(t *testing.T) {
 			pathToMatch: "/article/:string/comment/:number/subcomment/:number",
 			pathRaw:     "/article/golang/comment/4/subcomment/5",
 			buildMatcher: func(path string) Matcher {
-				return newPathWithVarsMatcher(path)
+				matcher, _ := newPathWithVarsMatcher(path, nil)
+				return matcher
 			},
 		},
 		{
@@ -127,7 +359,8 @@ func TestPathMatchers(t *testing.T) {
 			pathToMatch: "/:number/:number/:number/:number/:number/:number/:number/:number/:number/:number",
 			pathRaw:     "/1/1/1/1/1/1/1/1/1/1",
 			buildMatcher: func(path string) Matcher {
-				return newPathWithVarsMatcher(path)
+				matcher, _ := newPathWithVarsMatcher(path, nil)
+				return matcher
 			},
 		},
 		{
@@ -135,7 +368,8 @@ func TestPathMatchers(t *testing.T) {
 			pathToMatch: "/:string/:number/:string/:number/:string/:number/:string/:number/:string/:number",
 			pathRaw:     "/dummy/1/dummy/1/dummy/1/dummy/1/dummy/1",
 			buildMatcher: func(path string) Matcher {
-				return newPathWithVarsMatcher(path)
+				matcher, _ := newPathWithVarsMatcher(path, nil)
+				return matcher
 			},
 		},
 		{
@@ -143,7 +377,8 @@ func TestPathMatchers(t *testing.T) {
 			pathToMatch: "/#([a-z]){1,}/#([0-9]){1,}/#([a-z]){1,}/#([0-9]){1,}/#([a-z]){1,}/#([0-9]){1,}/#([a-z]){1,}/#([0-9]){1,}/#([a-z]){1,}/#([0-9]){1,}",
 			pathRaw:     "/dummy/1/dummy/1/dummy/1/dummy/1/dummy/1",
 			buildMatcher: func(path string) Matcher {
-				return newPathRegexMatcher(path)
+				matcher, _ := newPathRegexMatcher(path)
+				return matcher
 			},
 		},
 	}
@@ -193,7 +428,8 @@ func BenchmarkPathMatchers(b *testing.B) {
 			pathToMatch: "/:string/:number/:string/:number/:string/:number/:string/:number/:string/:number",
 			pathRaw:     "/user/1",
 			buildMatcher: func(path string) Matcher {
-				return newPathWithVarsMatcher(path)
+				matcher, _ := newPathWithVarsMatcher(path, nil)
+				return matcher
 			},
 		},
 		{
@@ -201,7 +437,8 @@ func BenchmarkPathMatchers(b *testing.B) {
 			pathToMatch: "/#([a-z]){1,}/#([0-9]){1,}/#([a-z]){1,}/#([0-9]){1,}/#([a-z]){1,}/#([0-9]){1,}/#([a-z]){1,}/#([0-9]){1,}/#([a-z]){1,}/#([0-9]){1,}",
 			pathRaw:     "/dummy/1/dummy/1/dummy/1/dummy/1/dummy/1",
 			buildMatcher: func(path string) Matcher {
-				return newPathRegexMatcher(path)
+				matcher, _ := newPathRegexMatcher(path)
+				return matcher
 			},
 		},
 	}
@@ -247,6 +484,43 @@ func TestPathVarsMatcherFail(t *testing.T) {
 	}
 }
 
+func TestPathPrefixMatcher(t *testing.T) {
+
+	tests := []struct {
+		prefix string
+		path   string
+		match  bool
+	}{
+		{prefix: "/api", path: "/api", match: true},
+		{prefix: "/api", path: "/api/user", match: true},
+		{prefix: "/api", path: "/apiv2", match: false},
+		{prefix: "/api/", path: "/api/user", match: true},
+		{prefix: "/api/", path: "/api", match: false},
+		{prefix: "/users/:id", path: "/users/1/posts", match: true},
+		{prefix: "/users/:id", path: "/users/1", match: true},
+		{prefix: "/users/:id", path: "/users", match: false},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Prefix: %s, Path: %s", test.prefix, test.path), func(t *testing.T) {
+			matcher, err := newPathPrefixMatcher(test.prefix, nil)
+			if err != nil {
+				t.Fatalf("Unexpected error (%s)", err.Error())
+			}
+
+			request := &http.Request{
+				URL: &url.URL{
+					Path: test.path,
+				},
+			}
+
+			if matcher.Match(request) != test.match {
+				t.Errorf("Unexpected match result (%v)", !test.match)
+			}
+		})
+	}
+}
+
 func TestHeaderMatcher(t *testing.T) {
 
 	tests := []struct {
@@ -305,6 +579,242 @@ func TestHeaderMatcher(t *testing.T) {
 	}
 }
 
+func TestHeaderPresentMatcher(t *testing.T) {
+	matcher := newHeaderPresentMatcher("authorization", "X-Request-Id")
+	request := &http.Request{
+		Header: http.Header{},
+	}
+	request.Header.Set("Authorization", "Bearer token")
+
+	if matcher.Match(request) {
+		t.Errorf("Unexpected matched (%v)", request.Header)
+	}
+
+	request.Header.Set("X-Request-Id", "")
+
+	if !matcher.Match(request) {
+		t.Errorf("Unexpected not matched (%v)", request.Header)
+	}
+}
+
+func TestHeaderAbsentMatcher(t *testing.T) {
+	matcher := newHeaderAbsentMatcher("x-legacy-client")
+	request := &http.Request{
+		Header: http.Header{},
+	}
+
+	if !matcher.Match(request) {
+		t.Errorf("Unexpected not matched (%v)", request.Header)
+	}
+
+	request.Header.Set("X-Legacy-Client", "1")
+
+	if matcher.Match(request) {
+		t.Errorf("Unexpected matched (%v)", request.Header)
+	}
+}
+
+func TestContentTypeMatcher(t *testing.T) {
+	matcher, err := newContentTypeMatcher("application/json", "Text/*")
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	tests := []struct {
+		contentType string
+		matched     bool
+	}{
+		{"application/json", true},
+		{"Application/JSON; charset=utf-8", true},
+		{"text/plain", true},
+		{"text/csv;charset=utf-8", true},
+		{"application/x-www-form-urlencoded", false},
+		{"application/json-patch+json", false},
+		{"invalid", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		request := &http.Request{Header: http.Header{}}
+		request.Header.Set("Content-Type", test.contentType)
+
+		if matched := matcher.Match(request); matched != test.matched {
+			t.Errorf("Expected matched %v for %q", test.matched, test.contentType)
+		}
+	}
+}
+
+func TestContentTypeMatcherFail(t *testing.T) {
+	for _, contentType := range []string{"json", "application/json;;", ""} {
+		if _, err := newContentTypeMatcher(contentType); err == nil {
+			t.Errorf("Expected an error for %q", contentType)
+		}
+	}
+}
+
+func TestRemoteIPMatcher(t *testing.T) {
+	matcher, err := newRemoteIPMatcher("10.0.0.0/8", "192.168.1.7", "fd00::/8")
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	tests := map[string]bool{
+		"10.1.2.3:1234":     true,
+		"192.168.1.7:1234":  true,
+		"192.168.1.8:1234":  false,
+		"[fd12::1]:1234":    true,
+		"[2001:db8::1]:443": false,
+		"invalid":           false,
+	}
+
+	for remoteAddr, expected := range tests {
+		request := &http.Request{RemoteAddr: remoteAddr}
+
+		if matched := matcher.Match(request); matched != expected {
+			t.Errorf("Expected matched %v for %s", expected, remoteAddr)
+		}
+	}
+}
+
+func TestRemoteIPMatcherFail(t *testing.T) {
+	if _, err := newRemoteIPMatcher("10.0.0.0/8", "10.0.0.0/40"); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestUserAgentMatcher(t *testing.T) {
+	matcher, err := newUserAgentMatcher("Googlebot", `#^ShopApp/1\.[0-4]\.`)
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	tests := map[string]bool{
+		"Mozilla/5.0 (compatible; googlebot/2.1)": true,
+		"ShopApp/1.3.0 (iOS)":                     true,
+		"ShopApp/1.5.0 (iOS)":                     false,
+		"Mozilla/5.0 ShopApp/1.3.0":               false,
+		"":                                        false,
+	}
+
+	for userAgent, expected := range tests {
+		request := &http.Request{Header: http.Header{}}
+		request.Header.Set("User-Agent", userAgent)
+
+		if matched := matcher.Match(request); matched != expected {
+			t.Errorf("Expected matched %v for %q", expected, userAgent)
+		}
+	}
+}
+
+func TestUserAgentMatcherFail(t *testing.T) {
+	if _, err := newUserAgentMatcher("#[a-"); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestClientCertMatcher(t *testing.T) {
+	pinned := &x509.Certificate{Raw: []byte("pinned"), Subject: pkix.Name{CommonName: "deployer"}}
+	sum := sha256.Sum256(pinned.Raw)
+	fingerprint := strings.ToUpper(hex.EncodeToString(sum[:2])) + ":" + hex.EncodeToString(sum[2:])
+
+	matcher, err := newClientCertMatcher("admin", "*.ops.example.com", "#^svc-[0-9]+$", "uri:spiffe://example.com/admin/alice",
+		"email:ops@example.com", "ip:10.0.0.1", "sha256:"+fingerprint)
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	spiffe, _ := url.Parse("spiffe://example.com/admin/alice")
+	spoofed, _ := url.Parse("spiffe://x.ops.example.com")
+	tests := []struct {
+		cert     *x509.Certificate
+		verified bool
+		expected bool
+	}{
+		{&x509.Certificate{Subject: pkix.Name{CommonName: "admin"}}, true, true},
+		{&x509.Certificate{Subject: pkix.Name{CommonName: "admin"}}, false, false},
+		{&x509.Certificate{DNSNames: []string{"web-1.OPS.example.com"}}, true, true},
+		{&x509.Certificate{DNSNames: []string{"a.b.ops.example.com"}}, true, false},
+		{&x509.Certificate{DNSNames: []string{"ops.example.com"}}, true, false},
+		{&x509.Certificate{Subject: pkix.Name{CommonName: "svc-12"}}, true, true},
+		{&x509.Certificate{URIs: []*url.URL{spiffe}}, true, true},
+		{&x509.Certificate{EmailAddresses: []string{"ops@example.com"}}, true, true},
+		{&x509.Certificate{IPAddresses: []net.IP{net.ParseIP("10.0.0.1")}}, true, true},
+		// the name patterns don't match other identities
+		{&x509.Certificate{EmailAddresses: []string{"eve@x.ops.example.com"}}, true, false},
+		{&x509.Certificate{EmailAddresses: []string{"admin"}}, true, false},
+		{&x509.Certificate{URIs: []*url.URL{spoofed}}, true, false},
+		{&x509.Certificate{URIs: []*url.URL{spiffe}}, false, false},
+		{&x509.Certificate{Subject: pkix.Name{CommonName: "guest"}}, true, false},
+		{pinned, false, true},
+		{nil, true, false},
+	}
+
+	for i, test := range tests {
+		request := &http.Request{TLS: &tls.ConnectionState{}}
+		if test.cert != nil {
+			request.TLS.PeerCertificates = []*x509.Certificate{test.cert}
+			if test.verified {
+				request.TLS.VerifiedChains = [][]*x509.Certificate{{test.cert}}
+			}
+		}
+
+		if matched := matcher.Match(request); matched != test.expected {
+			t.Errorf("Expected matched %v for test %d", test.expected, i)
+		}
+	}
+
+	if matcher.Match(&http.Request{}) {
+		t.Error("Unexpected match without TLS")
+	}
+}
+
+func TestClientCertMatcherFail(t *testing.T) {
+	for _, patterns := range [][]string{{}, {"#[a-"}, {"sha256:abc"}, {"sha256:zz"}, {"ip:10.0.0"}} {
+		if _, err := newClientCertMatcher(patterns...); err == nil {
+			t.Errorf("Expected an error for %v", patterns)
+		}
+	}
+}
+
+func TestCookieMatcher(t *testing.T) {
+	matcher, err := newCookieMatcher("session", "", "cohort", "#^(b|c)$", "theme", "dark")
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	tests := []struct {
+		cookies []*http.Cookie
+		matched bool
+	}{
+		{[]*http.Cookie{{Name: "session", Value: "x"}, {Name: "cohort", Value: "b"}, {Name: "theme", Value: "dark"}}, true},
+		{[]*http.Cookie{{Name: "session", Value: ""}, {Name: "cohort", Value: "c"}, {Name: "theme", Value: "dark"}}, true},
+		{[]*http.Cookie{{Name: "session", Value: "x"}, {Name: "cohort", Value: "a"}, {Name: "theme", Value: "dark"}}, false},
+		{[]*http.Cookie{{Name: "session", Value: "x"}, {Name: "cohort", Value: "b"}, {Name: "theme", Value: "light"}}, false},
+		{[]*http.Cookie{{Name: "cohort", Value: "b"}, {Name: "theme", Value: "dark"}}, false},
+	}
+
+	for _, test := range tests {
+		request := &http.Request{Header: http.Header{}}
+		for _, cookie := range test.cookies {
+			request.AddCookie(cookie)
+		}
+
+		if matched := matcher.Match(request); matched != test.matched {
+			t.Errorf("Expected matched %v for %v", test.matched, request.Header.Get("Cookie"))
+		}
+	}
+}
+
+func TestCookieMatcherFail(t *testing.T) {
+	if _, err := newCookieMatcher("session"); err == nil {
+		t.Error("Expected an error for odd pairs")
+	}
+
+	if _, err := newCookieMatcher("cohort", "#[a-"); err == nil {
+		t.Error("Expected an error for an invalid regex")
+	}
+}
+
 func BenchmarkHeaderMatchers(b *testing.B) {
 
 	buildRequest := func() *http.Request {
@@ -451,7 +961,7 @@ func BenchmarkNewPathWithVarsMatcher(b *testing.B) {
 	for _, test := range tests {
 		b.Run(test.title, func(b *testing.B) {
 			for n := 0; n < b.N; n++ {
-				newPathWithVarsMatcher(test.path)
+				newPathWithVarsMatcher(test.path, nil)
 			}
 		})
 	}
@@ -0,0 +1,106 @@
+package mux
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// MaxBytes returns a middleware which limits the request bodies to n bytes
+// with http.MaxBytesReader. Requests whose Content-Length exceeds the limit
+// are answered with 413 Request Entity Too Large at once. If the handler
+// reads past the limit, its response is replaced with 413 as well unless it
+// has started writing it. See Route.MaxBytes.
+func MaxBytes(n int64) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.ContentLength > n {
+				tooLarge(w)
+				return
+			}
+			if req.Body == nil || req.Body == http.NoBody {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			body := &maxBytesBody{ReadCloser: http.MaxBytesReader(w, req.Body, n)}
+			mw := &maxBytesWriter{ResponseWriter: w, body: body}
+			req.Body = body
+			next.ServeHTTP(mw, req)
+
+			if !mw.wroteHeader && body.exceeded.Load() {
+				tooLarge(w)
+			}
+		})
+	}
+}
+
+func tooLarge(w http.ResponseWriter) {
+	http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+}
+
+// maxBytesBody records whether the limit of a body was exceeded.
+type maxBytesBody struct {
+	io.ReadCloser
+	exceeded atomic.Bool
+}
+
+func (b *maxBytesBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		b.exceeded.Store(true)
+	}
+	return n, err
+}
+
+// maxBytesWriter replaces the response with 413 if the body exceeded the
+// limit before the response was started.
+type maxBytesWriter struct {
+	http.ResponseWriter
+	body        *maxBytesBody
+	wroteHeader bool
+	rejected    bool
+}
+
+func (w *maxBytesWriter) WriteHeader(code int) {
+	if w.rejected {
+		return
+	}
+	if !w.wroteHeader && w.body.exceeded.Load() {
+		w.wroteHeader, w.rejected = true, true
+		tooLarge(w.ResponseWriter)
+		return
+	}
+	if code < 100 || code > 199 {
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *maxBytesWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.rejected {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends the buffered data to the client if the wrapped ResponseWriter
+// supports it.
+func (w *maxBytesWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok && !w.rejected {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped ResponseWriter, e.g. for http.ResponseController.
+func (w *maxBytesWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
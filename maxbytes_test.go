@@ -0,0 +1,62 @@
+package mux
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxBytes(t *testing.T) {
+	r := Classic()
+	r.Post("/orders", func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Write(body)
+	}).MaxBytes(8)
+
+	tests := []struct {
+		body          string
+		contentLength int64
+		code          int
+	}{
+		{"small", 5, http.StatusOK},
+		{"far too large", 13, http.StatusRequestEntityTooLarge},
+		// without a Content-Length the limit is hit while reading
+		{"far too large", -1, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(test.body))
+		req.ContentLength = test.contentLength
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != test.code {
+			t.Errorf("Unexpected status for %q (%d, %s)", test.body, w.Code, w.Body.String())
+		}
+		if test.code == http.StatusOK && w.Body.String() != test.body {
+			t.Errorf("Unexpected body (%s)", w.Body.String())
+		}
+	}
+}
+
+func TestMaxBytesIgnored(t *testing.T) {
+	r := Classic()
+	r.Post("/ping", func(w http.ResponseWriter, req *http.Request) {
+		io.ReadAll(req.Body)
+	}).MaxBytes(4)
+
+	req := httptest.NewRequest(http.MethodPost, "/ping", strings.NewReader("too large"))
+	req.ContentLength = -1
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Unexpected status (%d)", w.Code)
+	}
+}
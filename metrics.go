@@ -0,0 +1,232 @@
+package mux
+
+import (
+	"bytes"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the default upper bounds of the latency histograms in
+// seconds, the default buckets of the Prometheus client libraries.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// MetricsOptions configures the metrics of a router.
+type MetricsOptions struct {
+	// Namespace prefixes the metric names, e.g. myapp for
+	// myapp_http_requests_total.
+	Namespace string
+	// Buckets are the upper bounds of the latency histograms in seconds, it
+	// defaults to DefaultBuckets.
+	Buckets []float64
+	// Clock returns the current time, it defaults to time.Now.
+	Clock func() time.Time
+}
+
+// Metrics collects request metrics per route in the Prometheus exposition
+// format, without depending on a client library:
+//
+//   - http_requests_total, a counter by method, route and status code
+//   - http_requests_in_flight, a gauge by method and route
+//   - http_request_duration_seconds, a latency histogram by method and route
+//
+// The route label is the path template of the matched route, e.g.
+// /users/:id, not the requested path, and the method label is OTHER for
+// non-standard methods, so the number of series is bounded by the number of
+// routes.
+type Metrics struct {
+	opts  MetricsOptions
+	names [3]string
+
+	mu     sync.Mutex
+	series map[metricsKey]*metricsSeries
+}
+
+type metricsKey struct {
+	method, route string
+}
+
+type metricsSeries struct {
+	inFlight int64
+	codes    map[int]uint64
+	buckets  []uint64
+	count    uint64
+	sum      float64
+}
+
+// NewMetrics returns the metrics of a router. Its Middleware is attached to
+// the router and its Handler serves the exposition endpoint, e.g.:
+//
+//     metrics := mux.NewMetrics(mux.MetricsOptions{})
+//     r.Use(metrics.Middleware)
+//     r.Get("/metrics", metrics.Handler().ServeHTTP)
+//
+func NewMetrics(opts MetricsOptions) *Metrics {
+	if opts.Buckets == nil {
+		opts.Buckets = DefaultBuckets
+	}
+	opts.Buckets = append([]float64(nil), opts.Buckets...)
+	sort.Float64s(opts.Buckets)
+	if opts.Clock == nil {
+		opts.Clock = time.Now
+	}
+
+	prefix := ""
+	if opts.Namespace != "" {
+		prefix = opts.Namespace + "_"
+	}
+	return &Metrics{
+		opts: opts,
+		names: [3]string{
+			prefix + "http_requests_total",
+			prefix + "http_requests_in_flight",
+			prefix + "http_request_duration_seconds",
+		},
+		series: map[metricsKey]*metricsSeries{},
+	}
+}
+
+// Middleware records the requests of the matched routes. Requests without
+// a route aren't recorded, as middlewares only wrap matched routes.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		key := metricsKey{method: metricsMethod(req.Method)}
+		if route := CurrentRoute(req); route != nil {
+			key.route = route.GetPath()
+		}
+
+		m.mu.Lock()
+		s := m.series[key]
+		if s == nil {
+			s = &metricsSeries{codes: map[int]uint64{}, buckets: make([]uint64, len(m.opts.Buckets))}
+			m.series[key] = s
+		}
+		s.inFlight++
+		m.mu.Unlock()
+
+		start := m.opts.Clock()
+		sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+		// a panic of the handler is recorded as 500
+		code := http.StatusInternalServerError
+		defer func() {
+			m.record(s, code, m.opts.Clock().Sub(start).Seconds())
+		}()
+
+		next.ServeHTTP(sw, req)
+		code = sw.code
+	})
+}
+
+// metricsMethod returns the method label of a request method, OTHER for a
+// non-standard method.
+func metricsMethod(method string) string {
+	if _, ok := methods[method]; ok || method == http.MethodTrace {
+		return method
+	}
+	return "OTHER"
+}
+
+// record records a finished request of a series.
+func (m *Metrics) record(s *metricsSeries, code int, seconds float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s.inFlight--
+	s.codes[code]++
+	s.count++
+	s.sum += seconds
+	for i, bound := range m.opts.Buckets {
+		if seconds <= bound {
+			s.buckets[i]++
+		}
+	}
+}
+
+// Handler returns a handler which renders the metrics in the Prometheus
+// text exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write(m.exposition())
+	})
+}
+
+// exposition renders the metrics in the text exposition format, with the
+// series sorted by their labels.
+func (m *Metrics) exposition() []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]metricsKey, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		return keys[i].method < keys[j].method
+	})
+
+	var buf bytes.Buffer
+	writeMetricHeader(&buf, m.names[0], "counter", "Total number of HTTP requests by route and status code.")
+	for _, key := range keys {
+		s := m.series[key]
+		codes := make([]int, 0, len(s.codes))
+		for code := range s.codes {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			writeMetric(&buf, m.names[0], key, "code", strconv.Itoa(code), float64(s.codes[code]))
+		}
+	}
+
+	writeMetricHeader(&buf, m.names[1], "gauge", "Number of HTTP requests being served by route.")
+	for _, key := range keys {
+		writeMetric(&buf, m.names[1], key, "", "", float64(m.series[key].inFlight))
+	}
+
+	writeMetricHeader(&buf, m.names[2], "histogram", "Latency of the HTTP requests by route in seconds.")
+	for _, key := range keys {
+		s := m.series[key]
+		for i, bound := range m.opts.Buckets {
+			writeMetric(&buf, m.names[2]+"_bucket", key, "le", formatFloat(bound), float64(s.buckets[i]))
+		}
+		writeMetric(&buf, m.names[2]+"_bucket", key, "le", "+Inf", float64(s.count))
+		writeMetric(&buf, m.names[2]+"_sum", key, "", "", s.sum)
+		writeMetric(&buf, m.names[2]+"_count", key, "", "", float64(s.count))
+	}
+	return buf.Bytes()
+}
+
+func writeMetricHeader(buf *bytes.Buffer, name, kind, help string) {
+	buf.WriteString("# HELP " + name + " " + help + "\n")
+	buf.WriteString("# TYPE " + name + " " + kind + "\n")
+}
+
+// writeMetric writes a sample with the labels of key and an optional extra
+// label.
+func writeMetric(buf *bytes.Buffer, name string, key metricsKey, label, value string, sample float64) {
+	buf.WriteString(name)
+	buf.WriteString(`{method="` + escapeLabel(key.method) + `",route="` + escapeLabel(key.route) + `"`)
+	if label != "" {
+		buf.WriteString("," + label + `="` + escapeLabel(value) + `"`)
+	}
+	buf.WriteString("} ")
+	buf.WriteString(formatFloat(sample))
+	buf.WriteByte('\n')
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
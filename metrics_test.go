@@ -0,0 +1,105 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	now := time.Unix(0, 0)
+	metrics := NewMetrics(MetricsOptions{
+		Namespace: "app",
+		Buckets:   []float64{0.5, 0.1},
+		Clock: func() time.Time {
+			now = now.Add(100 * time.Millisecond)
+			return now
+		},
+	})
+
+	r := Classic()
+	r.Use(metrics.Middleware)
+	r.Get("/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	})
+	r.Get("/metrics", metrics.Handler().ServeHTTP)
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/3?fail=1", "/missing"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type (%s)", w.Header().Get("Content-Type"))
+	}
+
+	body := w.Body.String()
+	expected := []string{
+		"# TYPE app_http_requests_total counter\n",
+		`app_http_requests_total{method="GET",route="/users/:id",code="200"} 2` + "\n",
+		`app_http_requests_total{method="GET",route="/users/:id",code="502"} 1` + "\n",
+		"# TYPE app_http_requests_in_flight gauge\n",
+		`app_http_requests_in_flight{method="GET",route="/users/:id"} 0` + "\n",
+		// the request for the metrics is in flight
+		`app_http_requests_in_flight{method="GET",route="/metrics"} 1` + "\n",
+		"# TYPE app_http_request_duration_seconds histogram\n",
+		`app_http_request_duration_seconds_bucket{method="GET",route="/users/:id",le="0.1"} 3` + "\n",
+		`app_http_request_duration_seconds_bucket{method="GET",route="/users/:id",le="0.5"} 3` + "\n",
+		`app_http_request_duration_seconds_bucket{method="GET",route="/users/:id",le="+Inf"} 3` + "\n",
+		`app_http_request_duration_seconds_count{method="GET",route="/users/:id"} 3` + "\n",
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("Missing %q in metrics:\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, "/missing") || strings.Contains(body, "/users/1") {
+		t.Errorf("Unexpected raw path in metrics:\n%s", body)
+	}
+}
+
+func TestMetricsMethod(t *testing.T) {
+	metrics := NewMetrics(MetricsOptions{})
+	handler := metrics.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	for _, method := range []string{http.MethodGet, "PROPFIND", "X-RANDOM-1", "X-RANDOM-2"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/", nil))
+	}
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := w.Body.String()
+	for _, line := range []string{
+		`http_requests_total{method="GET",route="",code="200"} 1` + "\n",
+		`http_requests_total{method="OTHER",route="",code="200"} 3` + "\n",
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("Missing %q in metrics:\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, "RANDOM") {
+		t.Errorf("Unexpected raw method in metrics:\n%s", body)
+	}
+}
+
+func TestMetricsPanic(t *testing.T) {
+	metrics := NewMetrics(MetricsOptions{})
+	r := Classic()
+	r.Use(Recovery(nil, nil))
+	r.Use(metrics.Middleware)
+	r.Get("/panic", func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	body := string(metrics.exposition())
+	if !strings.Contains(body, `http_requests_total{method="GET",route="/panic",code="500"} 1`) ||
+		!strings.Contains(body, `http_requests_in_flight{method="GET",route="/panic"} 0`) {
+		t.Errorf("Unexpected metrics:\n%s", body)
+	}
+}
@@ -0,0 +1,19 @@
+package mux
+
+import "net/http"
+
+// MiddlewareFunc wraps a http.Handler, e.g. to add logging, authentication
+// or recovery to the matched handler.
+type MiddlewareFunc func(http.Handler) http.Handler
+
+// middlewares implements a chain of middlewares.
+type middlewares []MiddlewareFunc
+
+// apply wraps h with the middlewares. The first middleware is the outermost
+// and is called first.
+func (m middlewares) apply(h http.Handler) http.Handler {
+	for i := len(m) - 1; i >= 0; i-- {
+		h = m[i](h)
+	}
+	return h
+}
@@ -0,0 +1,112 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func appendMiddleware(key string) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(key))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestMiddlewaresApply(t *testing.T) {
+	mws := middlewares{appendMiddleware("a"), appendMiddleware("b")}
+	handler := mws.apply(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("h"))
+	}))
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, &http.Request{})
+
+	if res.Body.String() != "abh" {
+		t.Errorf("Unexpected order (%s)", res.Body.String())
+	}
+}
+
+func TestRouterUse(t *testing.T) {
+	r := Classic()
+	r.Use(appendMiddleware("a"))
+	r.Use(appendMiddleware("b"), appendMiddleware("c"))
+	r.Get("/echo", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("h"))
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/echo", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	if res.Body.String() != "abch" {
+		t.Errorf("Unexpected order (%s)", res.Body.String())
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "http://localhost/notfound", nil)
+	res = httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	if res.Code != http.StatusNotFound {
+		t.Errorf("Unexpected status code (%d)", res.Code)
+	}
+}
+
+func TestRouteUse(t *testing.T) {
+	r := Classic()
+	r.Use(appendMiddleware("a"))
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("h"))
+	}
+	r.Get("/admin", handler).Use(appendMiddleware("b"), appendMiddleware("c"))
+	r.Get("/echo", handler)
+
+	tests := map[string]string{
+		"http://localhost/admin": "abch",
+		"http://localhost/echo":  "ah",
+	}
+
+	for url, body := range tests {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, req)
+
+		if res.Body.String() != body {
+			t.Errorf("Unexpected order (Url: %s, Body: %s)", url, res.Body.String())
+		}
+	}
+}
+
+func TestSubrouterUse(t *testing.T) {
+	r := Classic()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("h"))
+	}
+
+	r.Use(appendMiddleware("a"))
+	admin := r.PathPrefix("/admin").Subrouter()
+	admin.Get("/users", handler).Use(appendMiddleware("d"))
+	admin.Use(appendMiddleware("b"))
+	audit := admin.PathPrefix("/audit").Subrouter()
+	audit.Use(appendMiddleware("c"))
+	audit.Get("/logs", handler).Use(appendMiddleware("d"))
+	r.Get("/echo", handler)
+
+	tests := map[string]string{
+		"http://localhost/admin/users":      "abdh",
+		"http://localhost/admin/audit/logs": "abcdh",
+		"http://localhost/echo":             "ah",
+	}
+
+	for url, body := range tests {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, req)
+
+		if res.Body.String() != body {
+			t.Errorf("Unexpected order (Url: %s, Body: %s)", url, res.Body.String())
+		}
+	}
+}
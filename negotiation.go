@@ -0,0 +1,228 @@
+package mux
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// qualityValue is an element of a header with quality values, e.g.
+// "text/html;q=0.8" of an Accept header.
+type qualityValue struct {
+	value string
+	q     float64
+}
+
+// parseQualityValues parses a comma separated list of values with optional
+// quality values. Values are lowercased, other parameters are dropped and
+// invalid quality values are treated as 0.
+func parseQualityValues(header string) []qualityValue {
+	values := []qualityValue{}
+
+	for _, element := range strings.Split(header, ",") {
+		value, params, _ := strings.Cut(element, ";")
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+
+		qv := qualityValue{value: value, q: 1}
+		for _, param := range strings.Split(params, ";") {
+			key, v, _ := strings.Cut(param, "=")
+			if strings.TrimSpace(strings.ToLower(key)) != "q" {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil || q < 0 || q > 1 {
+				q = 0
+			}
+			qv.q = q
+		}
+		values = append(values, qv)
+	}
+
+	return values
+}
+
+// negotiate returns the offer with the highest quality value in header. The
+// quality value of an offer is the one of the most specific range matching
+// it, specificity returns -1 if a range doesn't match. Ties are broken by
+// the specificity of the ranges and then by the order of the offers. An
+// empty header accepts any offer.
+func negotiate(header string, offers []string, specificity func(rng, offer string) int) string {
+	if len(offers) == 0 {
+		return ""
+	}
+
+	if strings.TrimSpace(header) == "" {
+		return offers[0]
+	}
+
+	ranges := parseQualityValues(header)
+
+	best, bestQ, bestSpecificity := "", 0.0, -1
+	for _, offer := range offers {
+		q, s := 0.0, -1
+		for _, rng := range ranges {
+			if rs := specificity(rng.value, strings.ToLower(offer)); rs > s {
+				q, s = rng.q, rs
+			}
+		}
+
+		if q > bestQ || (q == bestQ && q > 0 && s > bestSpecificity) {
+			best, bestQ, bestSpecificity = offer, q, s
+		}
+	}
+
+	return best
+}
+
+// mediaRangeSpecificity returns how specific a media range of an Accept
+// header matches a media type: 2 for type/subtype, 1 for type/* and 0 for
+// */*, as defined by RFC 7231 section 5.3.2.
+func mediaRangeSpecificity(rng, offer string) int {
+	switch {
+	case rng == offer:
+		return 2
+	case rng == "*/*":
+		return 0
+	case strings.HasSuffix(rng, "/*") && strings.HasPrefix(offer, rng[:len(rng)-1]):
+		return 1
+	}
+	return -1
+}
+
+// NegotiateContentType returns the media type of offers the client prefers
+// according to the Accept header, or an empty string if it accepts none of
+// them. A client without an Accept header accepts the first offer.
+//
+//     switch mux.NegotiateContentType(req, "application/json", "text/html") {
+//     case "text/html":
+//         renderHTML(w, users)
+//     default:
+//         json.NewEncoder(w).Encode(users)
+//     }
+//
+func NegotiateContentType(r *http.Request, offers ...string) string {
+	return negotiate(r.Header.Get("Accept"), offers, mediaRangeSpecificity)
+}
+
+// languageRangeSpecificity returns how specific a language range of an
+// Accept-Language header matches a language tag: 3 if they are equal, 2 if
+// the range is a prefix of the tag (en matches en-US, see RFC 4647 section
+// 3.3.1), 1 if the tag is a prefix of the range (en-US falls back to en) and
+// 0 for *.
+func languageRangeSpecificity(rng, offer string) int {
+	switch {
+	case rng == offer:
+		return 3
+	case strings.HasPrefix(offer, rng+"-"):
+		return 2
+	case strings.HasPrefix(rng, offer+"-"):
+		return 1
+	case rng == "*":
+		return 0
+	}
+	return -1
+}
+
+// NegotiateLanguage returns the locale of supported the client prefers
+// according to the Accept-Language header, or an empty string if it accepts
+// none of them. A client without an Accept-Language header accepts the
+// first locale.
+func NegotiateLanguage(r *http.Request, supported ...string) string {
+	return negotiate(r.Header.Get("Accept-Language"), supported, languageRangeSpecificity)
+}
+
+// Locales returns a middleware which negotiates the locale of the request
+// with the supported locales, see NegotiateLanguage. The handler gets the
+// locale with Locale, it is the first supported locale if the client accepts
+// none of them.
+func Locales(supported ...string) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			locale := NegotiateLanguage(req, supported...)
+			if locale == "" && len(supported) != 0 {
+				locale = supported[0]
+			}
+			next.ServeHTTP(w, contextSet(req, localeKey, locale))
+		})
+	}
+}
+
+// Locale returns the locale negotiated by the Locales middleware.
+func Locale(r *http.Request) string {
+	if rv := contextGet(r, localeKey); rv != nil {
+		return rv.(string)
+	}
+	return ""
+}
+
+// negotiationMatcher matches if the client prefers one of its values over
+// the values of the other routes with the same path in a header (e.g. the
+// media types of Accept), so the routes of a path are selected by the header
+// regardless of their order.
+type negotiationMatcher struct {
+	header string
+	values []string
+	// route whose siblings are negotiated with, nil for conflict detection.
+	route       *Route
+	specificity func(rng, offer string) int
+}
+
+func newNegotiationMatcher(route *Route, header string, specificity func(rng, offer string) int, values ...string) negotiationMatcher {
+	lowered := make([]string, len(values))
+	for k, v := range values {
+		lowered[k] = strings.ToLower(strings.TrimSpace(v))
+	}
+	return negotiationMatcher{header: header, values: lowered, route: route, specificity: specificity}
+}
+
+func (m negotiationMatcher) Match(r *http.Request) bool {
+	best := negotiate(r.Header.Get(m.header), m.offers(r.Method), m.specificity)
+	for _, v := range m.values {
+		if v == best {
+			return true
+		}
+	}
+	return false
+}
+
+// offers returns the values of the routes registered for method with the
+// same path and header as the route of the matcher, in matching order.
+func (m negotiationMatcher) offers(method string) []string {
+	if m.route == nil || m.route.router == nil {
+		return m.values
+	}
+
+	offers := []string{}
+	seen := map[string]struct{}{}
+	for _, route := range m.route.router.table().routes[method] {
+		if route.Kind() != m.route.Kind() || route.GetPath() != m.route.GetPath() {
+			continue
+		}
+		for _, matcher := range route.GetMatchers() {
+			if nm, ok := matcher.(negotiationMatcher); ok && nm.header == m.header {
+				for _, v := range nm.values {
+					if _, found := seen[v]; !found {
+						seen[v] = struct{}{}
+						offers = append(offers, v)
+					}
+				}
+			}
+		}
+	}
+
+	if len(offers) == 0 {
+		return m.values
+	}
+	return offers
+}
+
+func (m negotiationMatcher) Rank() Rank {
+	return RankHeader
+}
+
+func (m negotiationMatcher) Cost() Cost {
+	return CostRegex
+}
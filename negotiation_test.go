@@ -0,0 +1,163 @@
+package mux
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseQualityValues(t *testing.T) {
+	values := parseQualityValues("text/html, application/json;q=0.9;charset=utf-8, */*; Q=0.1, ,text/plain;q=2")
+
+	expected := []qualityValue{
+		{"text/html", 1},
+		{"application/json", 0.9},
+		{"*/*", 0.1},
+		{"text/plain", 0},
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Unexpected values (%v)", values)
+	}
+}
+
+func TestNegotiateContentType(t *testing.T) {
+	offers := []string{"application/json", "text/html"}
+
+	tests := []struct {
+		accept   string
+		expected string
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"text/html", "text/html"},
+		{"text/html;q=0.8, application/json;q=0.9", "application/json"},
+		{"text/*, application/json;q=0.5", "text/html"},
+		// the most specific range applies
+		{"*/*;q=0.1, text/html;q=0", "application/json"},
+		{"application/*;q=0.2, text/html;q=0.2", "text/html"},
+		{"image/png", ""},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", test.accept)
+
+		if value := NegotiateContentType(req, offers...); value != test.expected {
+			t.Errorf("Expected %q for %q, got %q", test.expected, test.accept, value)
+		}
+	}
+}
+
+func TestAccepts(t *testing.T) {
+	r := Classic()
+	r.Get("/users", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "json")
+	}).Accepts("application/json")
+	r.Get("/users", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "html")
+	}).Accepts("text/html", "application/xhtml+xml")
+
+	if ok, errs := r.HasErrors(); ok {
+		t.Fatalf("Unexpected errors (%v)", errs)
+	}
+
+	tests := []struct {
+		accept string
+		body   string
+	}{
+		{"", "json"},
+		{"text/html, application/json;q=0.9", "html"},
+		{"application/xhtml+xml", "html"},
+		{"application/json, text/html;q=0.9", "json"},
+		{"image/png", "404 page not found\n"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set("Accept", test.accept)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Body.String() != test.body {
+			t.Errorf("Expected body %q for %q, got %q", test.body, test.accept, w.Body.String())
+		}
+	}
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+	supported := []string{"en", "de-CH", "fr"}
+
+	tests := []struct {
+		acceptLanguage string
+		expected       string
+	}{
+		{"", "en"},
+		{"de-CH", "de-CH"},
+		{"fr;q=0.8, de;q=0.9", "de-CH"},
+		{"en-US, fr;q=0.9", "en"},
+		{"*;q=0.1, en;q=0", "de-CH"},
+		{"it, es", ""},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Language", test.acceptLanguage)
+
+		if value := NegotiateLanguage(req, supported...); value != test.expected {
+			t.Errorf("Expected %q for %q, got %q", test.expected, test.acceptLanguage, value)
+		}
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	r := Classic()
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, Locale(req))
+	}
+	r.Get("/help", handler).Languages("de", "de-CH").Use(Locales("de", "de-CH"))
+	r.Get("/help", handler).Languages("en").Use(Locales("en"))
+
+	tests := []struct {
+		acceptLanguage string
+		body           string
+	}{
+		{"", "de"},
+		{"en-GB, de;q=0.5", "en"},
+		{"de-CH", "de-CH"},
+		{"ja", "404 page not found\n"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/help", nil)
+		req.Header.Set("Accept-Language", test.acceptLanguage)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Body.String() != test.body {
+			t.Errorf("Expected body %q for %q, got %q", test.body, test.acceptLanguage, w.Body.String())
+		}
+	}
+}
+
+func TestLocalesDefault(t *testing.T) {
+	var locale string
+	handler := Locales("en", "de")(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		locale = Locale(req)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "ja")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if locale != "en" {
+		t.Errorf("Unexpected locale (%s)", locale)
+	}
+
+	if locale := Locale(req); locale != "" {
+		t.Errorf("Unexpected locale (%s)", locale)
+	}
+}
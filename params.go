@@ -0,0 +1,111 @@
+package mux
+
+import (
+	"net/http"
+	"sync"
+)
+
+// param is a route variable of a request.
+type param struct {
+	name  string
+	value string
+	// typed is the converted value of a variable with a custom type.
+	typed interface{}
+}
+
+// params holds the route variables of a request in order of appearance.
+//
+// The router takes params from a pool and returns them after the handler of
+// the matched route returns, so capturing the variables doesn't allocate.
+// Param reads from them without allocating, GetVars copies them into a map.
+type params []param
+
+var paramsPool = sync.Pool{
+	New: func() interface{} {
+		p := make(params, 0, 8)
+		return &p
+	},
+}
+
+// acquireParams returns empty params from the pool.
+func acquireParams() *params {
+	return paramsPool.Get().(*params)
+}
+
+// releaseParams clears the params and puts them back into the pool.
+func releaseParams(p *params) {
+	for k := range *p {
+		(*p)[k] = param{}
+	}
+	*p = (*p)[:0]
+	paramsPool.Put(p)
+}
+
+// detachParams returns req with a copy of its pooled params, so it can be
+// used by a goroutine which outlives the handler, e.g. after a timeout.
+func detachParams(req *http.Request) *http.Request {
+	p, ok := contextGet(req, varsKey).(*params)
+	if !ok {
+		return req
+	}
+	detached := append(params(nil), *p...)
+	return contextSet(req, varsKey, &detached)
+}
+
+// set sets the value of a variable, replacing a previous value.
+func (p *params) set(name, value string) {
+	for k := range *p {
+		if (*p)[k].name == name {
+			(*p)[k].value = value
+			return
+		}
+	}
+	*p = append(*p, param{name: name, value: value})
+}
+
+// get returns the value of a variable.
+func (p params) get(name string) (string, bool) {
+	for _, param := range p {
+		if param.name == name {
+			return param.value, true
+		}
+	}
+	return "", false
+}
+
+// typed returns the converted value of a variable.
+func (p params) typed(name string) interface{} {
+	for _, param := range p {
+		if param.name == name {
+			return param.typed
+		}
+	}
+	return nil
+}
+
+// vars copies the params into a map.
+func (p params) vars() Vars {
+	vars := make(Vars, len(p))
+	for _, param := range p {
+		vars[param.name] = param.value
+	}
+	return vars
+}
+
+// paramsExtractor is implemented by routes which can write their variables
+// to params.
+type paramsExtractor interface {
+	extractParams(req *http.Request, p *params)
+}
+
+// captureParams writes the variables of the matched route to p.
+func captureParams(route RouteInterface, req *http.Request, p *params) {
+	if pe, ok := route.(paramsExtractor); ok {
+		pe.extractParams(req, p)
+		return
+	}
+
+	for name, value := range route.ExtractVars(req) {
+		p.set(name, value)
+	}
+}
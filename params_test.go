@@ -0,0 +1,91 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParams(t *testing.T) {
+	p := params{}
+	p.set("id", "1")
+	p.set("slug", "hello")
+	p.set("id", "2")
+
+	if value, found := p.get("id"); !found || value != "2" {
+		t.Errorf("Unexpected value (%v)", value)
+	}
+
+	if _, found := p.get("missing"); found {
+		t.Error("Unexpected found value")
+	}
+
+	if !reflect.DeepEqual(p.vars(), Vars{"id": "2", "slug": "hello"}) {
+		t.Errorf("Unexpected vars (%v)", p.vars())
+	}
+}
+
+func TestReleaseParams(t *testing.T) {
+	p := acquireParams()
+	p.set("id", "1")
+	releaseParams(p)
+
+	if len(*p) != 0 || cap(*p) == 0 || (*p)[:1][0] != (param{}) {
+		t.Errorf("Unexpected params (%v)", *p)
+	}
+}
+
+func TestParamFromRouter(t *testing.T) {
+	r := Classic()
+
+	var id, filepath string
+	var vars Vars
+	r.Get("/users/:id/files/*filepath", func(w http.ResponseWriter, req *http.Request) {
+		id = Param(req, "id")
+		filepath = Param(req, "filepath")
+		vars = GetVars(req)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42/files/a/b.txt", nil))
+
+	if id != "42" || filepath != "a/b.txt" {
+		t.Errorf("Unexpected params (id: %q, filepath: %q)", id, filepath)
+	}
+
+	if !reflect.DeepEqual(vars, Vars{"id": "42", "filepath": "a/b.txt"}) {
+		t.Errorf("Unexpected vars (%v)", vars)
+	}
+}
+
+func TestCaptureParamsAllocs(t *testing.T) {
+	r := Classic()
+	route := r.Get("/users/:id/posts/:number", nil)
+	req := httptest.NewRequest(http.MethodGet, "/users/42/posts/7", nil)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		p := acquireParams()
+		captureParams(route, req, p)
+		if value, _ := p.get(":number"); value != "7" {
+			t.Fatalf("Unexpected value (%v)", value)
+		}
+		releaseParams(p)
+	})
+
+	if allocs != 0 {
+		t.Errorf("Unexpected allocations (%v)", allocs)
+	}
+}
+
+func BenchmarkCaptureParams(b *testing.B) {
+	r := Classic()
+	route := r.Get("/users/:id/posts/:slug", nil)
+	req := httptest.NewRequest(http.MethodGet, "/users/42/posts/hello", nil)
+
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		p := acquireParams()
+		captureParams(route, req, p)
+		releaseParams(p)
+	}
+}
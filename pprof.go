@@ -0,0 +1,37 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/pprof"
+)
+
+// MountPprof registers the handlers of net/http/pprof below prefix, e.g.
+// /debug/pprof, and returns their subrouter. The profiling endpoints expose
+// internals and can slow down the server, so they should be guarded: the
+// routes only match requests which also match all matchers, and middlewares
+// added to the subrouter wrap them, e.g.:
+//
+//     r.MountPprof("/debug/pprof", mux.RemoteIPs("10.0.0.0/8"))
+//     r.MountPprof("/debug/pprof").Use(mux.BasicAuth("pprof", mux.BasicAuthUsers(admins)))
+//
+// The index is served at prefix with a trailing slash, the profiles at
+// their names, e.g. /debug/pprof/heap.
+func (r *Router) MountPprof(prefix string, matchers ...Matcher) *Router {
+	route := r.PathPrefix(prefix)
+	for _, m := range matchers {
+		route.AddMatcher(m)
+	}
+	sub := route.Subrouter()
+
+	sub.Get("/", pprof.Index)
+	sub.Get("/cmdline", pprof.Cmdline)
+	sub.Get("/profile", pprof.Profile)
+	sub.Match([]string{http.MethodGet, http.MethodPost}, "/symbol", pprof.Symbol)
+	sub.Get("/trace", pprof.Trace)
+	// pprof.Index only serves the profiles below /debug/pprof/
+	sub.Get("/:name", func(w http.ResponseWriter, req *http.Request) {
+		pprof.Handler(Param(req, "name")).ServeHTTP(w, req)
+	})
+
+	return sub
+}
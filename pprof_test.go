@@ -0,0 +1,58 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMountPprof(t *testing.T) {
+	r := Classic()
+	r.MountPprof("/internal/pprof", RemoteIPs("10.0.0.0/8"))
+
+	tests := []struct {
+		path       string
+		remoteAddr string
+		code       int
+		body       string
+	}{
+		{"/internal/pprof/", "10.1.2.3:1234", http.StatusOK, "Types of profiles available"},
+		{"/internal/pprof/goroutine?debug=1", "10.1.2.3:1234", http.StatusOK, "goroutine profile:"},
+		{"/internal/pprof/cmdline", "10.1.2.3:1234", http.StatusOK, ""},
+		{"/internal/pprof/symbol", "10.1.2.3:1234", http.StatusOK, "num_symbols"},
+		{"/internal/pprof/unknown", "10.1.2.3:1234", http.StatusNotFound, "Unknown profile"},
+		{"/internal/pprof/", "203.0.113.9:1234", http.StatusNotFound, ""},
+		{"/internal/pprof/heap", "203.0.113.9:1234", http.StatusNotFound, ""},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+		req.RemoteAddr = test.remoteAddr
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != test.code || !strings.Contains(w.Body.String(), test.body) {
+			t.Errorf("Unexpected response for %s from %s (%d %.100q)", test.path, test.remoteAddr, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestMountPprofMiddleware(t *testing.T) {
+	r := Classic()
+	r.MountPprof("/debug/pprof").Use(BasicAuth("pprof", BasicAuthUsers(map[string]string{"admin": "secret"})))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/pprof/heap", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Unexpected status (%d)", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/pprof/heap", nil)
+	req.SetBasicAuth("admin", "secret")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Unexpected status (%d)", w.Code)
+	}
+}
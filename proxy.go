@@ -0,0 +1,259 @@
+package mux
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Proxy is a reverse proxy handler which forwards requests to upstream
+// targets. The path of a target may contain the variables of the route,
+// e.g. /v2/accounts/:id, which are replaced with the values of the request.
+// A target without variables gets the path of the request appended.
+//
+// The Balancer selects the upstream of a request among the healthy ones. An
+// upstream whose request fails is marked as unhealthy for FailTimeout
+// (passive health checks). If all upstreams are unhealthy, all of them are
+// tried. With a RetryPolicy, failed requests are retried against other
+// upstreams.
+//
+// The X-Forwarded-For, X-Forwarded-Host and X-Forwarded-Proto headers of the
+// upstream request describe the client request. The chain of X-Forwarded-For
+// is kept if the peer is a trusted proxy of the router (see
+// Router.SetTrustedProxies), otherwise it starts with the peer.
+type Proxy struct {
+	// Upstreams are the targets of the requests.
+	Upstreams []*Upstream
+	// Balancer selects the upstream of a request, it defaults to
+	// RoundRobin.
+	Balancer Balancer
+	// FailTimeout is the time an upstream is skipped after a failed request,
+	// it defaults to 10 seconds.
+	FailTimeout time.Duration
+	// Host overrides the Host header of the upstream requests. It defaults
+	// to the host of the target.
+	Host string
+	// PreserveHost passes the Host header of the client on.
+	PreserveHost bool
+	// Transport performs the upstream requests, it defaults to
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+	// ModifyResponse modifies the upstream response, see
+	// httputil.ReverseProxy.
+	ModifyResponse func(*http.Response) error
+	// ErrorHandler answers requests whose upstream request failed. It
+	// defaults to a 502 Bad Gateway response.
+	ErrorHandler func(http.ResponseWriter, *http.Request, error)
+	// Retry retries failed requests with idempotent methods, see
+	// RetryPolicy. Requests aren't retried if it is nil.
+	Retry *RetryPolicy
+
+	// router whose trusted proxies are trusted.
+	router *Router
+	// roundRobin is the balancer of the proxy if Balancer is nil.
+	roundRobin roundRobin
+}
+
+// NewProxy returns a Proxy to the target URLs, which must be absolute.
+func NewProxy(targets ...string) (*Proxy, error) {
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("mux: proxy has no targets")
+	}
+
+	p := &Proxy{}
+	for _, target := range targets {
+		u, err := url.Parse(target)
+		if err != nil {
+			return nil, fmt.Errorf("mux: proxy target %q is invalid: %v", target, err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("mux: proxy target %q isn't absolute", target)
+		}
+		p.Upstreams = append(p.Upstreams, &Upstream{URL: u})
+	}
+	return p, nil
+}
+
+// Proxy registers a route for pattern and every standard method which
+// forwards the requests to the targets, e.g.:
+//
+//     r.Proxy("/accounts/:id", "http://accounts.internal/v2/accounts/:id")
+//     r.Proxy("/legacy/*path", "http://legacy-1.internal/*path", "http://legacy-2.internal/*path")
+//
+// See Proxy for the forwarded headers and the balancing. Use NewProxy for
+// further options and register it as the handler of a route.
+func (r *Router) Proxy(pattern string, targets ...string) RouteInterface {
+	route := r.NewRoute().Path(pattern)
+
+	p, err := NewProxy(targets...)
+	if err != nil && !route.HasError() {
+		route.SetError(NewBadRouteError(route, err.Error()))
+	}
+	if p != nil {
+		p.router = r
+		route.Handler(p)
+	}
+
+	return r.registerRoute(standardMethods(), route)
+}
+
+// ServeHTTP forwards the request to an upstream.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if p.Retry != nil && isIdempotent(req.Method) {
+		p.serveRetries(w, req)
+		return
+	}
+
+	upstream := p.next(req)
+	if upstream == nil {
+		http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		return
+	}
+
+	upstream.active.Add(1)
+	defer upstream.active.Add(-1)
+
+	rp := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			p.rewrite(pr, upstream.URL)
+		},
+		Transport:      p.Transport,
+		ModifyResponse: p.ModifyResponse,
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			p.failUpstream(upstream, req)
+			p.handleError(w, req, err)
+		},
+	}
+	rp.ServeHTTP(w, req)
+}
+
+// failUpstream marks the upstream of a failed request as unhealthy, unless
+// the client went away, which isn't the fault of the upstream.
+func (p *Proxy) failUpstream(upstream *Upstream, req *http.Request) {
+	if req.Context().Err() != nil {
+		return
+	}
+	upstream.fail(p.failTimeout())
+}
+
+// handleError answers a request whose upstream request failed.
+func (p *Proxy) handleError(w http.ResponseWriter, req *http.Request, err error) {
+	if p.ErrorHandler != nil {
+		p.ErrorHandler(w, req, err)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+}
+
+// next returns the upstream of the request, nil if there is none. The
+// upstreams which were tried already are avoided if there are others.
+func (p *Proxy) next(req *http.Request, tried ...*Upstream) *Upstream {
+	if len(p.Upstreams) == 0 {
+		return nil
+	}
+
+	candidates := p.Upstreams
+	if len(tried) != 0 {
+		candidates = make([]*Upstream, 0, len(p.Upstreams))
+		for _, u := range p.Upstreams {
+			if !containsUpstream(tried, u) {
+				candidates = append(candidates, u)
+			}
+		}
+		if len(candidates) == 0 {
+			candidates = p.Upstreams
+		}
+	}
+
+	now := time.Now()
+	healthy := make([]*Upstream, 0, len(candidates))
+	for _, u := range candidates {
+		if u.Healthy(now) {
+			healthy = append(healthy, u)
+		}
+	}
+	if len(healthy) == 0 {
+		healthy = candidates
+	}
+
+	balancer := p.Balancer
+	if balancer == nil {
+		balancer = &p.roundRobin
+	}
+	return balancer.Next(req, healthy)
+}
+
+func containsUpstream(upstreams []*Upstream, u *Upstream) bool {
+	for _, v := range upstreams {
+		if v == u {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Proxy) failTimeout() time.Duration {
+	if p.FailTimeout <= 0 {
+		return 10 * time.Second
+	}
+	return p.FailTimeout
+}
+
+// rewrite builds the upstream request to target.
+func (p *Proxy) rewrite(pr *httputil.ProxyRequest, target *url.URL) {
+	pr.Out.URL.Scheme = target.Scheme
+	pr.Out.URL.Host = target.Host
+	pr.Out.URL.Path, pr.Out.URL.RawPath = upstreamPath(target.Path, pr.In), ""
+	switch {
+	case target.RawQuery == "" || pr.In.URL.RawQuery == "":
+		pr.Out.URL.RawQuery = target.RawQuery + pr.In.URL.RawQuery
+	default:
+		pr.Out.URL.RawQuery = target.RawQuery + "&" + pr.In.URL.RawQuery
+	}
+
+	pr.SetXForwarded()
+	pr.Out.Header.Set("X-Forwarded-Proto", requestScheme(pr.In))
+	if p.router != nil && p.router.isTrustedProxy(remoteIP(pr.In)) {
+		if chain := pr.In.Header.Values("X-Forwarded-For"); len(chain) != 0 {
+			pr.Out.Header.Set("X-Forwarded-For", strings.Join(chain, ", ")+", "+remoteIP(pr.In))
+		}
+	}
+	InjectTrace(pr.In.Context(), pr.Out.Header)
+
+	switch {
+	case p.PreserveHost:
+		pr.Out.Host = pr.In.Host
+	case p.Host != "":
+		pr.Out.Host = p.Host
+	default:
+		pr.Out.Host = ""
+	}
+}
+
+// upstreamPath returns the path of the upstream request: the path of the
+// target with the variables of the request, or the path of the request
+// appended to it.
+func upstreamPath(path string, req *http.Request) string {
+	if !strings.ContainsAny(path, ":*{") {
+		return strings.TrimSuffix(path, "/") + "/" + strings.TrimPrefix(req.URL.Path, "/")
+	}
+
+	vars := GetVars(req)
+	segments := strings.Split(path, "/")
+	for k, segment := range segments {
+		var name string
+		switch {
+		case strings.HasPrefix(segment, ":"), strings.HasPrefix(segment, "*"):
+			name = strings.TrimSuffix(segment[1:], "?")
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+			name, _, _ = strings.Cut(segment[1:len(segment)-1], ":")
+		default:
+			continue
+		}
+		segments[k] = strings.TrimPrefix(vars.Get(name), "/")
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,109 @@
+package mux
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// upstream echoes the request it got.
+func upstream() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "%s %s host=%s xff=%s xfh=%s xfp=%s",
+			req.Method, req.URL.RequestURI(), req.Host,
+			req.Header.Get("X-Forwarded-For"), req.Header.Get("X-Forwarded-Host"), req.Header.Get("X-Forwarded-Proto"))
+	}))
+}
+
+func TestProxy(t *testing.T) {
+	backend := upstream()
+	defer backend.Close()
+
+	r := Classic()
+	r.Proxy("/accounts/:id", backend.URL+"/v2/accounts/:id?source=mux")
+	r.Proxy("/legacy/*path", backend.URL+"/old/*path")
+	r.Proxy("/health", backend.URL)
+
+	if ok, errs := r.HasErrors(); ok {
+		t.Fatalf("Unexpected errors (%v)", errs)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodGet, "/accounts/42?expand=1", "GET /v2/accounts/42?source=mux&expand=1 host=" + backend.Listener.Addr().String() + " xff=192.0.2.1 xfh=example.com xfp=http"},
+		{http.MethodPost, "/legacy/a/b.txt", "POST /old/a/b.txt host=" + backend.Listener.Addr().String() + " xff=192.0.2.1 xfh=example.com xfp=http"},
+		{http.MethodGet, "/health", "GET /health host=" + backend.Listener.Addr().String() + " xff=192.0.2.1 xfh=example.com xfp=http"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.path, nil)
+		req.Header.Set("X-Forwarded-For", "203.0.113.9")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Body.String() != test.body {
+			t.Errorf("Expected %q for %s %s, got %q", test.body, test.method, test.path, w.Body.String())
+		}
+	}
+}
+
+func TestProxyHost(t *testing.T) {
+	backend := upstream()
+	defer backend.Close()
+
+	r := Classic()
+	if err := r.SetTrustedProxies("192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	preserve, _ := NewProxy(backend.URL)
+	preserve.PreserveHost = true
+	r.Get("/preserve", preserve.ServeHTTP)
+
+	override, _ := NewProxy(backend.URL)
+	override.Host = "api.internal"
+	r.Get("/override", override.ServeHTTP)
+
+	r.Proxy("/chain", backend.URL)
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/preserve", "GET /preserve host=example.com xff=192.0.2.1 xfh=example.com xfp=http"},
+		{"/override", "GET /override host=api.internal xff=192.0.2.1 xfh=example.com xfp=http"},
+		{"/chain", "GET /chain host=" + backend.Listener.Addr().String() + " xff=203.0.113.9, 192.0.2.1 xfh=shop.example.com xfp=https"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+		if test.path == "/chain" {
+			req.Header.Set("X-Forwarded-For", "203.0.113.9")
+			req.Header.Set("X-Forwarded-Host", "shop.example.com")
+			req.Header.Set("X-Forwarded-Proto", "https")
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Body.String() != test.body {
+			t.Errorf("Expected %q for %s, got %q", test.body, test.path, w.Body.String())
+		}
+	}
+}
+
+func TestProxyInvalidTarget(t *testing.T) {
+	r := Classic()
+	r.Proxy("/accounts", "accounts.internal")
+
+	if ok, errs := r.HasErrors(); !ok || len(errs) == 0 {
+		t.Error("Expected an error for a relative target")
+	}
+
+	if _, err := NewProxy("http://[::1"); err == nil {
+		t.Error("Expected an error for an invalid target")
+	}
+}
@@ -0,0 +1,175 @@
+package mux
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RateLimitStore holds the token buckets of the RateLimit middleware. It has
+// to be safe for concurrent use. NewRateLimitStore returns an in-memory
+// store, a limiter shared by several processes (e.g. backed by Redis) can be
+// plugged in by implementing it.
+type RateLimitStore interface {
+	// Take takes a token from the bucket of key, which holds up to burst
+	// tokens and is refilled with rate tokens per second. If the bucket is
+	// empty it returns false and the time until a token is available.
+	Take(key string, rate float64, burst int, now time.Time) (bool, time.Duration)
+}
+
+// NewRateLimitStore returns an in-memory RateLimitStore. Buckets which are
+// full again are dropped.
+func NewRateLimitStore() RateLimitStore {
+	return &memoryRateLimitStore{buckets: map[string]*tokenBucket{}}
+}
+
+type memoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	swept   time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	rate   float64
+	burst  int
+}
+
+// sweepInterval is the interval the full buckets are dropped in.
+const sweepInterval = time.Minute
+
+func (s *memoryRateLimitStore) Take(key string, rate float64, burst int, now time.Time) (bool, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.swept) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	b, found := s.buckets[key]
+	if !found {
+		b = &tokenBucket{tokens: float64(burst), last: now}
+		s.buckets[key] = b
+	}
+	b.rate, b.burst = rate, burst
+	b.refill(now)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	if rate <= 0 {
+		return false, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// sweep drops the buckets which are full by now, refilled with the rate
+// and burst of their last take.
+func (s *memoryRateLimitStore) sweep(now time.Time) {
+	s.swept = now
+	for key, b := range s.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// refill adds the tokens of the time since the last refill.
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(float64(b.burst), b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+// RateLimitOptions configures the RateLimit middleware.
+type RateLimitOptions struct {
+	// Rate is the number of requests per second allowed per key.
+	Rate float64
+	// Burst is the number of requests allowed at once, it defaults to the
+	// rate rounded up.
+	Burst int
+	// Key returns the key the requests are limited by, it defaults to
+	// ClientIP. Requests with an empty key aren't limited.
+	Key func(*http.Request) string
+	// Store holds the token buckets, it defaults to a NewRateLimitStore.
+	Store RateLimitStore
+	// Prefix is prepended to the keys in the store, it defaults to a prefix
+	// unique to the middleware. Middlewares with the same prefix and store
+	// share their buckets, e.g. a limiter of several processes.
+	Prefix string
+	// Clock returns the current time, it defaults to time.Now.
+	Clock func() time.Time
+}
+
+// RateLimit returns a middleware which limits the rate of the requests per
+// key with a token bucket, e.g. per client IP for a login route and per API
+// key for a group:
+//
+//     r.Post("/login", loginHandler).Use(mux.RateLimit(mux.RateLimitOptions{Rate: 1, Burst: 5}))
+//
+//     api := r.PathPrefix("/api").Subrouter()
+//     api.Use(mux.RateLimit(mux.RateLimitOptions{Rate: 100, Key: mux.HeaderKey("X-API-Key")}))
+//
+// Requests over the limit are answered with 429 Too Many Requests and a
+// Retry-After header. Each middleware has its own buckets unless they share
+// a store and a prefix.
+func RateLimit(opts RateLimitOptions) MiddlewareFunc {
+	if opts.Burst < 1 {
+		opts.Burst = int(math.Max(1, math.Ceil(opts.Rate)))
+	}
+	if opts.Key == nil {
+		opts.Key = ClientIP
+	}
+	if opts.Store == nil {
+		opts.Store = NewRateLimitStore()
+	}
+	if opts.Clock == nil {
+		opts.Clock = time.Now
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = "ratelimit" + strconv.FormatUint(rateLimiters.Add(1), 10) + ":"
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			key := opts.Key(req)
+			if key == "" {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			if ok, wait := opts.Store.Take(opts.Prefix+key, opts.Rate, opts.Burst, opts.Clock()); !ok {
+				seconds := int((wait + time.Second - 1) / time.Second)
+				if seconds < 1 {
+					seconds = 1
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(seconds))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// rateLimiters numbers the RateLimit middlewares for their default prefix.
+var rateLimiters atomic.Uint64
+
+// HeaderKey returns a RateLimit key function which limits the requests by a
+// request header, e.g. an API key. Requests without the header are limited
+// by their ClientIP.
+func HeaderKey(name string) func(*http.Request) string {
+	return func(req *http.Request) string {
+		if value := req.Header.Get(name); value != "" {
+			return name + ":" + value
+		}
+		return ClientIP(req)
+	}
+}
@@ -0,0 +1,110 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limit := RateLimit(RateLimitOptions{Rate: 0.5, Burst: 2, Clock: func() time.Time { return now }})
+
+	r := Classic()
+	r.Get("/login", func(w http.ResponseWriter, req *http.Request) {}).Use(limit)
+
+	serve := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/login", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := serve("10.0.0.1:1234"); w.Code != http.StatusOK {
+			t.Errorf("Unexpected status within the burst (%d)", w.Code)
+		}
+	}
+
+	w := serve("10.0.0.1:1234")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "2" {
+		t.Errorf("Unexpected response over the limit (%d, %s)", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := serve("10.0.0.2:1234"); w.Code != http.StatusOK {
+		t.Errorf("Unexpected status of another client (%d)", w.Code)
+	}
+
+	now = now.Add(2 * time.Second)
+	if w := serve("10.0.0.1:1234"); w.Code != http.StatusOK {
+		t.Errorf("Unexpected status after the refill (%d)", w.Code)
+	}
+}
+
+func TestRateLimitHeaderKey(t *testing.T) {
+	r := Classic()
+	r.Get("/api", func(w http.ResponseWriter, req *http.Request) {}).Use(RateLimit(RateLimitOptions{Rate: 1, Key: HeaderKey("X-API-Key")}))
+
+	codes := []int{}
+	for _, key := range []string{"a", "a", "b", ""} {
+		req := httptest.NewRequest(http.MethodGet, "/api", nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		codes = append(codes, w.Code)
+	}
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests || codes[2] != http.StatusOK || codes[3] != http.StatusOK {
+		t.Errorf("Unexpected statuses (%v)", codes)
+	}
+}
+
+func TestRateLimitStoreSweep(t *testing.T) {
+	s := NewRateLimitStore().(*memoryRateLimitStore)
+	now := time.Now()
+
+	s.Take("a", 1, 1, now)
+	s.Take("b", 1, 1, now)
+	if len(s.buckets) != 2 {
+		t.Fatalf("Unexpected buckets (%d)", len(s.buckets))
+	}
+
+	s.Take("b", 1, 1, now.Add(2*sweepInterval))
+	if _, found := s.buckets["a"]; found || len(s.buckets) != 1 {
+		t.Errorf("Full bucket isn't dropped (%d)", len(s.buckets))
+	}
+
+	// the buckets are refilled with their own rate and burst
+	s.Take("slow", 0.001, 10, now)
+	s.Take("fast", 1000, 1, now.Add(3*sweepInterval))
+	if _, found := s.buckets["slow"]; !found {
+		t.Error("Bucket is refilled with the rate of another key")
+	}
+}
+
+func TestRateLimitSharedStore(t *testing.T) {
+	store := NewRateLimitStore()
+	strict := RateLimit(RateLimitOptions{Rate: 1, Store: store})
+	loose := RateLimit(RateLimitOptions{Rate: 10, Store: store})
+	shared := RateLimit(RateLimitOptions{Rate: 1, Store: store, Prefix: "login:"})
+
+	r := Classic()
+	r.Get("/strict", func(w http.ResponseWriter, req *http.Request) {}).Use(strict)
+	r.Get("/loose", func(w http.ResponseWriter, req *http.Request) {}).Use(loose)
+	r.Get("/a", func(w http.ResponseWriter, req *http.Request) {}).Use(shared)
+	r.Get("/b", func(w http.ResponseWriter, req *http.Request) {}).Use(shared)
+
+	codes := []int{}
+	for _, path := range []string{"/strict", "/loose", "/loose", "/a", "/b"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		codes = append(codes, w.Code)
+	}
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusOK || codes[3] != http.StatusOK || codes[4] != http.StatusTooManyRequests {
+		t.Errorf("Unexpected statuses (%v)", codes)
+	}
+}
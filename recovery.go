@@ -0,0 +1,64 @@
+package mux
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+// Logger logs the messages of the middlewares. It is implemented by
+// *log.Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Recovery returns a middleware which recovers from a panic of the wrapped
+// handler, so a single bad request can't crash the server. The panic and
+// its stack trace are logged to logger. If it is nil, they are logged at
+// slog.LevelError to the structured logger of the router (see Router.Log)
+// with the attributes of RouteAttrs.
+//
+// The response is written by panicHandler, which can read the recovered
+// value with Recovered. It defaults to a plain text 500 Internal Server
+// Error response.
+//
+// A panic with http.ErrAbortHandler is passed on, as it is used to abort a
+// response on purpose.
+func Recovery(logger Logger, panicHandler http.Handler) MiddlewareFunc {
+	if panicHandler == nil {
+		panicHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			defer func() {
+				rv := recover()
+				if rv == nil {
+					return
+				}
+
+				if rv == http.ErrAbortHandler {
+					panic(rv)
+				}
+
+				if logger != nil {
+					logger.Printf("mux: panic serving %s %s: %v\n%s", req.Method, req.URL.Path, rv, debug.Stack())
+				} else {
+					attrs := append(RouteAttrs(req), slog.Any("panic", rv), slog.String("stack", string(debug.Stack())))
+					requestLogger(req).LogAttrs(req.Context(), slog.LevelError, "mux: panic", attrs...)
+				}
+				panicHandler.ServeHTTP(w, contextSet(req, recoveredKey, rv))
+			}()
+
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// Recovered returns the value recovered by the Recovery middleware. It only
+// works when called inside the panic handler of the middleware.
+func Recovered(r *http.Request) interface{} {
+	return contextGet(r, recoveredKey)
+}
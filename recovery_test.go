@@ -0,0 +1,76 @@
+package mux
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecovery(t *testing.T) {
+	var buf bytes.Buffer
+
+	r := Classic()
+	r.Use(Recovery(log.New(&buf, "", 0), nil))
+	r.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if res.Code != http.StatusInternalServerError {
+		t.Errorf("Unexpected status code (%d)", res.Code)
+	}
+
+	if !strings.HasPrefix(buf.String(), "mux: panic serving GET /panic: boom\n") || !strings.Contains(buf.String(), "goroutine") {
+		t.Errorf("Unexpected log (%s)", buf.String())
+	}
+}
+
+func TestRecoveryPanicHandler(t *testing.T) {
+	panicHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, `{"error":%q}`, fmt.Sprint(Recovered(r)))
+	})
+
+	r := Classic()
+	r.Use(Recovery(log.New(&bytes.Buffer{}, "", 0), panicHandler))
+	r.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	r.Get("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if res.Code != http.StatusServiceUnavailable || res.Body.String() != `{"error":"boom"}` {
+		t.Errorf("Unexpected response (Code: %d, Body: %s)", res.Code, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/ok", nil))
+
+	if res.Code != http.StatusOK || res.Body.String() != "ok" {
+		t.Errorf("Unexpected response (Code: %d, Body: %s)", res.Code, res.Body.String())
+	}
+}
+
+func TestRecoveryAbortHandler(t *testing.T) {
+	handler := Recovery(log.New(&bytes.Buffer{}, "", 0), nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if rv := recover(); rv != http.ErrAbortHandler {
+			t.Errorf("Unexpected recovered value (%v)", rv)
+		}
+	}()
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -0,0 +1,113 @@
+package mux
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+// RequestIDOptions configures the RequestID middleware.
+type RequestIDOptions struct {
+	// Header is the request and response header of the ID, it defaults to
+	// X-Request-ID.
+	Header string
+	// Generator returns a new ID, it defaults to NewUUID.
+	Generator func() string
+	// IgnoreIncoming generates an ID for every request instead of using the
+	// ID of the request header, e.g. if the clients aren't trusted.
+	IgnoreIncoming bool
+}
+
+// RequestID returns a middleware which assigns an ID to every request, so
+// the log entries of a request can be correlated, e.g.:
+//
+//     r.Use(mux.RequestID(mux.RequestIDOptions{Generator: mux.NewULID}))
+//
+// The ID of the request header is used if it is valid (up to 128 printable
+// ASCII characters), otherwise a new one is generated. The ID is echoed in
+// the response header and handlers get it with GetRequestID.
+func RequestID(opts RequestIDOptions) MiddlewareFunc {
+	if opts.Header == "" {
+		opts.Header = "X-Request-ID"
+	}
+	if opts.Generator == nil {
+		opts.Generator = NewUUID
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			id := req.Header.Get(opts.Header)
+			if opts.IgnoreIncoming || !validRequestID(id) {
+				id = opts.Generator()
+			}
+
+			w.Header().Set(opts.Header, id)
+			next.ServeHTTP(w, contextSet(req, requestIDKey, id))
+		})
+	}
+}
+
+// GetRequestID returns the ID of the current request assigned by the
+// RequestID middleware, empty if there is none.
+func GetRequestID(r *http.Request) string {
+	if rv := contextGet(r, requestIDKey); rv != nil {
+		return rv.(string)
+	}
+	return ""
+}
+
+// validRequestID returns true if id is a non-empty string of up to 128
+// printable ASCII characters, so it can't inject anything into logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// NewUUID returns a random (version 4) UUID, e.g.
+// 9b2e4f0c-3a6d-4c1e-8f5a-2d7b9e0c4a13.
+func NewUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	var s [36]byte
+	hex.Encode(s[0:8], b[0:4])
+	hex.Encode(s[9:13], b[4:6])
+	hex.Encode(s[14:18], b[6:8])
+	hex.Encode(s[19:23], b[8:10])
+	hex.Encode(s[24:], b[10:])
+	s[8], s[13], s[18], s[23] = '-', '-', '-', '-'
+	return string(s[:])
+}
+
+// crockford is the Crockford base32 alphabet of ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a ULID, e.g. 01HZX3Q4J8K2M5N7P9R1S3T5V7. ULIDs sort by
+// their creation time in milliseconds.
+func NewULID() string {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(time.Now().UnixMilli())<<16)
+	rand.Read(b[6:])
+
+	// 26 characters of 5 bits encode the 128 bits with 2 leading zero bits
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+	var s [26]byte
+	for i := 25; i >= 0; i-- {
+		s[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(s[:])
+}
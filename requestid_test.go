@@ -0,0 +1,76 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestID(t *testing.T) {
+	r := Classic()
+	r.Use(RequestID(RequestIDOptions{}))
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(GetRequestID(req)))
+	})
+
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	tests := map[string]bool{
+		"":                       false,
+		"abc-123":                true,
+		"bad id":                 false,
+		strings.Repeat("x", 129): false,
+		"line\nbreak":            false,
+	}
+
+	for incoming, kept := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header["X-Request-Id"] = []string{incoming}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		id := w.Body.String()
+		if w.Header().Get("X-Request-ID") != id {
+			t.Errorf("Response header doesn't echo the ID (%s, %s)", w.Header().Get("X-Request-ID"), id)
+		}
+		if kept && id != incoming {
+			t.Errorf("Incoming ID isn't kept (%s)", id)
+		}
+		if !kept && !uuid.MatchString(id) {
+			t.Errorf("Unexpected generated ID for %q (%s)", incoming, id)
+		}
+	}
+}
+
+func TestRequestIDOptions(t *testing.T) {
+	r := Classic()
+	r.Use(RequestID(RequestIDOptions{Header: "X-Correlation-ID", Generator: func() string { return "generated" }, IgnoreIncoming: true}))
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(GetRequestID(req)))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Correlation-ID", "incoming")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Body.String() != "generated" || w.Header().Get("X-Correlation-ID") != "generated" {
+		t.Errorf("Unexpected ID (%s, %v)", w.Body.String(), w.Header())
+	}
+}
+
+func TestNewULID(t *testing.T) {
+	first := NewULID()
+	time.Sleep(2 * time.Millisecond)
+	second := NewULID()
+
+	ulid := regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+	if !ulid.MatchString(first) || !ulid.MatchString(second) {
+		t.Errorf("Unexpected ULIDs (%s, %s)", first, second)
+	}
+	if first[:10] >= second[:10] {
+		t.Errorf("ULIDs don't sort by time (%s, %s)", first, second)
+	}
+}
@@ -0,0 +1,220 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AttemptsHeader is the response header of a proxy with a retry policy which
+// holds the number of upstream requests made for the request.
+const AttemptsHeader = "X-Proxy-Attempts"
+
+// RetryPolicy configures the retries of a Proxy, e.g.:
+//
+//     p, _ := mux.NewProxy("http://search-1.internal", "http://search-2.internal")
+//     p.Retry = &mux.RetryPolicy{Attempts: 3, TryTimeout: 2 * time.Second, Budget: 0.2}
+//     r.Get("/search", p.ServeHTTP)
+//
+// Requests with an idempotent method (GET, HEAD, OPTIONS, TRACE, PUT and
+// DELETE) are retried if the upstream request fails, times out or answers
+// with one of the Statuses, against another upstream if there is one. Their
+// bodies are buffered to be sent again. A RetryPolicy mustn't be shared by
+// proxies whose budgets are separate.
+type RetryPolicy struct {
+	// Attempts is the maximum number of upstream requests per request
+	// including the first one, it defaults to 3.
+	Attempts int
+	// TryTimeout limits the time until the response headers of each upstream
+	// request, zero means no limit.
+	TryTimeout time.Duration
+	// Statuses are the upstream response statuses which are retried, they
+	// default to 502, 503 and 504.
+	Statuses []int
+	// Budget limits the retries to a share of the requests, e.g. 0.2 for
+	// 20%, so retries don't multiply the load of failing upstreams. Zero
+	// means no limit.
+	Budget float64
+	// MinRetries is the number of retries within a budget window (10
+	// seconds) allowed regardless of the budget, it defaults to 10.
+	MinRetries int
+
+	mu          sync.Mutex
+	windowStart time.Time
+	requests    int
+	retries     int
+}
+
+// budgetWindow is the period whose requests and retries are compared with
+// the budget.
+const budgetWindow = 10 * time.Second
+
+// errRetry aborts an upstream response which is retried.
+var errRetry = errors.New("mux: retrying upstream request")
+
+func (p *RetryPolicy) attempts() int {
+	if p.Attempts < 1 {
+		return 3
+	}
+	return p.Attempts
+}
+
+// retryable returns true if a response status is retried.
+func (p *RetryPolicy) retryable(code int) bool {
+	statuses := p.Statuses
+	if statuses == nil {
+		statuses = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+	}
+	for _, status := range statuses {
+		if code == status {
+			return true
+		}
+	}
+	return false
+}
+
+// deposit counts a request within the budget window.
+func (p *RetryPolicy) deposit() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.advance()
+	p.requests++
+}
+
+// withdraw returns true and counts the retry if the budget allows it.
+func (p *RetryPolicy) withdraw() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.advance()
+	minRetries := p.MinRetries
+	if minRetries <= 0 {
+		minRetries = 10
+	}
+	if p.Budget > 0 && p.retries >= minRetries && float64(p.retries) >= p.Budget*float64(p.requests) {
+		return false
+	}
+	p.retries++
+	return true
+}
+
+// advance starts a new budget window if the current one is over.
+func (p *RetryPolicy) advance() {
+	now := time.Now()
+	if now.Sub(p.windowStart) >= budgetWindow {
+		p.windowStart = now
+		p.requests, p.retries = 0, 0
+	}
+}
+
+// isIdempotent returns true for the methods whose requests may be repeated.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// serveRetries forwards an idempotent request until an upstream answers, the
+// attempts are exhausted or the budget is spent.
+func (p *Proxy) serveRetries(w http.ResponseWriter, req *http.Request) {
+	policy := p.Retry
+	policy.deposit()
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		req.Body.Close()
+	}
+
+	var tried []*Upstream
+	for attempt := 1; ; attempt++ {
+		upstream := p.next(req, tried...)
+		if upstream == nil {
+			http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+			return
+		}
+
+		try := req
+		if body != nil {
+			try = req.Clone(req.Context())
+			try.Body = io.NopCloser(bytes.NewReader(body))
+			try.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+		}
+
+		retry := func() bool {
+			return attempt < policy.attempts() && req.Context().Err() == nil && policy.withdraw()
+		}
+		if !p.serveAttempt(w, try, upstream, attempt, retry) {
+			return
+		}
+		tried = append(tried, upstream)
+	}
+}
+
+// serveAttempt forwards a request to an upstream and returns true if it is
+// to be retried, in which case nothing is written to w.
+func (p *Proxy) serveAttempt(w http.ResponseWriter, req *http.Request, upstream *Upstream, attempt int, retry func() bool) (retried bool) {
+	upstream.active.Add(1)
+	defer upstream.active.Add(-1)
+
+	// the try timeout ends with the response headers, not with the body
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+	var timer *time.Timer
+	if p.Retry.TryTimeout > 0 {
+		timer = time.AfterFunc(p.Retry.TryTimeout, cancel)
+		defer timer.Stop()
+	}
+
+	rp := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			p.rewrite(pr, upstream.URL)
+		},
+		Transport: p.Transport,
+		ModifyResponse: func(res *http.Response) error {
+			if timer != nil {
+				timer.Stop()
+			}
+			if p.Retry.retryable(res.StatusCode) && retry() {
+				res.Body.Close()
+				retried = true
+				return errRetry
+			}
+			res.Header.Set(AttemptsHeader, strconv.Itoa(attempt))
+			if p.ModifyResponse != nil {
+				return p.ModifyResponse(res)
+			}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, _ *http.Request, err error) {
+			if retried {
+				return
+			}
+			// the try timeout cancels only the context of the try
+			p.failUpstream(upstream, req)
+			if retry() {
+				retried = true
+				return
+			}
+			w.Header().Set(AttemptsHeader, strconv.Itoa(attempt))
+			p.handleError(w, req, err)
+		},
+	}
+	rp.ServeHTTP(w, req.WithContext(ctx))
+	return retried
+}
@@ -0,0 +1,132 @@
+package mux
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func statusUpstream(code int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, http.StatusText(code), code)
+	}))
+}
+
+func TestProxyRetry(t *testing.T) {
+	a, b := statusUpstream(http.StatusServiceUnavailable), namedUpstream("b")
+	defer a.Close()
+	defer b.Close()
+
+	p, err := NewProxy(a.URL, b.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Retry = &RetryPolicy{}
+
+	r := Classic()
+	r.Get("/", p.ServeHTTP)
+	r.Post("/", p.ServeHTTP)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "b" || w.Header().Get(AttemptsHeader) != "2" {
+		t.Errorf("Unexpected response (%d, %s, %s)", w.Code, w.Body.String(), w.Header().Get(AttemptsHeader))
+	}
+
+	// round robin selects a again, the POST isn't retried
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get(AttemptsHeader) != "" {
+		t.Errorf("Unexpected response (%d, %s)", w.Code, w.Header().Get(AttemptsHeader))
+	}
+}
+
+func TestProxyRetryBody(t *testing.T) {
+	a := statusUpstream(http.StatusBadGateway)
+	defer a.Close()
+	b := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		fmt.Fprintf(w, "%s %s", req.Method, body)
+	}))
+	defer b.Close()
+
+	p, err := NewProxy(a.URL, b.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Retry = &RetryPolicy{}
+
+	r := Classic()
+	r.Put("/", p.ServeHTTP)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/", strings.NewReader("payload")))
+	if w.Body.String() != "PUT payload" || w.Header().Get(AttemptsHeader) != "2" {
+		t.Errorf("Unexpected response (%s, %s)", w.Body.String(), w.Header().Get(AttemptsHeader))
+	}
+}
+
+func TestProxyRetryTimeout(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-release:
+		case <-req.Context().Done():
+		}
+	}))
+	defer slow.Close()
+	defer close(release)
+	fast := namedUpstream("fast")
+	defer fast.Close()
+
+	p, err := NewProxy(slow.URL, fast.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Retry = &RetryPolicy{TryTimeout: 50 * time.Millisecond}
+
+	r := Classic()
+	r.Get("/", p.ServeHTTP)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Body.String() != "fast" || w.Header().Get(AttemptsHeader) != "2" {
+		t.Errorf("Unexpected response (%s, %s)", w.Body.String(), w.Header().Get(AttemptsHeader))
+	}
+	if p.Upstreams[0].Healthy(time.Now()) {
+		t.Error("Timed out upstream is healthy")
+	}
+}
+
+func TestProxyRetryBudget(t *testing.T) {
+	a, b := statusUpstream(http.StatusServiceUnavailable), statusUpstream(http.StatusServiceUnavailable)
+	defer a.Close()
+	defer b.Close()
+
+	p, err := NewProxy(a.URL, b.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Retry = &RetryPolicy{Attempts: 2, Budget: 0.2, MinRetries: 1}
+
+	r := Classic()
+	r.Get("/", p.ServeHTTP)
+
+	attempts := []string{}
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("Unexpected status (%d)", w.Code)
+		}
+		attempts = append(attempts, w.Header().Get(AttemptsHeader))
+	}
+
+	if strings.Join(attempts, ",") != "2,1,1" {
+		t.Errorf("Unexpected attempts (%v)", attempts)
+	}
+}
@@ -3,11 +3,15 @@ package mux
 import (
 	"fmt"
 	"net/http"
+	"net/url"
+	"sort"
 	"strings"
+	"sync/atomic"
 )
 
 const (
-	kindNormalPath = iota
+	kindPrefixPath = iota - 1
+	kindNormalPath
 	kindVarsPath
 	kindRegexPath
 )
@@ -30,6 +34,38 @@ type RouteInterface interface {
 	GetMatchers() Matchers
 	Kind() int
 	Match(req *http.Request) RouteInterface
+	Methods(methods ...string) RouteInterface
+	GetMethods() []string
+	Host(host string) RouteInterface
+	Queries(pairs ...string) RouteInterface
+	HeadersPresent(keys ...string) RouteInterface
+	HeadersAbsent(keys ...string) RouteInterface
+	ContentTypes(types ...string) RouteInterface
+	Accepts(types ...string) RouteInterface
+	Languages(locales ...string) RouteInterface
+	RemoteIPs(cidrs ...string) RouteInterface
+	UserAgents(patterns ...string) RouteInterface
+	Cookies(pairs ...string) RouteInterface
+	Flag(name string, enabled func(*http.Request) bool) RouteInterface
+	During(windows ...TimeWindow) RouteInterface
+	APIKey(source string, lookup func(key string) bool) RouteInterface
+	ClientCerts(patterns ...string) RouteInterface
+	AddMatcher(m Matcher) RouteInterface
+	PathPrefix(prefix string) RouteInterface
+	Subrouter() *Router
+	Use(mws ...MiddlewareFunc) RouteInterface
+	GetMiddlewares() []MiddlewareFunc
+	MaxBytes(n int64) RouteInterface
+	GetRouter() *Router
+	Name(name string) RouteInterface
+	GetName() string
+	URL(pairs ...string) (*url.URL, error)
+	Schemes(schemes ...string) RouteInterface
+	WithMeta(key string, value interface{}) RouteInterface
+	GetMeta(key string) (interface{}, bool)
+	Disable() RouteInterface
+	Enable() RouteInterface
+	IsDisabled() bool
 }
 
 // Route stores information to match a request and build URLs.
@@ -46,10 +82,16 @@ type Route struct {
 	err error
 	// MethodName used to build proper error messages
 	methodName string
+	// registeredMethods the route is registered for
+	registeredMethods []string
+	// middlewares wrap the handler of the route
+	middlewares middlewares
 	// path used to build proper error messages
 	path string
-	// varIndexies used to extract vars
-	varIndexies map[string]int
+	// meta holds arbitrary metadata, e.g. the owner of the route
+	meta map[string]interface{}
+	// disabled routes are skipped during matching, see Disable
+	disabled atomic.Bool
 
 	router *Router
 }
@@ -57,16 +99,15 @@ type Route struct {
 // NewRoute returns a new route instance.
 func NewRoute(router *Router) RouteInterface {
 	return &Route{
-		router:      router,
-		ms:          Matchers([]Matcher{}),
-		varIndexies: map[string]int{},
+		router: router,
+		ms:     Matchers([]Matcher{}),
 	}
 }
 
 // Match matches the route against the request.
 // if match is successfully then return route else return nil
 func (r *Route) Match(req *http.Request) RouteInterface {
-	if r.err != nil {
+	if r.err != nil || r.disabled.Load() {
 		return nil
 	}
 
@@ -80,6 +121,30 @@ func (r *Route) Match(req *http.Request) RouteInterface {
 	return r
 }
 
+// Disable marks the route as disabled, e.g. as a kill switch for an
+// endpoint during an incident. A disabled route stays registered but is
+// skipped during matching, so the request is matched by the following routes
+// or not found. If the router of the route has DisabledUnavailable set, the
+// requests it would match get 503 Service Unavailable instead.
+//
+// Unlike the other route methods, Disable and Enable are safe while the
+// router serves requests.
+func (r *Route) Disable() RouteInterface {
+	r.disabled.Store(true)
+	return r
+}
+
+// Enable enables a disabled route again, see Disable.
+func (r *Route) Enable() RouteInterface {
+	r.disabled.Store(false)
+	return r
+}
+
+// IsDisabled returns true if the route is disabled, see Disable.
+func (r *Route) IsDisabled() bool {
+	return r.disabled.Load()
+}
+
 // HasHandler returns ture if route has a handler.
 func (r *Route) HasHandler() bool {
 	return r.handler != nil
@@ -97,6 +162,40 @@ func (r *Route) Handler(h http.Handler) {
 	}
 }
 
+// Use appends middlewares to the route. The middlewares of the route wrap
+// the handler after the middlewares of the router, e.g.:
+//
+//     r := mux.Classic()
+//     r.Use(loggingMiddleware)
+//     r.Get("/admin", adminHandler).Use(authMiddleware)
+//
+// calls loggingMiddleware, authMiddleware and then adminHandler.
+func (r *Route) Use(mws ...MiddlewareFunc) RouteInterface {
+	r.middlewares = append(r.middlewares, mws...)
+	return r
+}
+
+// GetMiddlewares returns the middlewares of the route.
+func (r *Route) GetMiddlewares() []MiddlewareFunc {
+	return r.middlewares
+}
+
+// MaxBytes limits the request bodies of the route to n bytes, larger
+// requests are answered with 413 Request Entity Too Large, e.g.:
+//
+//     r.Post("/uploads", uploadHandler).MaxBytes(32 << 20)
+//     r.Post("/api/orders", ordersHandler).MaxBytes(64 << 10)
+//
+// See the MaxBytes middleware.
+func (r *Route) MaxBytes(n int64) RouteInterface {
+	return r.Use(MaxBytes(n))
+}
+
+// GetRouter returns the router which created the route.
+func (r *Route) GetRouter() *Router {
+	return r.router
+}
+
 // HasError check if an error exists.
 func (r *Route) HasError() bool {
 	return r.err != nil
@@ -113,8 +212,21 @@ func (r *Route) SetError(err error) {
 }
 
 //SetMethodName set the method name for the route
+//The method is also recorded as registered method (see Route.GetMethods).
 func (r *Route) SetMethodName(m string) {
 	r.methodName = m
+
+	if m == "" {
+		return
+	}
+
+	for _, method := range r.registeredMethods {
+		if method == m {
+			return
+		}
+	}
+
+	r.registeredMethods = append(r.registeredMethods, m)
 }
 
 // GetMethodName get the method name for the route
@@ -134,7 +246,10 @@ func (r *Route) HandlerFunc(handler func(http.ResponseWriter, *http.Request)) Ro
 }
 
 // Name sets the name for the route, used to build URLs.
-func (r *Route) Name(name string) *Route {
+// The names are unique within a router and its subrouters, a name already
+// used by another route is an error.
+// See Router.URL().
+func (r *Route) Name(name string) RouteInterface {
 
 	if r.name != "" {
 		r.err = NewBadRouteError(r, fmt.Sprintf("route already has name %q, can't set %q", r.name, name))
@@ -143,6 +258,13 @@ func (r *Route) Name(name string) *Route {
 
 	if r.err == nil {
 		r.name = name
+
+		if r.router != nil {
+			if other := namedRoute(r.router.table(), r); other != nil {
+				r.name = ""
+				r.err = NewBadRouteError(r, fmt.Sprintf("name %q is already used by the route %s", name, other.GetPath()))
+			}
+		}
 	}
 
 	return r
@@ -153,8 +275,133 @@ func (r *Route) GetName() string {
 	return r.name
 }
 
-// addMatcher adds a matcher to the route.
-func (r *Route) addMatcher(m Matcher) RouteInterface {
+// WithMeta attaches metadata to the route, e.g. its owner, a required scope
+// or a SLO class. A middleware or handler can read it from the current route,
+// see RouteMeta:
+//
+//     r.Get("/orders", ordersHandler).WithMeta("scope", "orders:read")
+func (r *Route) WithMeta(key string, value interface{}) RouteInterface {
+	if r.meta == nil {
+		r.meta = map[string]interface{}{}
+	}
+	r.meta[key] = value
+	return r
+}
+
+// GetMeta returns the metadata of the route for key.
+func (r *Route) GetMeta(key string) (interface{}, bool) {
+	value, found := r.meta[key]
+	return value, found
+}
+
+// URL builds a URL for the route. It accepts a sequence of key/value pairs
+// for the variables of the path and host, the values are validated against
+// the pattern of their variable. Pairs which aren't variables are appended
+// as query string. For example:
+//
+//     r := mux.Classic()
+//     route := r.Get("/users/:id/posts/:slug", postHandler)
+//     url, err := route.URL("id", "42", "slug", "hello", "page", "2")
+//     // url.String() == "/users/42/posts/hello?page=2"
+//
+// If the route has a host matcher without wildcards, the URL is absolute
+// and its scheme is https if the route matches it (see Route.Schemes), http
+// otherwise. A URL can't be built for a regex path.
+func (r *Route) URL(pairs ...string) (*url.URL, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	values, err := convertStringsToMap(isEvenPairs, pairs...)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := r.buildPath(values)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &url.URL{
+		Path: path,
+	}
+
+	vars := map[string]struct{}{}
+	for _, m := range r.ms {
+		switch m := m.(type) {
+		case pathWithVarsMatcher:
+			for _, name := range m.template.names {
+				vars[name] = struct{}{}
+			}
+		case pathPrefixMatcher:
+			if m.template != nil {
+				for _, name := range m.template.names {
+					vars[name] = struct{}{}
+				}
+			}
+		case hostMatcher:
+			for _, name := range m.template.names {
+				vars[name] = struct{}{}
+			}
+
+			if m.template.hasWildcard() {
+				continue
+			}
+
+			if u.Host, err = m.template.build(values); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if u.Host != "" {
+		u.Scheme = "http"
+		for _, m := range r.ms {
+			if sm, ok := m.(schemeMatcher); ok {
+				if _, found := sm["https"]; found {
+					u.Scheme = "https"
+				}
+			}
+		}
+	}
+
+	query := url.Values{}
+	for i := 0; i < len(pairs); i += 2 {
+		if _, found := vars[pairs[i]]; !found {
+			query.Add(pairs[i], pairs[i+1])
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u, nil
+}
+
+// buildPath builds the path of the route out of values.
+func (r *Route) buildPath(values map[string]string) (string, error) {
+	for _, m := range r.ms {
+		switch m := m.(type) {
+		case pathMatcher:
+			return r.path, nil
+		case pathWithVarsMatcher:
+			return m.template.build(values)
+		case pathPrefixMatcher:
+			if m.template == nil {
+				return r.path, nil
+			}
+			return m.template.build(values)
+		case pathRegexMatcher:
+			return "", fmt.Errorf("mux: can't build a URL for the regex path %q", r.path)
+		}
+	}
+
+	return "", fmt.Errorf("mux: route has no path")
+}
+
+// AddMatcher adds a matcher to the route.
+func (r *Route) AddMatcher(m Matcher) RouteInterface {
+	if err := matcherError(m); err != nil && r.err == nil {
+		r.err = NewBadRouteError(r, err.Error())
+	}
 	if r.err == nil {
 		r.ms = append(r.ms, m)
 	}
@@ -162,76 +409,189 @@ func (r *Route) addMatcher(m Matcher) RouteInterface {
 }
 
 // Path adds a matcher for the URL path.
-// It accepts a path with zero variables. The
+// It accepts a path with zero or more variables. The
 // template must start with a "/".
 // For example:
 //
 //     r := mux.Classic()
 //     r.Path("/billing/").Handler(BillingHandler)
 //     r.Path("/user/:number/comment/:string").Handler(commentHandler)
+//     r.Path("/users/:id/posts/:slug").Handler(postHandler)
+//     r.Path("/articles/{id:[0-9a-f]{8}}").Handler(articleHandler)
+//     r.Path("/reports/:year/:month?").Handler(reportHandler)
+//     r.Path("/static/*filepath").Handler(staticHandler)
 //     r.Path("/article/#([a-z]{,10})").Handler(articleHandler)
 //
+// Variables of the built-in types (:number, :string) are stored under
+// their type (":number"), named variables (:id, {id:[0-9]+}) under their
+// name ("id"). A variable followed by a "?" makes its segment optional, a
+// missing optional variable is not set. A catch-all variable (*filepath)
+// matches the rest of the path and is only allowed at the end of the
+// template. The pattern of a variable is compiled once, errors are stored on
+// the route.
 func (r *Route) Path(path string) RouteInterface {
 
 	if r.path != "" {
 		r.err = NewBadRouteError(r, fmt.Sprintf("route already has path can't set a new path %v", path))
 	}
 
+	path = r.withPrefix(path)
+	r.path = path
+
 	var matcher Matcher
 	switch {
 	case containsRegex(path):
-		matcher = newPathRegexMatcher(path)
-		r.extractVarsIndexies("#", path, "var")
+		m, err := newPathRegexMatcher(path)
+		if err != nil {
+			r.err = NewBadRouteError(r, err.Error())
+		} else if r.ignoresCase() {
+			m.regex = ignoreCase(m.regex)
+		}
+		matcher = m
 		r.kind = kindRegexPath
 	case containsVars(path):
-		matcher = newPathWithVarsMatcher(path)
-		r.extractVarsIndexies(":", path, "")
+		m, err := newPathWithVarsMatcher(path, r.paramTypes())
+		if err != nil {
+			r.err = NewBadRouteError(r, err.Error())
+		} else if r.ignoresCase() {
+			m.template.ignoreCase()
+		}
+		matcher = m
 		r.kind = kindVarsPath
 	default:
+		if r.ignoresCase() {
+			path = strings.ToLower(path)
+		}
 		matcher = pathMatcher(path)
 		r.kind = kindNormalPath
 	}
 
-	r.path = path
-	r.addMatcher(matcher)
+	r.AddMatcher(matcher)
 
 	return r
 }
 
-//GetPath returns the handler for the route.
-func (r *Route) GetPath() string {
-	return r.path
+// PathPrefix adds a matcher for the URL path prefix. The route matches the
+// prefix itself and all paths below it, e.g. the prefix "/api" matches
+// "/api" and "/api/user" but not "/apiv2". The prefix may contain variables.
+//
+// For example:
+//
+//     r := mux.Classic()
+//     api := r.PathPrefix("/api/v1").Subrouter()
+//     api.Get("/users", usersHandler)
+//
+func (r *Route) PathPrefix(prefix string) RouteInterface {
+
+	if r.path != "" {
+		r.err = NewBadRouteError(r, fmt.Sprintf("route already has path can't set a new path prefix %v", prefix))
+	}
+
+	prefix = r.withPrefix(prefix)
+	r.path = prefix
+	r.kind = kindPrefixPath
+
+	matcher, err := newPathPrefixMatcher(prefix, r.paramTypes())
+	if err != nil {
+		r.err = NewBadRouteError(r, err.Error())
+	} else if r.ignoresCase() {
+		matcher.prefix = strings.ToLower(matcher.prefix)
+		if matcher.template != nil {
+			matcher.template.ignoreCase()
+		}
+	}
+
+	return r.AddMatcher(matcher)
 }
 
-func (r *Route) extractVarsIndexies(prefix string, path string, name string) {
+// canonicalPath returns the path of a request matched by the route with the
+// literals in the case of the route path. Only static paths and paths with
+// vars have a canonical path.
+func (r *Route) canonicalPath(req *http.Request) (string, bool) {
+	for _, m := range r.ms {
+		switch m := m.(type) {
+		case pathMatcher:
+			return r.path, true
+		case pathWithVarsMatcher:
+			p := params{}
+			m.template.extractVars(req.URL.Path, &p)
+
+			path, err := m.template.build(p.vars())
+			return path, err == nil
+		}
+	}
 
-	urlSeg := strings.Split(path, "/")
+	return "", false
+}
 
-	indexies := map[string]int{}
-	var count int
-	for k, v := range urlSeg {
-		if strings.HasPrefix(v, prefix) {
+// paramTypes returns the variable types of the router of the route.
+func (r *Route) paramTypes() paramTypes {
+	if r.router == nil {
+		return nil
+	}
+	return r.router.paramTypes
+}
 
-			if name != "" {
-				v = name
-			}
+// ignoresCase returns true if the path of the route is matched regardless
+// of its case, see Router.CaseSensitiveURL.
+func (r *Route) ignoresCase() bool {
+	return r.router != nil && !r.router.CaseSensitiveURL
+}
 
-			if _, found := indexies[v]; !found {
-				indexies[v] = k
-				continue
-			}
+// withPrefix prepends the path prefix of the router to path.
+func (r *Route) withPrefix(path string) string {
+	if r.router == nil || r.router.prefix == "" || !strings.HasPrefix(path, "/") {
+		return path
+	}
 
-			count++
-			indexies[v+string(count)] = k
+	return strings.TrimSuffix(r.router.prefix, "/") + path
+}
+
+// Subrouter returns a router for the route. Routes registered on the
+// subrouter share the path prefix (see Route.PathPrefix) and all other
+// matchers of the route, the subrouter inherits the configuration and the
+// middlewares of its router (see Router.Use).
+//
+// For example:
+//
+//     r := mux.Classic()
+//     api := r.PathPrefix("/api").HeadersPresent("X-API-Version").Subrouter()
+//     // matches /api/users with the header X-API-Version
+//     api.Get("/users", usersHandler)
+//
+func (r *Route) Subrouter() *Router {
+	matchers := Matchers{}
+
+	for _, m := range r.ms {
+		switch m.(type) {
+		case pathMatcher, pathPrefixMatcher, pathWithVarsMatcher, pathRegexMatcher:
+			// the prefix is added to the paths of the subrouter
+		default:
+			matchers = append(matchers, m)
 		}
 	}
 
-	r.varIndexies = indexies
+	prefix := ""
+	if r.kind == kindPrefixPath {
+		prefix = r.path
+	}
+
+	return r.router.newSubrouter(prefix, matchers)
+}
+
+//GetPath returns the handler for the route.
+func (r *Route) GetPath() string {
+	return r.path
 }
 
 //HasVars check if path has any vars
 func (r *Route) HasVars() bool {
-	return len(r.varIndexies) != 0
+	for _, m := range r.ms {
+		if _, ok := m.(varsMatcher); ok {
+			return true
+		}
+	}
+	return false
 }
 
 type Vars map[string]string
@@ -249,24 +609,96 @@ func (v Vars) GetAll() map[string]string {
 	return v
 }
 
-//ExtractVars extract all vars of the current path
+//ExtractVars extract all vars of the current request
 func (r *Route) ExtractVars(req *http.Request) Vars {
+	p := acquireParams()
+	defer releaseParams(p)
 
-	urlSeg := strings.Split(req.URL.Path, "/")
+	r.extractParams(req, p)
 
-	vars := Vars(map[string]string{})
+	return p.vars()
+}
 
-	for k, v := range r.varIndexies {
-		vars[k] = urlSeg[v]
+// extractParams writes the vars of a request matched by the route to p.
+func (r *Route) extractParams(req *http.Request, p *params) {
+	for _, m := range r.ms {
+		if vm, ok := m.(varsMatcher); ok {
+			vm.extractVars(req, p)
+		}
 	}
+}
 
-	return vars
+// Methods adds a matcher for HTTP methods.
+// It accepts a sequence of one or more methods to be matched, e.g.:
+// "GET", "POST", "PUT".
+//
+// For example:
+//
+//     r := mux.Classic()
+//     r.Register(r.NewRoute().Path("/user").Methods("GET", "POST").HandlerFunc(userHandler))
+//
+func (r *Route) Methods(methods ...string) RouteInterface {
+	return r.AddMatcher(newMethodMatcher(methods...))
+}
+
+// GetMethods returns the methods the route is registered for and the
+// methods added with Route.Methods in sorted order.
+func (r *Route) GetMethods() []string {
+	set := map[string]struct{}{}
+
+	for _, method := range r.registeredMethods {
+		set[method] = struct{}{}
+	}
+
+	for _, m := range r.ms {
+		if mm, ok := m.(methodMatcher); ok {
+			for method := range mm {
+				set[method] = struct{}{}
+			}
+		}
+	}
+
+	methods := make([]string, 0, len(set))
+	for method := range set {
+		methods = append(methods, method)
+	}
+
+	sort.Strings(methods)
+
+	return methods
+}
+
+// Host adds a matcher for the host of the request. The port is ignored and
+// a "*" label matches any single label. Variables ({tenant} or
+// {tenant:[a-z]+}) are available alongside the path variables.
+//
+// For example:
+//
+//     r := mux.Classic()
+//     r.Get("/", homeHandler).Host("www.example.com")
+//     r.Get("/", anyHandler).Host("*.example.com")
+//     r.Get("/", tenantHandler).Host("{tenant}.example.com")
+//
+func (r *Route) Host(host string) RouteInterface {
+	if r.err != nil {
+		return r
+	}
+
+	matcher, err := newHostMatcher(host)
+
+	if err != nil {
+		r.err = NewBadRouteError(r, err.Error())
+	}
+
+	return r.AddMatcher(matcher)
 }
 
 // Schemes adds a matcher for URL schemes.
 // It accepts a sequence of schemes to be matched, e.g.: "http", "https".
+// Requests over TLS are https, behind a proxy see
+// Router.TrustForwardedProto.
 func (r *Route) Schemes(schemes ...string) RouteInterface {
-	return r.addMatcher(newSchemeMatcher(schemes...))
+	return r.AddMatcher(newSchemeMatcher(schemes...))
 }
 
 // Headers adds a matcher for request header values.
@@ -298,7 +730,7 @@ func (r *Route) Headers(pairs ...string) RouteInterface {
 		r.err = err
 	}
 
-	r.addMatcher(matcher)
+	r.AddMatcher(matcher)
 
 	return r
 }
@@ -332,14 +764,285 @@ func (r *Route) HeadersRegex(pairs ...string) RouteInterface {
 		r.err = err
 	}
 
-	r.addMatcher(matcher)
+	r.AddMatcher(matcher)
 
 	return r
 }
 
+// Queries adds a matcher for URL query values.
+// It accepts a sequence of key/value pairs to be matched. For example:
+//
+//     r := mux.Classic()
+//     r.Get("/articles", jsonHandler).Queries("format", "json")
+//     r.Get("/articles", pageHandler).Queries("page", "")
+//     r.Get("/articles", pageHandler).Queries("page", "#^[0-9]+$")
+//
+// The first route only matches if the query value format is json. The second
+// route matches any value if the key page is set. The third route matches if
+// the value matches the regex, the matched value can be retrieved calling
+// mux.Param(req, "page").
+func (r *Route) Queries(pairs ...string) RouteInterface {
+	if r.err != nil {
+		return r
+	}
+
+	matcher, err := newQueryMatcher(pairs...)
+
+	if err != nil {
+		r.err = NewBadRouteError(r, err.Error())
+	}
+
+	return r.AddMatcher(matcher)
+}
+
+// HeadersPresent adds a matcher which requires the header keys to be set,
+// the values are not checked. For example:
+//
+//     r := mux.Classic()
+//     r.Get("/profile", userHandler).HeadersPresent("Authorization")
+//
+func (r *Route) HeadersPresent(keys ...string) RouteInterface {
+	return r.AddMatcher(newHeaderPresentMatcher(keys...))
+}
+
+// HeadersAbsent adds a matcher which requires the header keys to be unset.
+// For example:
+//
+//     r := mux.Classic()
+//     r.Get("/profile", anonymousHandler).HeadersAbsent("Authorization")
+//
+func (r *Route) HeadersAbsent(keys ...string) RouteInterface {
+	return r.AddMatcher(newHeaderAbsentMatcher(keys...))
+}
+
+// ContentTypes adds a matcher for the media type of the Content-Type
+// header, so the same path can dispatch different bodies to different
+// handlers. Parameters like the charset are ignored and "type/*" matches
+// any subtype. For example:
+//
+//     r := mux.Classic()
+//     r.Post("/users", createUserJSON).ContentTypes("application/json")
+//     r.Post("/users", createUserForm).ContentTypes("application/x-www-form-urlencoded", "multipart/form-data")
+//
+func (r *Route) ContentTypes(types ...string) RouteInterface {
+	if r.err != nil {
+		return r
+	}
+
+	matcher, err := newContentTypeMatcher(types...)
+
+	if err != nil {
+		r.err = NewBadRouteError(r, err.Error())
+		return r
+	}
+
+	return r.AddMatcher(matcher)
+}
+
+// Accepts adds a matcher for the Accept header. The routes of a path are
+// negotiated with each other: a route matches if the client prefers one of
+// its media types over the media types of the other routes registered for
+// the method and path, according to the quality values and wildcards of the
+// header (RFC 7231). A request without an Accept header matches the first
+// route. For example:
+//
+//     r := mux.Classic()
+//     r.Get("/users", usersJSON).Accepts("application/json")
+//     r.Get("/users", usersHTML).Accepts("text/html")
+//
+// A request with "Accept: text/html, application/json;q=0.9" is served by
+// usersHTML.
+func (r *Route) Accepts(types ...string) RouteInterface {
+	return r.AddMatcher(newNegotiationMatcher(r, "Accept", mediaRangeSpecificity, types...))
+}
+
+// Languages adds a matcher for the Accept-Language header. Like Accepts the
+// routes of a path are negotiated with each other, a route matches if the
+// client prefers one of its locales. Use the Locales middleware to expose
+// the locale to the handler. For example:
+//
+//     r := mux.Classic()
+//     r.Get("/help", helpGerman).Languages("de")
+//     r.Get("/help", helpEnglish).Languages("en", "en-US", "en-GB")
+//
+func (r *Route) Languages(locales ...string) RouteInterface {
+	return r.AddMatcher(newNegotiationMatcher(r, "Accept-Language", languageRangeSpecificity, locales...))
+}
+
+// RemoteIPs adds a matcher for the IP of the client. It accepts CIDRs and
+// single IPs. Behind a proxy the IP of the client is only known if the
+// proxy is trusted, see Router.SetTrustedProxies. For example:
+//
+//     r := mux.Classic()
+//     internal := r.PathPrefix("/internal").RemoteIPs("10.0.0.0/8", "::1").Subrouter()
+//
+func (r *Route) RemoteIPs(cidrs ...string) RouteInterface {
+	if r.err != nil {
+		return r
+	}
+
+	matcher, err := newRemoteIPMatcher(cidrs...)
+
+	if err != nil {
+		r.err = NewBadRouteError(r, err.Error())
+		return r
+	}
+
+	return r.AddMatcher(matcher)
+}
+
+// UserAgents adds a matcher for the User-Agent header. It matches if the
+// header contains one of the strings, regardless of their case, or matches
+// one of the patterns starting with a "#", which are regexes. For example:
+//
+//     r := mux.Classic()
+//     r.Get("/", botHandler).UserAgents("googlebot", "bingbot")
+//     r.Get("/", legacyAppHandler).UserAgents("#^ShopApp/1\\.[0-4]\\.")
+//
+func (r *Route) UserAgents(patterns ...string) RouteInterface {
+	if r.err != nil {
+		return r
+	}
+
+	matcher, err := newUserAgentMatcher(patterns...)
+
+	if err != nil {
+		r.err = NewBadRouteError(r, err.Error())
+		return r
+	}
+
+	return r.AddMatcher(matcher)
+}
+
+// Cookies adds a matcher for cookie values.
+// It accepts a sequence of name/value pairs to be matched. An empty value
+// only requires the cookie to be set and a value starting with a "#" is a
+// regex. For example:
+//
+//     r := mux.Classic()
+//     r.Get("/", dashboardHandler).Cookies("session", "")
+//     r.Get("/checkout", newCheckoutHandler).Cookies("cohort", "#^(b|c)$")
+//
+func (r *Route) Cookies(pairs ...string) RouteInterface {
+	if r.err != nil {
+		return r
+	}
+
+	matcher, err := newCookieMatcher(pairs...)
+
+	if err != nil {
+		r.err = NewBadRouteError(r, err.Error())
+		return r
+	}
+
+	return r.AddMatcher(matcher)
+}
+
+// Flag adds a matcher which delegates to the evaluation of a feature flag,
+// e.g. to expose an endpoint to the users of a beta:
+//
+//     r.Get("/search", newSearchHandler).Flag("new-search", flags.IsEnabled("new-search"))
+//
+// Like a MatcherFunc it has RankCustom, but the name of the flag is shown by
+// DebugHandler and in traces, e.g. "GET /search: rejected by flag(new-search)".
+func (r *Route) Flag(name string, enabled func(*http.Request) bool) RouteInterface {
+	if r.err != nil {
+		return r
+	}
+
+	matcher, err := newFlagMatcher(name, enabled)
+	if err != nil {
+		r.err = NewBadRouteError(r, err.Error())
+		return r
+	}
+
+	return r.AddMatcher(matcher)
+}
+
+// During adds a matcher which only matches during the time windows, e.g. to
+// serve a maintenance page during a scheduled window. Registered before the
+// regular route, it takes over at the start of the window and the regular
+// route is matched again after its end:
+//
+//     window := mux.TimeWindow{Start: start, End: start.Add(2 * time.Hour)}
+//     r.Get("/checkout", maintenanceHandler).During(window)
+//     r.Get("/checkout", checkoutHandler)
+//
+// The current time is taken from the Clock of the router.
+func (r *Route) During(windows ...TimeWindow) RouteInterface {
+	if r.err != nil {
+		return r
+	}
+
+	matcher, err := newTimeWindowMatcher(r, windows...)
+
+	if err != nil {
+		r.err = NewBadRouteError(r, err.Error())
+		return r
+	}
+
+	return r.AddMatcher(matcher)
+}
+
+// APIKey adds a matcher for an API key from a header or a query parameter,
+// source is header:<name> or query:<name>. It matches if lookup accepts the
+// key, e.g.:
+//
+//     r.Get("/reports", reportsHandler).APIKey("header:X-API-Key", keys.Valid)
+//     r.Get("/feeds/:id", feedHandler).APIKey("query:api_key", keys.Valid)
+//
+// Requests without a valid key don't match the route, use the APIKeyAuth
+// middleware to answer them with 401 Unauthorized instead. lookup should
+// compare the keys in constant time, e.g. with SecureCompare.
+func (r *Route) APIKey(source string, lookup func(key string) bool) RouteInterface {
+	if r.err != nil {
+		return r
+	}
+
+	matcher, err := newAPIKeyMatcher(source, lookup)
+
+	if err != nil {
+		r.err = NewBadRouteError(r, err.Error())
+		return r
+	}
+
+	return r.AddMatcher(matcher)
+}
+
+// ClientCerts adds a matcher for the TLS client certificate (mTLS). It
+// matches the subject common name and the DNS names of the certificate
+// against the patterns: a pattern starting with "*." matches a single label,
+// e.g. "*.ops.example.com", and a pattern starting with "#" is a regex. The
+// other subject alternative names are only matched by typed patterns, which
+// compare them exactly: "email:<address>", "uri:<uri>" and "ip:<address>". A
+// pattern "sha256:<hex>" matches the SHA-256 fingerprint of the certificate
+// instead, colons between the hex bytes are allowed. For example:
+//
+//     r.Get("/admin", adminHandler).ClientCerts("*.ops.example.com", "uri:spiffe://example.com/admin")
+//     r.Post("/deploy", deployHandler).ClientCerts("sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08")
+//
+// The names are only matched if the server verified the certificate chain
+// (see tls.Config.ClientAuth), a pinned fingerprint is matched regardless.
+// Requests without a certificate don't match, so public routes can share
+// the server with mTLS routes using tls.VerifyClientCertIfGiven.
+func (r *Route) ClientCerts(patterns ...string) RouteInterface {
+	if r.err != nil {
+		return r
+	}
+
+	matcher, err := newClientCertMatcher(patterns...)
+
+	if err != nil {
+		r.err = NewBadRouteError(r, err.Error())
+		return r
+	}
+
+	return r.AddMatcher(matcher)
+}
+
 // MatcherFunc adds a custom function to be used as request matcher.
 func (r *Route) MatcherFunc(f MatcherFunc) RouteInterface {
-	return r.addMatcher(f)
+	return r.AddMatcher(f)
 }
 
 //Kind returns kind of route
@@ -2,9 +2,12 @@ package mux
 
 import (
 	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"sort"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewBadRouteError(t *testing.T) {
@@ -27,10 +30,185 @@ func TestSortMatchers(t *testing.T) {
 	mf := MatcherFunc(matcherFunc)
 
 	ms := Matchers([]Matcher{})
-	ms = append(ms, newSchemeMatcher("https"), pathMatcher("/api/"), mf)
+	ms = append(ms, newSchemeMatcher("https"), pathMatcher("/api/"), mf, newMethodMatcher(http.MethodGet))
 	sort.Sort(ms)
 
-	if ms[0].Rank() != rankAny || ms[1].Rank() != rankPath || ms[2].Rank() != rankScheme {
-		t.Errorf("Unexpected ranking (Index 0: %d, Index 1: %d, Index 2: %d)", ms[0].Rank(), ms[1].Rank(), ms[2].Rank())
+	if ms[0].Rank() != RankMethod || ms[1].Rank() != RankCustom || ms[2].Rank() != RankPath || ms[3].Rank() != RankScheme {
+		t.Errorf("Unexpected ranking (Index 0: %d, Index 1: %d, Index 2: %d, Index 3: %d)", ms[0].Rank(), ms[1].Rank(), ms[2].Rank(), ms[3].Rank())
+	}
+}
+
+func TestSortCustomRanks(t *testing.T) {
+	early := WithRank(MatcherFunc(func(*http.Request) bool { return true }), RankHost+1)
+	late := WithRank(MatcherFunc(func(*http.Request) bool { return true }), RankScheme+1)
+
+	ms := Matchers{late, newHeaderPresentMatcher("X-Debug"), Host("example.com"), early}
+	sort.Sort(ms)
+
+	ranks := []Rank{}
+	for _, m := range ms {
+		ranks = append(ranks, m.Rank())
+	}
+
+	if !reflect.DeepEqual(ranks, []Rank{RankHost, RankHost + 1, RankHeader, RankScheme + 1}) {
+		t.Errorf("Unexpected ranking (%v)", ranks)
+	}
+
+	if !early.Match(&http.Request{}) {
+		t.Error("Unexpected mismatch")
+	}
+}
+
+func TestSortMatchersByCost(t *testing.T) {
+	custom := MatcherFunc(func(*http.Request) bool { return true })
+
+	ms := Matchers{WithRank(custom, RankHeader), HeadersRegex("X-Version", "^2"), Headers("X-Client", "app"), HeadersPresent("X-Debug")}
+	sort.Sort(ms)
+
+	costs := []Cost{}
+	for _, m := range ms {
+		costs = append(costs, costOf(m))
+	}
+
+	if !reflect.DeepEqual(costs, []Cost{CostLookup, CostCompare, CostRegex, CostCustom}) {
+		t.Errorf("Unexpected costs (%v)", costs)
+	}
+}
+
+func TestMatcherCosts(t *testing.T) {
+	tests := []struct {
+		matcher Matcher
+		cost    Cost
+	}{
+		{Methods(http.MethodGet), CostLookup},
+		{Queries("page", ""), CostCompare},
+		{Queries("page", "#^[0-9]+$"), CostRegex},
+		{UserAgents("bot"), CostCompare},
+		{UserAgents("bot", "#^curl/"), CostRegex},
+		{Not(Host("example.com")), CostRegex},
+		{AnyOf(HeadersPresent("X-Debug"), Cookies("cohort", "b")), CostCompare},
+		{AnyOf(), CostLookup},
+		{pathMatcher("/users"), CostCompare},
+	}
+
+	for _, test := range tests {
+		if cost := costOf(test.matcher); cost != test.cost {
+			t.Errorf("Expected cost %d for %#v, got %d", test.cost, test.matcher, cost)
+		}
+	}
+}
+
+func TestPathWithInvalidVarPattern(t *testing.T) {
+	r := Classic()
+	route := r.Get("/articles/{id:[0-9}", func(w http.ResponseWriter, r *http.Request) {})
+
+	if err := route.GetError(); err == nil || !strings.Contains(err.Error(), "pattern of variable") {
+		t.Errorf("Unexpected error (%v)", err)
+	}
+}
+
+func TestGetMethods(t *testing.T) {
+	r := Classic()
+	route := r.NewRoute().Methods(http.MethodPost, "get").Methods(http.MethodPut)
+
+	if methods := route.GetMethods(); !reflect.DeepEqual(methods, []string{http.MethodGet, http.MethodPost, http.MethodPut}) {
+		t.Errorf("Unexpected methods (%v)", methods)
+	}
+}
+
+func TestNameTwice(t *testing.T) {
+	r := Classic()
+	route := r.NewRoute().Name("users").Name("accounts")
+
+	if !route.HasError() || route.GetName() != "users" {
+		t.Errorf("Unexpected route name (%s)", route.GetName())
+	}
+}
+
+func TestNameDuplicate(t *testing.T) {
+	r := Classic()
+	r.Get("/users", nil).Name("users")
+
+	route := r.Get("/accounts", nil).Name("users")
+	if !route.HasError() || route.GetName() != "" {
+		t.Errorf("Unexpected route name (%s)", route.GetName())
+	}
+
+	// named before the registration
+	route = r.NewRoute().Name("members").Path("/members").Methods(http.MethodGet)
+	r.Get("/people", nil).Name("members")
+	if r.Register(route); !route.HasError() {
+		t.Error("Duplicate name wasn't rejected")
+	}
+
+	if u, err := r.URL("users"); err != nil || u.String() != "/users" {
+		t.Errorf("Unexpected URL (%v, %v)", u, err)
+	}
+}
+
+func TestWithMeta(t *testing.T) {
+	r := Classic()
+	route := r.NewRoute().WithMeta("owner", "billing").WithMeta("owner", "payments")
+
+	if value, found := route.GetMeta("owner"); !found || value != "payments" {
+		t.Errorf("Unexpected meta (%v)", value)
+	}
+
+	if _, found := route.GetMeta("missing"); found {
+		t.Error("Unexpected found meta")
+	}
+}
+
+func TestDuring(t *testing.T) {
+	start := time.Date(2024, time.March, 2, 22, 0, 0, 0, time.UTC)
+	now := start.Add(-time.Minute)
+
+	r := Classic()
+	r.Clock = func() time.Time {
+		return now
+	}
+	r.Get("/checkout", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("maintenance"))
+	}).During(TimeWindow{Start: start, End: start.Add(2 * time.Hour)})
+	r.Get("/checkout", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("checkout"))
+	})
+
+	if ok, errs := r.HasErrors(); ok {
+		t.Fatalf("Unexpected errors (%v)", errs)
+	}
+
+	tests := []struct {
+		now  time.Time
+		body string
+	}{
+		{start.Add(-time.Minute), "checkout"},
+		{start, "maintenance"},
+		{start.Add(time.Hour), "maintenance"},
+		{start.Add(2 * time.Hour), "checkout"},
+	}
+
+	for _, test := range tests {
+		now = test.now
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/checkout", nil))
+		if w.Body.String() != test.body {
+			t.Errorf("Expected body %q at %s, got %q", test.body, test.now, w.Body.String())
+		}
+	}
+}
+
+func TestDuringFail(t *testing.T) {
+	start := time.Date(2024, time.March, 2, 22, 0, 0, 0, time.UTC)
+
+	r := Classic()
+	route := r.Get("/checkout", nil).During(TimeWindow{Start: start, End: start})
+
+	if err := route.GetError(); err == nil || !strings.HasSuffix(err.Error(), "Error: mux: time window 2024-03-02T22:00:00Z - 2024-03-02T22:00:00Z doesn't end after its start") {
+		t.Errorf("Unexpected error (%v)", err)
+	}
+
+	if err := matcherError(During()); err == nil {
+		t.Error("Expected an error without windows")
 	}
 }
@@ -1,16 +1,26 @@
 package mux
 
 import (
+	"fmt"
+	"net"
 	"net/http"
+	"net/url"
 	"path"
+	"reflect"
+	"regexp/syntax"
 	"sort"
 	"strings"
+	"time"
 )
 
 // NewRouter returns a new router instance.
 func NewRouter() *Router {
 	return &Router{
-		routes: map[string]routes{},
+		store: newRouteStore(nil),
+		paramTypes: paramTypes{
+			"number": varTypes["number"],
+			"string": varTypes["string"],
+		},
 		Validatoren: map[string]Validator{
 			"method": newMethodValidator(),
 			"path":   newPathValidator(),
@@ -31,22 +41,129 @@ func NewRouter() *Router {
 //
 // This will send all incoming requests to the router.
 type Router struct {
-	// Configurable Handler to be used when no route matches.
+	// NotFoundHandler is called when no route matches and for matched routes
+	// without a handler, e.g. to write a branded page or a JSON error. It
+	// defaults to http.NotFoundHandler. The middlewares only wrap it for
+	// matched routes.
 	NotFoundHandler http.Handler
-	// Routes to be matched, in order.
-	routes map[string]routes
-	// This defines the flag for new routes.
+	// MethodNotAllowedHandler is called when no route matches but routes for
+	// other methods match the path. The Allow header of the response is set
+	// to these methods before it is called. It defaults to a plain text 405
+	// Method Not Allowed response.
+	MethodNotAllowedHandler http.Handler
+	// store holds the routes to be matched, it is shared with the
+	// subrouters.
+	store *routeStore
+	// paramTypes holds the variable types, see RegisterParamType.
+	paramTypes paramTypes
+	// trustedProxies holds the networks of the trusted proxies, see
+	// SetTrustedProxies.
+	trustedProxies []*net.IPNet
+	// StrictSlash redirects a request without a matching route to the path
+	// with the trailing slash added or removed, if a route of this router
+	// matches that path. GET and HEAD requests are redirected with 301,
+	// others with 308 to keep the method and body. A subrouter inherits the
+	// flag on creation and can change it for its own routes.
 	StrictSlash bool
-	// This defines the flag for new routes.
+	// SkipClean matches the path as requested. By default the path is
+	// cleaned (e.g. // and ../ are resolved) and the request is redirected to
+	// the cleaned path, see SkipCleanRedirect.
 	SkipClean bool
+	// SkipCleanRedirect matches the cleaned path instead of redirecting to
+	// it. The handler gets the cleaned path.
+	SkipCleanRedirect bool
 	// This defines a flag for all routes.
 	UseEncodedPath bool
 	// see Validator
 	Validatoren map[string]Validator
-	// This defines a flag for all routes.
+	// CaseSensitiveURL matches paths exactly. By default paths are matched
+	// regardless of their case, while the handler still gets the path as
+	// requested and vars keep their case. Set it before registering routes.
 	CaseSensitiveURL bool
+	// RedirectCanonicalCase redirects a request whose path only matches
+	// regardless of its case to the path in the case of the route. GET and
+	// HEAD requests are redirected with 301, others with 308. Regex paths
+	// and path prefixes aren't redirected.
+	RedirectCanonicalCase bool
+	// HandleOptions answers OPTIONS requests without a matching route with
+	// the methods of the routes registered for the path.
+	HandleOptions bool
+	// TrustForwardedProto matches the scheme of the X-Forwarded-Proto or
+	// Forwarded header set by a proxy instead of the scheme of the
+	// connection (see Route.Schemes). The value appended last is used. Only
+	// set it if every request passes a proxy which appends to or overwrites
+	// the header, otherwise clients can spoof the scheme.
+	TrustForwardedProto bool
+	// RedirectHTTPS redirects requests over http to https, keeping the host
+	// without its port, the path and the query. GET and HEAD requests are
+	// redirected with 301, others with 308. A subrouter inherits the flag on
+	// creation and can change it for its own routes, requests without a
+	// matching route are redirected if the router serving them has it set.
+	RedirectHTTPS bool
+	// DisabledUnavailable responds with 503 Service Unavailable to requests
+	// which a disabled route of this router would match (see Route.Disable)
+	// and no other route matches, instead of 404 or 405. A subrouter inherits
+	// the flag on creation and can change it for its own routes.
+	DisabledUnavailable bool
+	// Trace records for each request which routes were evaluated and which
+	// matcher rejected each of them, to diagnose why a request isn't
+	// matched. The trace is added to the response as TraceHeader lines and
+	// can be retrieved by the handlers with GetTrace. It is meant for
+	// development, as it evaluates every route and exposes the route table.
+	Trace bool
+	// CORS answers preflight requests and adds the CORS headers to the
+	// responses of cross-origin requests if it is set. Preflight requests are
+	// answered before matching, by default with the methods of the routes
+	// registered for the path.
+	CORS *CORSOptions
+	// Clock returns the current time for the time window matchers (see
+	// Route.During), e.g. a fixed time in tests. It defaults to time.Now. A
+	// subrouter inherits the clock on creation.
+	Clock func() time.Time
+	// Log is the structured logger of the router, e.g. of the Recovery and
+	// LogRequests middlewares and of the requests without a matching route
+	// (at slog.LevelDebug). It defaults to slog.Default(). A subrouter
+	// inherits the logger on creation.
+	Log StructuredLogger
+	// Stats counts the matches and the 404 and 405 responses per route if
+	// it is set, see NewRouteStats. A subrouter inherits the counters on
+	// creation.
+	Stats *RouteStats
 	// this builds a route
 	constructRoute func(*Router) RouteInterface
+	// parent router of a subrouter
+	parent *Router
+	// path prefix of a subrouter
+	prefix string
+	// matchers shared by the routes of a subrouter
+	matchers Matchers
+	// middlewares wrap the handler of every matched route
+	middlewares middlewares
+}
+
+// Use appends middlewares to the router. The middlewares wrap the handler of
+// every matched route in registration order, the first middleware is the
+// outermost. They are not applied if no route matches.
+//
+// The middlewares of a router also apply to the routes of its subrouters.
+// The handler of a route is wrapped in the following order, from the
+// outermost to the innermost:
+//
+//     1. the middlewares of the router
+//     2. the middlewares of the subrouters, from the parent to the child
+//     3. the middlewares of the route (see Route.Use)
+//
+// For example:
+//
+//     r := mux.Classic()
+//     r.Use(loggingMiddleware)
+//     admin := r.PathPrefix("/admin").Subrouter()
+//     admin.Use(authMiddleware)
+//     // calls loggingMiddleware, authMiddleware, auditMiddleware and usersHandler
+//     admin.Get("/users", usersHandler).Use(auditMiddleware)
+//
+func (r *Router) Use(mws ...MiddlewareFunc) {
+	r.middlewares = append(r.middlewares, mws...)
 }
 
 // UseRoute that you can use diffrent instances routes
@@ -54,28 +171,94 @@ func (r *Router) UseRoute(constructer func(*Router) RouteInterface) {
 	r.constructRoute = constructer
 }
 
+// newSubrouter returns a router which registers its routes on r.
+// It inherits the configuration of r, matchers must contain the matchers of r.
+func (r *Router) newSubrouter(prefix string, matchers Matchers) *Router {
+	return &Router{
+		NotFoundHandler:         r.NotFoundHandler,
+		MethodNotAllowedHandler: r.MethodNotAllowedHandler,
+		store:                   r.store,
+		paramTypes:              r.paramTypes,
+		trustedProxies:          r.trustedProxies,
+		StrictSlash:             r.StrictSlash,
+		SkipClean:               r.SkipClean,
+		SkipCleanRedirect:       r.SkipCleanRedirect,
+		UseEncodedPath:          r.UseEncodedPath,
+		Validatoren:             r.Validatoren,
+		CaseSensitiveURL:        r.CaseSensitiveURL,
+		RedirectCanonicalCase:   r.RedirectCanonicalCase,
+		HandleOptions:           r.HandleOptions,
+		TrustForwardedProto:     r.TrustForwardedProto,
+		RedirectHTTPS:           r.RedirectHTTPS,
+		DisabledUnavailable:     r.DisabledUnavailable,
+		Trace:                   r.Trace,
+		CORS:                    r.CORS,
+		Clock:                   r.Clock,
+		Log:                     r.Log,
+		Stats:                   r.Stats,
+		constructRoute:          r.constructRoute,
+		parent:                  r,
+		prefix:                  prefix,
+		matchers:                matchers,
+	}
+}
+
 // triggerMatching matches registered routes against the request.
 func (r *Router) triggerMatching(req *http.Request) RouteInterface {
+	return r.matchMethod(req.Method, req)
+}
 
-	if routesForMethod, found := r.routes[req.Method]; found {
-		for _, route := range routesForMethod {
-			if route := route.Match(req); route != nil {
-				return route
-			}
-		}
+// matchMethod matches the routes registered for method against the request
+// using the route index of the method.
+func (r *Router) matchMethod(method string, req *http.Request) RouteInterface {
+	if idx := r.table().index(method); idx != nil {
+		return idx.match(req)
 	}
 
 	return nil
 }
 
+// allowedMethods returns the methods of the registered routes which match
+// the request except for its method, in sorted order.
+func (r *Router) allowedMethods(req *http.Request) []string {
+	allowed := []string{}
+	methodReq := new(http.Request)
+
+	for method := range r.table().routes {
+		if method == req.Method {
+			continue
+		}
+
+		*methodReq = *req
+		methodReq.Method = method
+
+		if r.matchMethod(method, methodReq) != nil {
+			allowed = append(allowed, method)
+		}
+	}
+
+	sort.Strings(allowed)
+
+	return allowed
+}
+
 // ServeHTTP dispatches the handler registered in the matched route.
 //
+// If no route matches but routes for other methods match, the response is
+// 405 Method Not Allowed with an Allow header listing those methods. If
+// HandleOptions is set, OPTIONS requests are answered with 200 OK and the
+// same Allow header instead.
+//
 // When there is a match, the route variables can be retrieved calling
 // mux.GetVars(req).Get(":number") or mux.GetVars(req).GetAll()
 //
 // and the route queires can be retrieved calling
 // mux.GetQueries(req).Get(":number") or mux.GetQueries(req).GetAll()
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if len(r.trustedProxies) != 0 {
+		req = r.forwardedRequest(req)
+	}
+
 	if !r.SkipClean {
 
 		path := req.URL.Path
@@ -86,35 +269,131 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 		// Clean path to canonical form and redirect.
 		if p := cleanPath(path); p != path {
-			w.Header().Set("Location", p)
-			w.WriteHeader(http.StatusMovedPermanently)
-			return
+			u := *req.URL
+			u.Path, u.RawPath = p, ""
+
+			if r.UseEncodedPath {
+				if unescaped, err := url.PathUnescape(p); err == nil {
+					u.Path, u.RawPath = unescaped, p
+				}
+			}
+
+			if !r.SkipCleanRedirect {
+				redirectPermanent(w, req, &u)
+				return
+			}
+
+			cleanReq := new(http.Request)
+			*cleanReq = *req
+			cleanReq.URL = &u
+			req = cleanReq
 		}
 	}
 
-	if !r.CaseSensitiveURL {
-		req.URL.Path = strings.ToLower(req.URL.Path)
+	if r.CORS != nil && r.handleCORS(w, req) {
+		return
 	}
 
-	route := r.triggerMatching(req)
+	if r.Trace {
+		steps := r.trace(r.matchRequest(req))
+		for _, step := range steps {
+			w.Header().Add(TraceHeader, step.String())
+		}
+		req = contextSet(req, traceKey, steps)
+	}
+
+	route := r.triggerMatching(r.matchRequest(req))
+
+	if r.redirectHTTPS(w, req, route) {
+		return
+	}
 
 	if route == nil {
-		r.notFoundHandler().ServeHTTP(w, req)
+		if r.serveUnavailable(w, r.matchRequest(req)) {
+			return
+		}
+
+		if r.redirectSlash(w, req) {
+			return
+		}
+
+		// the path matched but the method didn't
+		if allowed := r.allowedMethods(r.matchRequest(req)); len(allowed) != 0 {
+			if r.HandleOptions && req.Method == http.MethodOptions {
+				allowed = append(allowed, http.MethodOptions)
+				sort.Strings(allowed)
+				w.Header().Set("Allow", strings.Join(allowed, ", "))
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			r.logUnmatched(req, http.StatusMethodNotAllowed)
+			r.countMethodNotAllowed(r.matchRequest(req), allowed)
+			r.methodNotAllowedHandler().ServeHTTP(w, req)
+			return
+		}
+
+		if route = r.matchFallback(r.matchRequest(req)); route == nil {
+			r.logUnmatched(req, http.StatusNotFound)
+			if r.Stats != nil {
+				r.Stats.countNotFound()
+			}
+			r.notFoundHandler().ServeHTTP(w, req)
+			return
+		}
+	}
+
+	if r.RedirectCanonicalCase && !r.CaseSensitiveURL && r.redirectCase(w, req, route) {
 		return
 	}
 
+	if r.Stats != nil {
+		r.Stats.countMatch(route)
+	}
+
 	req = AddCurrentRoute(req, route)
 	req = AddQueries(req)
 
 	if route.HasVars() {
-		req = AddVars(req, route.ExtractVars(req))
+		p := acquireParams()
+		defer releaseParams(p)
+
+		captureParams(route, req, p)
+		if err := r.parseParams(p); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req = contextSet(req, varsKey, p)
 	}
 
-	if !route.HasHandler() {
-		route.Handler(r.notFoundHandler())
+	r.routeHandler(route).ServeHTTP(w, req)
+}
+
+// routeHandler returns the handler of the route wrapped with the middlewares
+// of the route, its subrouters and r (see Router.Use).
+func (r *Router) routeHandler(route RouteInterface) http.Handler {
+	handler := route.GetHandler()
+	if handler == nil {
+		handler = r.notFoundHandler()
 	}
+	handler = middlewares(route.GetMiddlewares()).apply(handler)
 
-	route.GetHandler().ServeHTTP(w, req)
+	for router := route.GetRouter(); router != nil && router != r; router = router.parent {
+		handler = router.middlewares.apply(handler)
+	}
+
+	return r.middlewares.apply(handler)
+}
+
+func (r *Router) methodNotAllowedHandler() http.Handler {
+	if r.MethodNotAllowedHandler == nil {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		})
+	}
+
+	return r.MethodNotAllowedHandler
 }
 
 func (r *Router) notFoundHandler() http.Handler {
@@ -145,43 +424,567 @@ func cleanPath(p string) string {
 	return np
 }
 
+// redirectSlash redirects to the path with the trailing slash added or
+// removed if that path matches a route whose router has StrictSlash set.
+func (r *Router) redirectSlash(w http.ResponseWriter, req *http.Request) bool {
+	if req.URL.Path == "" || req.URL.Path == "/" {
+		return false
+	}
+
+	u := *req.URL
+	u.RawPath = ""
+	if strings.HasSuffix(u.Path, "/") {
+		u.Path = strings.TrimSuffix(u.Path, "/")
+	} else {
+		u.Path += "/"
+	}
+
+	slashReq := new(http.Request)
+	*slashReq = *req
+	slashReq.URL = &u
+
+	route := r.triggerMatching(r.matchRequest(slashReq))
+	if route == nil {
+		return false
+	}
+
+	router := route.GetRouter()
+	if router == nil {
+		router = r
+	}
+
+	if !router.StrictSlash {
+		return false
+	}
+
+	redirectPermanent(w, req, &u)
+	return true
+}
+
+// canonicalPather is implemented by routes which can write the path of a
+// request in their case.
+type canonicalPather interface {
+	canonicalPath(req *http.Request) (string, bool)
+}
+
+// redirectCase redirects to the path in the case of the matched route if
+// the path of the request differs.
+func (r *Router) redirectCase(w http.ResponseWriter, req *http.Request, route RouteInterface) bool {
+	cp, ok := route.(canonicalPather)
+	if !ok {
+		return false
+	}
+
+	path, ok := cp.canonicalPath(req)
+	if !ok || path == req.URL.Path {
+		return false
+	}
+
+	u := *req.URL
+	u.Path = path
+	u.RawPath = ""
+
+	redirectPermanent(w, req, &u)
+	return true
+}
+
+// redirectHTTPS redirects a request over http to https if the router of the
+// matched route, or r if no route matches, has RedirectHTTPS set.
+func (r *Router) redirectHTTPS(w http.ResponseWriter, req *http.Request, route RouteInterface) bool {
+	router := r
+	if route != nil && route.GetRouter() != nil {
+		router = route.GetRouter()
+	}
+
+	if !router.RedirectHTTPS || requestScheme(r.matchRequest(req)) == "https" {
+		return false
+	}
+
+	host := stripHostPort(req.Host)
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+
+	u := *req.URL
+	u.Scheme = "https"
+	u.Host = host
+
+	redirectPermanentTo(w, req, u.String())
+	return true
+}
+
+// serveUnavailable responds with 503 if a disabled route whose router has
+// DisabledUnavailable set would match the request.
+func (r *Router) serveUnavailable(w http.ResponseWriter, req *http.Request) bool {
+	for _, route := range r.table().routes[req.Method] {
+		router := route.GetRouter()
+		if !route.IsDisabled() || router == nil || !router.DisabledUnavailable || route.HasError() {
+			continue
+		}
+
+		matched := true
+		for _, m := range route.GetMatchers() {
+			if !m.Match(req) {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return true
+		}
+	}
+	return false
+}
+
+// redirectPermanent redirects to u with 301 for GET and HEAD requests and
+// 308 otherwise, so the method and body are kept.
+func redirectPermanent(w http.ResponseWriter, req *http.Request, u *url.URL) {
+	redirectPermanentTo(w, req, u.RequestURI())
+}
+
+// redirectPermanentTo redirects to location, see redirectPermanent.
+func redirectPermanentTo(w http.ResponseWriter, req *http.Request, location string) {
+	code := http.StatusPermanentRedirect
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		code = http.StatusMovedPermanently
+	}
+
+	w.Header().Set("Location", location)
+	w.WriteHeader(code)
+}
+
+// matchRequest returns the request to be matched against the routes: a copy
+// with a lowercased path unless CaseSensitiveURL is set and with the scheme
+// of the proxy headers if TrustForwardedProto is set.
+func (r *Router) matchRequest(req *http.Request) *http.Request {
+	path := req.URL.Path
+	if !r.CaseSensitiveURL {
+		path = strings.ToLower(path)
+	}
+
+	scheme := req.URL.Scheme
+	if r.TrustForwardedProto {
+		if proto := forwardedProto(req, 1); proto != "" {
+			scheme = proto
+		}
+	}
+
+	if path == req.URL.Path && scheme == req.URL.Scheme {
+		return req
+	}
+
+	u := *req.URL
+	if path != req.URL.Path {
+		u.Path = path
+		u.RawPath = ""
+	}
+	u.Scheme = scheme
+
+	matchReq := new(http.Request)
+	*matchReq = *req
+	matchReq.URL = &u
+
+	return matchReq
+}
+
 // NewRoute registers an empty route.
+// The route has the matchers of the router if it is a subrouter.
 func (r *Router) NewRoute() RouteInterface {
-	return r.constructRoute(r)
+	route := r.constructRoute(r)
+
+	for _, m := range r.matchers {
+		route.AddMatcher(m)
+	}
+
+	return route
+}
+
+// PathPrefix registers an empty route with a matcher for the URL path prefix.
+// See Route.PathPrefix() and Route.Subrouter().
+func (r *Router) PathPrefix(prefix string) RouteInterface {
+	return r.NewRoute().PathPrefix(prefix)
 }
 
 // RegisterRoute registers and validates a new route
+//
+// Routes can be registered while the router serves requests, e.g. by
+// plugins loaded after startup. The route table is copied and swapped on
+// registration, so requests are matched without locking against either the
+// routes before or after the registration. A route registered at runtime
+// must be complete, as its matchers are not synchronized:
+//
+//     route := r.NewRoute().Path("/plugins/stats").Methods("GET").HandlerFunc(statsHandler)
+//     r.Register(route)
+//
 func (r *Router) RegisterRoute(method string, route RouteInterface) RouteInterface {
+	return r.registerRoute([]string{method}, route)
+}
 
-	route.SetMethodName(method)
+// registerRoute validates the route for each of the methods and publishes
+// it for all of them at once.
+func (r *Router) registerRoute(methods []string, route RouteInterface) RouteInterface {
+	for _, method := range methods {
+		route.SetMethodName(method)
 
-	for _, validatorKey := range []string{"method", "path"} {
-		if validator, found := r.Validatoren[validatorKey]; found {
+		for _, validatorKey := range []string{"method", "path"} {
+			// keep the error resulted from building the route
+			if route.HasError() {
+				break
+			}
 
-			err := validator.Validate(route)
+			if validator, found := r.Validatoren[validatorKey]; found {
 
-			if err != nil {
-				route.SetError(NewBadRouteError(route, err.Error()))
-				break
+				err := validator.Validate(route)
+
+				if err != nil {
+					route.SetError(NewBadRouteError(route, err.Error()))
+					break
+				}
 			}
 		}
 	}
-	r.routes[method] = append(r.routes[method], route)
+
+	r.store.update(func(t *routeTable) {
+		// the route may have been named before it was registered
+		if !route.HasError() {
+			if other := namedRoute(t, route); other != nil {
+				route.SetError(NewBadRouteError(route, fmt.Sprintf("name %q is already used by the route %s", route.GetName(), other.GetPath())))
+			}
+		}
+
+		for _, method := range methods {
+			if !route.HasError() {
+				if other := conflictingRoute(t.routes[method], route); other != nil {
+					route.SetError(NewBadRouteError(route, fmt.Sprintf("conflicts with the route %s", other.GetPath())))
+				}
+			}
+
+			t.setRoutes(method, append(t.routes[method], route))
+		}
+	})
+
 	return route
 }
 
+// Remove unregisters a route for all its methods, e.g. when a plugin is
+// unloaded. nameOrRoute is either the name of the routes (see Route.Name) or
+// a registered route. Like the registration it is safe while the router
+// serves requests, a request is matched against the routes either before or
+// after the removal.
+//
+//     route := r.Get("/plugins/stats", statsHandler).Name("plugin-stats")
+//     // ...
+//     err := r.Remove("plugin-stats") // or r.Remove(route)
+//
+// An error is returned if no route was removed.
+func (r *Router) Remove(nameOrRoute interface{}) error {
+	var remove func(route RouteInterface) bool
+	var desc string
+	switch v := nameOrRoute.(type) {
+	case string:
+		remove = func(route RouteInterface) bool {
+			return route.GetName() == v
+		}
+		desc = fmt.Sprintf("%q", v)
+	case RouteInterface:
+		remove = func(route RouteInterface) bool {
+			return route == v
+		}
+		desc = v.GetPath()
+	default:
+		return fmt.Errorf("mux: can't remove a route by %T", nameOrRoute)
+	}
+
+	removed := false
+	r.store.update(func(t *routeTable) {
+		for method, v := range t.routes {
+			// the published routes stay untouched
+			kept := make(routes, 0, len(v))
+			for _, route := range v {
+				if !remove(route) {
+					kept = append(kept, route)
+				}
+			}
+
+			if len(kept) != len(v) {
+				removed = true
+				t.setRoutes(method, kept)
+			}
+		}
+	})
+
+	if !removed {
+		return fmt.Errorf("mux: route %s not found", desc)
+	}
+	return nil
+}
+
+// Host registers an empty route with a matcher for the host.
+// Together with Route.Subrouter() whole route trees can be partitioned by
+// host, for example:
+//
+//     r := mux.Classic()
+//     admin := r.Host("admin.example.com").Subrouter()
+//     admin.Get("/users", usersHandler)
+//
+// See Route.Host() and Route.Subrouter().
+func (r *Router) Host(host string) RouteInterface {
+	return r.NewRoute().Host(host)
+}
+
+// URL builds a URL for the route with the given name, which is unique.
+// See Route.Name() and Route.URL(), for example:
+//
+//     r := mux.Classic()
+//     r.Get("/users/:id", userHandler).Name("user-detail")
+//     url, err := r.URL("user-detail", "id", "42")
+//     // url.String() == "/users/42"
+//
+func (r *Router) URL(name string, pairs ...string) (*url.URL, error) {
+	for _, routesForMethod := range r.table().routes {
+		for _, route := range routesForMethod {
+			if route.GetName() == name {
+				return route.URL(pairs...)
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("mux: route %q not found", name)
+}
+
+// WalkFunc is the type of the function called for each route visited by
+// Router.Walk.
+type WalkFunc func(route RouteInterface) error
+
+// Walk calls fn for each registered route, e.g. to generate documentation
+// or to test the route table. The routes are visited once ordered by their
+// methods and in matching order, a route registered for multiple methods is
+// visited for its first method. Walk stops if fn returns an error and
+// returns that error.
+//
+// For example:
+//
+//     r.Walk(func(route mux.RouteInterface) error {
+//         fmt.Println(route.GetMethods(), route.GetPath(), route.GetName())
+//         return nil
+//     })
+//
+func (r *Router) Walk(fn WalkFunc) error {
+	t := r.table()
+	methods := make([]string, 0, len(t.routes))
+	for method := range t.routes {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	visited := map[RouteInterface]struct{}{}
+	for _, method := range methods {
+		for _, route := range t.routes[method] {
+			if _, found := visited[route]; found {
+				continue
+			}
+			visited[route] = struct{}{}
+
+			if err := fn(route); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RouteMatch is the result of Router.MatchRequest.
+type RouteMatch struct {
+	// Route is the matched route.
+	Route RouteInterface
+	// Vars are the route variables of the request.
+	Vars Vars
+	// AllowedMethods are the methods of the routes matching the request
+	// except for its method, if Err is ErrMethodMismatch.
+	AllowedMethods []string
+	// Err is the reason why the request isn't served by a route:
+	// ErrNotFound, ErrMethodMismatch or a *BadParamError of a variable
+	// which can't be converted to its type.
+	Err error
+}
+
+// MatchRequest matches the request against the routes like ServeHTTP
+// without calling any handler, e.g. to test the route table:
+//
+//     match, ok := r.MatchRequest(httptest.NewRequest("GET", "/users/42", nil))
+//     if !ok || match.Vars["id"] != "42" {
+//         t.Errorf("unexpected match (%v)", match.Err)
+//     }
+//
+// The path of the request is matched as it is, the redirects of ServeHTTP
+// (path cleaning, StrictSlash and RedirectCanonicalCase) aren't applied.
+func (r *Router) MatchRequest(req *http.Request) (RouteMatch, bool) {
+	matchReq := r.matchRequest(req)
+
+	route := r.triggerMatching(matchReq)
+	if route == nil {
+		if allowed := r.allowedMethods(matchReq); len(allowed) != 0 {
+			return RouteMatch{AllowedMethods: allowed, Err: ErrMethodMismatch}, false
+		}
+		if route = r.matchFallback(matchReq); route == nil {
+			return RouteMatch{Err: ErrNotFound}, false
+		}
+	}
+
+	match := RouteMatch{Route: route}
+	if route.HasVars() {
+		p := &params{}
+		captureParams(route, req, p)
+		match.Vars = p.vars()
+
+		if err := r.parseParams(p); err != nil {
+			match.Err = err
+			return match, false
+		}
+	}
+
+	return match, true
+}
+
+// RegisterParamType registers a type for route variables, so a path like
+// /orders/:uuid matches the pattern of the type. The router and all of its
+// subrouters share the types, they have to be registered before the routes
+// using them.
+//
+// The pattern must not match a slash, a variable matches a single segment.
+// If parse is set, the value of the variable is converted when a route
+// matches and can be retrieved with ParamValue(req, ":uuid"). A request
+// whose value can't be converted is answered with 400 Bad Request.
+func (r *Router) RegisterParamType(name string, pattern string, parse ParamParseFunc) error {
+	if name == "" {
+		return fmt.Errorf("mux: name of param type is missing")
+	}
+
+	for i := 0; i < len(name); i++ {
+		if !isVarNameChar(name[i]) {
+			return fmt.Errorf("mux: name of param type %q is invalid", name)
+		}
+	}
+
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return fmt.Errorf("mux: pattern of param type %q is invalid: %s", name, err.Error())
+	}
+
+	if matchesSlash(re) {
+		return fmt.Errorf("mux: pattern of param type %q must not match a slash", name)
+	}
+
+	if r.paramTypes == nil {
+		r.paramTypes = paramTypes{}
+	}
+	r.paramTypes[name] = paramType{pattern: pattern, parse: parse}
+
+	return nil
+}
+
+// parseParams converts the values of the variables whose type has a parse
+// function.
+func (r *Router) parseParams(p *params) error {
+	for k := range *p {
+		param := &(*p)[k]
+		if !strings.HasPrefix(param.name, ":") {
+			continue
+		}
+
+		typ, found := r.paramTypes[param.name[1:]]
+		if !found || typ.parse == nil {
+			continue
+		}
+
+		value, err := typ.parse(param.value)
+		if err != nil {
+			return NewBadParamError(param.name, param.value, param.name[1:], err)
+		}
+		param.typed = value
+	}
+
+	return nil
+}
+
+// Mount registers handler for all paths below prefix and every standard
+// method, e.g. to serve the handler of a library:
+//
+//     r.Mount("/metrics", metricsHandler, false)
+//
+// If stripPrefix is set, the segments of the prefix are removed from the
+// path of the request before it is passed to handler, so /static/css/app.css
+// mounted at /static is served as /css/app.css.
+func (r *Router) Mount(prefix string, handler http.Handler, stripPrefix bool) RouteInterface {
+	route := r.NewRoute().PathPrefix(prefix)
+
+	if stripPrefix {
+		handler = stripSegmentsHandler(strings.Count(strings.TrimSuffix(route.GetPath(), "/"), "/"), handler)
+	}
+	route.Handler(handler)
+
+	return r.registerRoute(standardMethods(), route)
+}
+
+// Register registers the route for every method added with Route.Methods.
+// A route without methods is registered as invalid route.
+func (r *Router) Register(route RouteInterface) RouteInterface {
+	methods := route.GetMethods()
+
+	if len(methods) == 0 {
+		return r.RegisterRoute("", route)
+	}
+
+	return r.registerRoute(methods, route)
+}
+
 // Handle registers a new route with a matcher for the URL path.
 // See Route.Path() and Route.Handler().
-func (r *Router) Handle(method string, path string, handler http.Handler) RouteInterface {
+//
+// The error of the route is returned, e.g. for an invalid path pattern, so
+// it can be handled at registration. The route is registered regardless but
+// never matches.
+func (r *Router) Handle(method string, path string, handler http.Handler) (RouteInterface, error) {
 	route := r.NewRoute()
 	route.Path(path).Handler(handler)
-	return r.RegisterRoute(method, route)
+	return routeWithError(r.RegisterRoute(method, route))
 }
 
 // HandleFunc registers a new route with a matcher for the URL path.
 // See Route.Path() and Route.HandlerFunc().
-func (r *Router) HandleFunc(method string, path string, HandlerFunc func(http.ResponseWriter, *http.Request)) RouteInterface {
-	return r.RegisterRoute(method, r.NewRoute().Path(path).HandlerFunc(HandlerFunc))
+//
+// The error of the route is returned, see Handle.
+func (r *Router) HandleFunc(method string, path string, HandlerFunc func(http.ResponseWriter, *http.Request)) (RouteInterface, error) {
+	return routeWithError(r.RegisterRoute(method, r.NewRoute().Path(path).HandlerFunc(HandlerFunc)))
+}
+
+// MustHandle is like Handle but panics if the route has an error.
+func (r *Router) MustHandle(method string, path string, handler http.Handler) RouteInterface {
+	return mustRoute(r.Handle(method, path, handler))
+}
+
+// MustHandleFunc is like HandleFunc but panics if the route has an error.
+func (r *Router) MustHandleFunc(method string, path string, HandlerFunc func(http.ResponseWriter, *http.Request)) RouteInterface {
+	return mustRoute(r.HandleFunc(method, path, HandlerFunc))
+}
+
+// routeWithError returns the route alongside its error.
+func routeWithError(route RouteInterface) (RouteInterface, error) {
+	if route.HasError() {
+		return route, route.GetError()
+	}
+	return route, nil
+}
+
+// mustRoute panics if err is set.
+func mustRoute(route RouteInterface, err error) RouteInterface {
+	if err != nil {
+		panic(err)
+	}
+	return route
 }
 
 // Get registers a new get route for the URL path
@@ -202,6 +1005,32 @@ func (r *Router) Post(path string, handlerFunc func(http.ResponseWriter, *http.R
 	return r.RegisterRoute(http.MethodPost, r.NewRoute().Path(path).HandlerFunc(handlerFunc))
 }
 
+// Any registers a new route for the URL path and every standard method,
+// e.g. for a proxy.
+// See Route.Path() and Route.HandlerFunc()
+func (r *Router) Any(path string, handlerFunc func(http.ResponseWriter, *http.Request)) RouteInterface {
+	return r.Match(standardMethods(), path, handlerFunc)
+}
+
+// Match registers a new route for the URL path and each of the methods.
+// A route without methods is registered as invalid route.
+// See Route.Path() and Route.HandlerFunc()
+func (r *Router) Match(methods []string, path string, handlerFunc func(http.ResponseWriter, *http.Request)) RouteInterface {
+	route := r.NewRoute().Path(path).HandlerFunc(handlerFunc)
+
+	if len(methods) == 0 {
+		return r.RegisterRoute("", route)
+	}
+
+	return r.registerRoute(methods, route)
+}
+
+// Patch registers a new patch route for the URL path
+// See Route.Path() and Route.HandlerFunc()
+func (r *Router) Patch(path string, handlerFunc func(http.ResponseWriter, *http.Request)) RouteInterface {
+	return r.RegisterRoute(http.MethodPatch, r.NewRoute().Path(path).HandlerFunc(handlerFunc))
+}
+
 // Delete registers a new delete route for the URL path
 // See Route.Path() and Route.HandlerFunc()
 func (r *Router) Delete(path string, handlerFunc func(http.ResponseWriter, *http.Request)) RouteInterface {
@@ -247,7 +1076,7 @@ func (r *Router) HasErrors() (bool, []error) {
 	errors := []error{}
 	hasError := false
 
-	for _, v := range r.routes {
+	for _, v := range r.table().routes {
 		for _, vv := range v {
 			if vv.HasError() {
 				hasError = true
@@ -259,14 +1088,112 @@ func (r *Router) HasErrors() (bool, []error) {
 	return hasError, errors
 }
 
+// conflictingRoute returns a route of rs which matches the same requests as
+// route, which would never be matched otherwise.
+func conflictingRoute(rs routes, route RouteInterface) RouteInterface {
+	for _, other := range rs {
+		if other != route && !other.HasError() && routesConflict(other, route) {
+			return other
+		}
+	}
+	return nil
+}
+
+// namedRoute returns a route of t other than route with the name of route,
+// nil if route has no name.
+func namedRoute(t *routeTable, route RouteInterface) RouteInterface {
+	if route.GetName() == "" {
+		return nil
+	}
+	for _, rs := range t.routes {
+		for _, other := range rs {
+			if other != route && other.GetName() == route.GetName() {
+				return other
+			}
+		}
+	}
+	return nil
+}
+
+// routesConflict returns true if both routes have the same path template and
+// equal matchers. Paths with vars are compared by their regex, so the names
+// of the vars don't matter. Custom matchers never conflict.
+func routesConflict(a RouteInterface, b RouteInterface) bool {
+	if a.Kind() != b.Kind() {
+		return false
+	}
+
+	pathA, matchersA := conflictMatchers(a)
+	pathB, matchersB := conflictMatchers(b)
+
+	if pathA == "" || pathA != pathB || len(matchersA) != len(matchersB) {
+		return false
+	}
+
+	for _, ma := range matchersA {
+		found := false
+		for _, mb := range matchersB {
+			if reflect.DeepEqual(ma, mb) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// conflictMatchers returns the path template of a route and its matchers
+// except for the path and the methods.
+func conflictMatchers(route RouteInterface) (string, Matchers) {
+	path := ""
+	matchers := Matchers{}
+
+	for _, m := range route.GetMatchers() {
+		switch m := m.(type) {
+		case pathMatcher:
+			path = string(m)
+		case pathWithVarsMatcher:
+			path = m.template.regex.String()
+		case pathRegexMatcher:
+			path = m.regex.String()
+		case pathPrefixMatcher:
+			path = m.prefix
+			if m.template != nil {
+				path = m.template.regex.String()
+			}
+		case methodMatcher:
+		case negotiationMatcher:
+			// the route and the func are only used for negotiating
+			matchers = append(matchers, negotiationMatcher{header: m.header, values: m.values})
+		case timeWindowMatcher:
+			// the route only provides the clock
+			matchers = append(matchers, timeWindowMatcher{windows: m.windows})
+		default:
+			matchers = append(matchers, m)
+		}
+	}
+
+	return path, matchers
+}
+
 // SortRoutes sorts the routes (Rank: RegexPath, PathWithVars, PathNormal)
+// The matchers of the routes are sorted in place, so the routes should be
+// sorted before the router serves requests.
 func (r *Router) SortRoutes() {
-	for _, v := range r.routes {
-		for _, vv := range v {
-			sort.Sort(vv.GetMatchers())
+	r.store.update(func(t *routeTable) {
+		for method, v := range t.routes {
+			sorted := append(routes{}, v...)
+			for _, vv := range sorted {
+				sort.Sort(vv.GetMatchers())
+			}
+			sort.Sort(sorted)
+			t.setRoutes(method, sorted)
 		}
-		sort.Sort(v)
-	}
+	})
 }
 
 // routes implements the sort interface (len, swap, less)
@@ -8,7 +8,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -62,6 +64,15 @@ func TestPath(t *testing.T) {
 				r.Put(path, handler)
 			},
 		},
+		{
+			title:      "(PATCH) Path route with single path",
+			path:       "/api",
+			method:     http.MethodPatch,
+			statusCode: http.StatusOK,
+			route: func(r *Router, path string, method string, handler func(w http.ResponseWriter, r *http.Request)) {
+				r.Patch(path, handler)
+			},
+		},
 		{
 			title:      "(Head) Path route with single path",
 			path:       "/api/",
@@ -185,6 +196,28 @@ func TestPath(t *testing.T) {
 				r.HandleFunc(method, "/api/user/:number/article/:string", handler)
 			},
 		},
+		{
+			title:      "(GET) Path route with named vars",
+			path:       "/api/users/32/posts/golang",
+			method:     http.MethodGet,
+			statusCode: http.StatusOK,
+			kind:       "HandlerFunc",
+			vars:       map[string]string{"id": "32", "slug": "golang"},
+			route: func(r *Router, path string, method string, handler func(w http.ResponseWriter, r *http.Request)) {
+				r.HandleFunc(method, "/api/users/:id/posts/:slug", handler)
+			},
+		},
+		{
+			title:      "(GET) Path route with catch-all var",
+			path:       "/static/css/main.css",
+			method:     http.MethodGet,
+			statusCode: http.StatusOK,
+			kind:       "HandlerFunc",
+			vars:       map[string]string{"filepath": "css/main.css"},
+			route: func(r *Router, path string, method string, handler func(w http.ResponseWriter, r *http.Request)) {
+				r.HandleFunc(method, "/static/*filepath", handler)
+			},
+		},
 		{
 			title:      "(GET) Path route with vars",
 			path:       "/api/user/3",
@@ -362,6 +395,63 @@ func TestRouteNotfound(t *testing.T) {
 	}
 }
 
+func TestRouteMethodNotAllowed(t *testing.T) {
+	r := Classic()
+	testHandler := func(w http.ResponseWriter, r *http.Request) {}
+	r.Get("/user/:id", testHandler)
+	r.Post("/user/:id", testHandler)
+	r.Delete("/user", testHandler)
+
+	req, _ := http.NewRequest(http.MethodPut, "http://localhost/user/1", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Unexpected status code (%d)", res.Code)
+	}
+
+	if allow := res.Header().Get("Allow"); allow != "GET, POST" {
+		t.Errorf("Unexpected Allow header (%s)", allow)
+	}
+}
+
+func TestRouteHandleOptions(t *testing.T) {
+	r := Classic()
+	r.HandleOptions = true
+	testHandler := func(w http.ResponseWriter, r *http.Request) {}
+	r.Get("/user/:id", testHandler)
+	r.Put("/user/:id", testHandler)
+
+	req, _ := http.NewRequest(http.MethodOptions, "http://localhost/user/1", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Errorf("Unexpected status code (%d)", res.Code)
+	}
+
+	if allow := res.Header().Get("Allow"); allow != "GET, OPTIONS, PUT" {
+		t.Errorf("Unexpected Allow header (%s)", allow)
+	}
+}
+
+func TestRouteHandleOptionsWithRoute(t *testing.T) {
+	r := Classic()
+	r.HandleOptions = true
+	r.Get("/user", func(w http.ResponseWriter, r *http.Request) {})
+	r.Options("/user", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	req, _ := http.NewRequest(http.MethodOptions, "http://localhost/user", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	if res.Code != http.StatusNoContent {
+		t.Errorf("Unexpected status code (%d)", res.Code)
+	}
+}
+
 func TestRouteWithoutHandler(t *testing.T) {
 
 	var methods = []string{
@@ -390,41 +480,1352 @@ func TestRouteWithoutHandler(t *testing.T) {
 	}
 }
 
-func TestHasErrors(t *testing.T) {
-	routeA := &Route{
-		err: errors.New("Bad route"),
+func TestRegisterWithMethods(t *testing.T) {
+	r := Classic()
+	handler := func(key string) func(w http.ResponseWriter, r *http.Request) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(key))
+		}
 	}
-	routeB := &Route{
-		err: errors.New("Bad method"),
+
+	r.Register(r.NewRoute().Path("/user").Methods(http.MethodGet, http.MethodPost).HandlerFunc(handler("read-write")))
+	r.Register(r.NewRoute().Path("/user").Methods(http.MethodDelete).HandlerFunc(handler("delete")))
+
+	tests := []struct {
+		method     string
+		statusCode int
+		body       string
+	}{
+		{method: http.MethodGet, statusCode: http.StatusOK, body: "read-write"},
+		{method: http.MethodPost, statusCode: http.StatusOK, body: "read-write"},
+		{method: http.MethodDelete, statusCode: http.StatusOK, body: "delete"},
+		{method: http.MethodPut, statusCode: http.StatusMethodNotAllowed},
 	}
 
-	r := &Router{}
-	r.routes = map[string]routes{}
-	r.routes[http.MethodGet] = append(r.routes[http.MethodGet], routeA, routeB)
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Method: %s", test.method), func(t *testing.T) {
+			req, _ := http.NewRequest(test.method, "http://localhost/user", nil)
+			res := httptest.NewRecorder()
+			r.ServeHTTP(res, req)
 
-	if ok, errors := r.HasErrors(); !ok || 0 == len(errors) {
-		t.Errorf("Has no errros (Status is %v, How many errors ? %v)", ok, len(errors))
+			if res.Code != test.statusCode {
+				t.Errorf("Unexpected status code (%d)", res.Code)
+			}
+
+			if test.body != "" && res.Body.String() != test.body {
+				t.Errorf("Unexpected body (%s)", res.Body.String())
+			}
+		})
 	}
 }
 
-func TestSortsRoutes(t *testing.T) {
+func TestRegisterWithoutMethods(t *testing.T) {
+	r := Classic()
+	route := r.Register(r.NewRoute().Path("/user"))
 
-	kinds := []int{0, 2, 1, 2, 1, 2, 2, 1, 0}
+	if !route.HasError() {
+		t.Error("Unexpected vaild route")
+	}
+}
 
-	r := &Router{}
-	r.routes = map[string]routes{}
+func TestRouteWithHost(t *testing.T) {
+	r := Classic()
+	handler := func(key string) func(w http.ResponseWriter, r *http.Request) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(key))
+		}
+	}
 
-	for _, v := range kinds {
-		route := &Route{
-			kind: v,
+	r.Get("/", handler("api")).Host("api.example.com")
+	r.Get("/", handler("tenant")).Host("*.example.com")
+
+	tests := map[string]string{
+		"http://api.example.com:8080/": "api",
+		"http://acme.example.com/":     "tenant",
+	}
+
+	for url, key := range tests {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, req)
+
+		if res.Body.String() != key {
+			t.Errorf("Unexpected body (Url: %s, Body: %s)", url, res.Body.String())
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	if res.Code != http.StatusNotFound {
+		t.Errorf("Unexpected status code (%d)", res.Code)
+	}
+}
+
+func TestRouteWithHostVars(t *testing.T) {
+	r := Classic()
+	r.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(Param(r, "tenant") + ":" + Param(r, "id")))
+	}).Host("{tenant}.example.com")
+
+	req, _ := http.NewRequest(http.MethodGet, "http://acme.example.com/users/42", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	if res.Body.String() != "acme:42" {
+		t.Errorf("Unexpected body (%s)", res.Body.String())
+	}
+}
+
+func TestRouteWithQueries(t *testing.T) {
+	r := Classic()
+	handler := func(key string) func(w http.ResponseWriter, r *http.Request) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(key))
+		}
+	}
+
+	r.Get("/articles", handler("json")).Queries("format", "json")
+	r.Get("/articles", handler("page")).Queries("page", "")
+	r.Get("/articles", handler("default"))
+
+	tests := map[string]string{
+		"http://localhost/articles?format=json": "json",
+		"http://localhost/articles?page=2":      "page",
+		"http://localhost/articles?format=xml":  "default",
+	}
+
+	for url, key := range tests {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, req)
+
+		if res.Body.String() != key {
+			t.Errorf("Unexpected body (Url: %s, Body: %s)", url, res.Body.String())
+		}
+	}
+}
+
+func TestRouteWithHeadersPresentAndAbsent(t *testing.T) {
+	r := Classic()
+	handler := func(key string) func(w http.ResponseWriter, r *http.Request) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(key))
+		}
+	}
+
+	r.Get("/profile", handler("user")).HeadersPresent("Authorization")
+	r.Get("/profile", handler("anonymous")).HeadersAbsent("Authorization")
+
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/profile", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	if res.Body.String() != "anonymous" {
+		t.Errorf("Unexpected body (%s)", res.Body.String())
+	}
+
+	req.Header.Set("Authorization", "Bearer token")
+	res = httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	if res.Body.String() != "user" {
+		t.Errorf("Unexpected body (%s)", res.Body.String())
+	}
+}
+
+func TestSubrouter(t *testing.T) {
+	r := Classic()
+	handler := func(key string) func(w http.ResponseWriter, r *http.Request) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(key + Param(r, "id")))
+		}
+	}
+
+	api := r.PathPrefix("/api/v1").Subrouter()
+	api.Get("/users", handler("users"))
+	api.Get("/users/:id", handler("user"))
+
+	admin := api.PathPrefix("/admin").HeadersPresent("Authorization").Subrouter()
+	admin.Get("/", handler("admin"))
+	admin.Get("/users/:id", handler("admin-user"))
+
+	r.RegisterRoute(http.MethodGet, r.PathPrefix("/api/").HandlerFunc(handler("fallback")))
+
+	tests := []struct {
+		path          string
+		authorization bool
+		body          string
+	}{
+		{path: "/api/v1/users", body: "users"},
+		{path: "/api/v1/users/42", body: "user42"},
+		{path: "/api/v1/admin/", authorization: true, body: "admin"},
+		{path: "/api/v1/admin/users/7", authorization: true, body: "admin-user7"},
+		{path: "/api/v1/admin/users/7", body: "fallback"},
+		{path: "/api/v2/users", body: "fallback"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Path: %s", test.path), func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "http://localhost"+test.path, nil)
+			if test.authorization {
+				req.Header.Set("Authorization", "Bearer token")
+			}
+			res := httptest.NewRecorder()
+			r.ServeHTTP(res, req)
+
+			if res.Body.String() != test.body {
+				t.Errorf("Unexpected body (%s)", res.Body.String())
+			}
+		})
+	}
+}
+
+func TestHostSubrouter(t *testing.T) {
+	r := Classic()
+	handler := func(key string) func(w http.ResponseWriter, r *http.Request) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(key + Param(r, "tenant")))
+		}
+	}
+
+	admin := r.Host("admin.example.com").Subrouter()
+	admin.Get("/users", handler("admin"))
+
+	tenant := r.Host("{tenant}.example.com").PathPrefix("/api").Subrouter()
+	tenant.Get("/users", handler("tenant-"))
+
+	r.Get("/users", handler("default"))
+
+	tests := map[string]string{
+		"http://admin.example.com/users":    "admin",
+		"http://acme.example.com/api/users": "tenant-acme",
+		"http://example.com/users":          "default",
+	}
+
+	for url, body := range tests {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, req)
+
+		if res.Body.String() != body {
+			t.Errorf("Unexpected body (Url: %s, Body: %s)", url, res.Body.String())
+		}
+	}
+}
+
+func TestURL(t *testing.T) {
+	r := Classic()
+	testHandler := func(w http.ResponseWriter, r *http.Request) {}
+
+	r.Get("/users", testHandler).Name("users")
+	r.Get("/users/:id", testHandler).Name("user-detail")
+	api := r.PathPrefix("/api").Subrouter()
+	api.Get("/users/:id/posts/:slug", testHandler).Name("post-detail")
+	r.Get("/article/#([a-z]{1,})", testHandler).Name("article")
+	r.Get("/dashboard", testHandler).Host("{tenant}.example.com").Name("dashboard")
+	r.Get("/billing", testHandler).Host("{tenant}.example.com").Schemes("https").Name("billing")
+	r.Get("/status", testHandler).Host("*.example.com").Name("status")
+
+	tests := []struct {
+		name  string
+		pairs []string
+		url   string
+	}{
+		{name: "users", url: "/users"},
+		{name: "user-detail", pairs: []string{"id", "42"}, url: "/users/42"},
+		{name: "post-detail", pairs: []string{"id", "42", "slug", "hello"}, url: "/api/users/42/posts/hello"},
+		{name: "users", pairs: []string{"page", "2", "sort", "name asc", "page", "3"}, url: "/users?page=2&page=3&sort=name+asc"},
+		{name: "user-detail", pairs: []string{"id", "42", "tab", "posts"}, url: "/users/42?tab=posts"},
+		{name: "dashboard", pairs: []string{"tenant", "acme", "tab", "usage"}, url: "http://acme.example.com/dashboard?tab=usage"},
+		{name: "billing", pairs: []string{"tenant", "acme"}, url: "https://acme.example.com/billing"},
+		{name: "status", url: "/status"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Name: %s", test.name), func(t *testing.T) {
+			url, err := r.URL(test.name, test.pairs...)
+			if err != nil {
+				t.Fatalf("Unexpected error (%s)", err.Error())
+			}
+
+			if url.String() != test.url {
+				t.Errorf("Unexpected url (%s)", url.String())
+			}
+		})
+	}
+
+	for _, name := range []string{"article", "unknown"} {
+		if _, err := r.URL(name); err == nil {
+			t.Errorf("Expected a error (%s)", name)
+		}
+	}
+
+	if _, err := r.URL("user-detail", "id"); err == nil {
+		t.Error("Expected a error")
+	}
+
+	if _, err := r.URL("dashboard"); err == nil {
+		t.Error("Expected a error")
+	}
+}
+
+func TestWalk(t *testing.T) {
+	r := Classic()
+	testHandler := func(w http.ResponseWriter, r *http.Request) {}
+
+	r.Get("/users", testHandler).Name("users")
+	r.Post("/users", testHandler)
+	r.Register(r.NewRoute().Path("/users/:id").Methods(http.MethodGet, http.MethodPut).HandlerFunc(testHandler))
+	api := r.PathPrefix("/api").Subrouter()
+	api.Delete("/users/:id", testHandler)
+
+	visited := []string{}
+	err := r.Walk(func(route RouteInterface) error {
+		visited = append(visited, fmt.Sprintf("%v %s %s", route.GetMethods(), route.GetPath(), route.GetName()))
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error (%s)", err.Error())
+	}
+
+	expected := []string{
+		"[DELETE] /api/users/:id ",
+		"[GET] /users users",
+		"[GET PUT] /users/:id ",
+		"[POST] /users ",
+	}
+
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("Unexpected routes (%v)", visited)
+	}
+}
+
+func TestWalkFail(t *testing.T) {
+	r := Classic()
+	testHandler := func(w http.ResponseWriter, r *http.Request) {}
+	r.Get("/users", testHandler)
+	r.Get("/articles", testHandler)
+
+	var count int
+	err := r.Walk(func(route RouteInterface) error {
+		count++
+		return errors.New("stop")
+	})
+
+	if err == nil || count != 1 {
+		t.Errorf("Unexpected walk (Error: %v, Count: %d)", err, count)
+	}
+}
+
+func TestStrictSlash(t *testing.T) {
+	testHandler := func(w http.ResponseWriter, r *http.Request) {}
+
+	r := Classic()
+	r.StrictSlash = true
+	r.Get("/users", testHandler)
+	r.Head("/users", testHandler)
+	r.Post("/articles/", testHandler)
+
+	api := r.PathPrefix("/api").Subrouter()
+	api.StrictSlash = false
+	api.Get("/users", testHandler)
+
+	tests := []struct {
+		method     string
+		url        string
+		statusCode int
+		location   string
+	}{
+		{http.MethodGet, "/users/", http.StatusMovedPermanently, "/users"},
+		{http.MethodGet, "/users/?page=2", http.StatusMovedPermanently, "/users?page=2"},
+		{http.MethodHead, "/users/", http.StatusMovedPermanently, "/users"},
+		{http.MethodPost, "/articles", http.StatusPermanentRedirect, "/articles/"},
+		{http.MethodGet, "/users", http.StatusOK, ""},
+		{http.MethodGet, "/api/users/", http.StatusNotFound, ""},
+		{http.MethodGet, "/articles", http.StatusNotFound, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.method+" "+test.url, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(test.method, test.url, nil))
+
+			if w.Code != test.statusCode {
+				t.Errorf("Unexpected status code (Expected: %d, Actual: %d)", test.statusCode, w.Code)
+			}
+
+			if location := w.Header().Get("Location"); location != test.location {
+				t.Errorf("Unexpected location (Expected: %q, Actual: %q)", test.location, location)
+			}
+		})
+	}
+}
+
+func TestCaseInsensitivePath(t *testing.T) {
+	r := Classic()
+	r.RedirectCanonicalCase = true
+
+	handler := func(key string) func(w http.ResponseWriter, r *http.Request) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "%s%s %v", key, r.URL.Path, GetVars(r))
 		}
+	}
+
+	r.Get("/About", handler("about"))
+	r.Get("/Users/:name/Posts/{slug:[a-zA-Z-]+}", handler("post"))
+	r.Get("/articles/#([a-zA-Z0-9]{10,})", handler("article"))
+	r.PathPrefix("/Static/").Subrouter().Get("/*filepath", handler("static"))
 
-		r.routes[http.MethodGet] = append(r.routes[http.MethodGet], route)
+	tests := []struct {
+		url        string
+		statusCode int
+		location   string
+		body       string
+	}{
+		{"/About", http.StatusOK, "", "about/About map[]"},
+		{"/about?ref=ad", http.StatusMovedPermanently, "/About?ref=ad", ""},
+		{"/Users/JohnDoe/Posts/Hello-World", http.StatusOK, "", "post/Users/JohnDoe/Posts/Hello-World map[name:JohnDoe slug:Hello-World]"},
+		{"/USERS/JohnDoe/posts/Hello-World", http.StatusMovedPermanently, "/Users/JohnDoe/Posts/Hello-World", ""},
+		{"/ARTICLES/B00KY1U7GM", http.StatusOK, "", "article/ARTICLES/B00KY1U7GM map[var:B00KY1U7GM]"},
+		{"/Static/css/App.css", http.StatusOK, "", "static/Static/css/App.css map[filepath:css/App.css]"},
+		{"/static/css/App.css", http.StatusMovedPermanently, "/Static/css/App.css", ""},
 	}
 
+	for _, test := range tests {
+		t.Run(test.url, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.url, nil))
+
+			if w.Code != test.statusCode {
+				t.Errorf("Unexpected status code (Expected: %d, Actual: %d)", test.statusCode, w.Code)
+			}
+
+			if location := w.Header().Get("Location"); location != test.location {
+				t.Errorf("Unexpected location (Expected: %q, Actual: %q)", test.location, location)
+			}
+
+			if test.body != "" && w.Body.String() != test.body {
+				t.Errorf("Unexpected body (Expected: %q, Actual: %q)", test.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCaseSensitivePath(t *testing.T) {
+	r := Classic()
+	r.CaseSensitiveURL = true
+	r.Get("/About", func(w http.ResponseWriter, r *http.Request) {})
+
+	for url, statusCode := range map[string]int{"/About": http.StatusOK, "/about": http.StatusNotFound} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+
+		if w.Code != statusCode {
+			t.Errorf("Unexpected status code (Url: %s, Expected: %d, Actual: %d)", url, statusCode, w.Code)
+		}
+	}
+}
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		method     string
+		url        string
+		redirect   bool
+		statusCode int
+		location   string
+		body       string
+	}{
+		{http.MethodGet, "/api//users", true, http.StatusMovedPermanently, "/api/users", ""},
+		{http.MethodGet, "/api/./articles/../users?page=2", true, http.StatusMovedPermanently, "/api/users?page=2", ""},
+		{http.MethodPost, "/api//users", true, http.StatusPermanentRedirect, "/api/users", ""},
+		{http.MethodGet, "/api/users", true, http.StatusOK, "", "/api/users"},
+		{http.MethodGet, "/api//users", false, http.StatusOK, "", "/api/users"},
+		{http.MethodPost, "/api/../api/users/", false, http.StatusOK, "", "/api/users/"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s %s (Redirect: %v)", test.method, test.url, test.redirect), func(t *testing.T) {
+			r := Classic()
+			r.SkipCleanRedirect = !test.redirect
+
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(r.URL.Path))
+			}
+			r.Get("/api/users", handler)
+			r.Post("/api/users", handler)
+			r.Post("/api/users/", handler)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(test.method, test.url, nil))
+
+			if w.Code != test.statusCode {
+				t.Errorf("Unexpected status code (Expected: %d, Actual: %d)", test.statusCode, w.Code)
+			}
+
+			if location := w.Header().Get("Location"); location != test.location {
+				t.Errorf("Unexpected location (Expected: %q, Actual: %q)", test.location, location)
+			}
+
+			if w.Body.String() != test.body {
+				t.Errorf("Unexpected body (Expected: %q, Actual: %q)", test.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestNotFoundHandler(t *testing.T) {
+	r := Classic()
+	r.Get("/users", func(w http.ResponseWriter, r *http.Request) {})
+	r.RegisterRoute(http.MethodGet, r.NewRoute().Path("/articles"))
+
+	// set after the routes are registered
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"not found"}`))
+	})
+
+	for _, path := range []string{"/unknown", "/articles"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if w.Code != http.StatusNotFound || w.Body.String() != `{"error":"not found"}` || w.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected response (Path: %s, Code: %d, Body: %s)", path, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestDefaultNotFoundHandler(t *testing.T) {
+	r := Classic()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+
+	if w.Code != http.StatusNotFound || w.Body.String() != "404 page not found\n" {
+		t.Errorf("Unexpected response (Code: %d, Body: %s)", w.Code, w.Body.String())
+	}
+}
+
+func TestMethodNotAllowedHandler(t *testing.T) {
+	r := Classic()
+	r.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		fmt.Fprintf(w, `{"error":"method not allowed","allow":%q}`, w.Header().Get("Allow"))
+	})
+	r.Get("/users", func(w http.ResponseWriter, r *http.Request) {})
+	r.Post("/users", func(w http.ResponseWriter, r *http.Request) {})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users", nil))
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Unexpected status code (%d)", w.Code)
+	}
+
+	if body := `{"error":"method not allowed","allow":"GET, POST"}`; w.Body.String() != body {
+		t.Errorf("Unexpected body (Expected: %s, Actual: %s)", body, w.Body.String())
+	}
+
+	if allow := w.Header().Get("Allow"); allow != "GET, POST" {
+		t.Errorf("Unexpected Allow header (%s)", allow)
+	}
+}
+
+func TestMount(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", r.Method, r.URL.Path)
+	})
+
+	r := Classic()
+	r.Mount("/metrics", echo, false)
+	r.Mount("/static/", echo, true)
+	r.PathPrefix("/tenants/:id").Subrouter().Mount("/files", echo, true)
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodGet, "/metrics", "GET /metrics"},
+		{http.MethodPost, "/metrics/push", "POST /metrics/push"},
+		{http.MethodGet, "/static/css/app.css", "GET /css/app.css"},
+		{http.MethodDelete, "/static/", "DELETE /"},
+		{http.MethodPut, "/tenants/1/files/report.pdf", "PUT /report.pdf"},
+		{http.MethodGet, "/metricsfoo", "404 page not found\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.method+" "+test.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+
+			if w.Body.String() != test.body {
+				t.Errorf("Unexpected body (Expected: %q, Actual: %q)", test.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRegisterParamType(t *testing.T) {
+	r := Classic()
+
+	err := r.RegisterParamType("uuid", "[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error (%s)", err.Error())
+	}
+
+	err = r.RegisterParamType("year", "[0-9]+", func(value string) (interface{}, error) {
+		year, err := strconv.Atoi(value)
+		if err == nil && year < 1970 {
+			err = errors.New("year is before 1970")
+		}
+		return year, err
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error (%s)", err.Error())
+	}
+
+	r.Get("/orders/:uuid", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %v", Param(r, ":uuid"), ParamValue(r, ":uuid"))
+	})
+
+	// registered on the root router, available on the subrouters
+	r.PathPrefix("/reports").Subrouter().Get("/:year", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%d", ParamValue(r, ":year").(int)+1)
+	})
+
+	tests := []struct {
+		path       string
+		statusCode int
+		body       string
+	}{
+		{"/orders/123e4567-e89b-12d3-a456-426614174000", http.StatusOK, "123e4567-e89b-12d3-a456-426614174000 <nil>"},
+		{"/orders/42", http.StatusNotFound, "404 page not found\n"},
+		{"/reports/2024", http.StatusOK, "2025"},
+		{"/reports/1900", http.StatusBadRequest, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+			if w.Code != test.statusCode {
+				t.Errorf("Unexpected status code (Expected: %d, Actual: %d)", test.statusCode, w.Code)
+			}
+
+			if test.body != "" && w.Body.String() != test.body {
+				t.Errorf("Unexpected body (Expected: %q, Actual: %q)", test.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRegisterParamTypeFail(t *testing.T) {
+	r := Classic()
+
+	tests := map[string]string{
+		"":       "[0-9]+",
+		"a-b":    "[0-9]+",
+		"path":   ".+",
+		"dir":    "[a-z/]+",
+		"slash":  "a/b",
+		"broken": "[0-9",
+	}
+
+	for name, pattern := range tests {
+		if err := r.RegisterParamType(name, pattern, nil); err == nil {
+			t.Errorf("Expected a error (Name: %s, Pattern: %s)", name, pattern)
+		}
+	}
+}
+
+func TestManyVars(t *testing.T) {
+	r := Classic()
+
+	var vars Vars
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		vars = GetVars(r)
+	}
+	r.Get("/:string/:number/:string/:number", handler)
+	r.Get("/users/:id/friends/:id/posts", handler)
+	r.Get("/articles/#(?P<slug>[a-z-]+)", handler)
+
+	tests := map[string]Vars{
+		"/abc/1/def/2":             {":string": "abc", ":number": "1", ":string1": "def", ":number1": "2"},
+		"/users/1/friends/2/posts": {"id": "1", "id1": "2"},
+		"/articles/hello-world":    {"var": "hello-world", "slug": "hello-world"},
+	}
+
+	for path, expected := range tests {
+		vars = nil
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+
+		if !reflect.DeepEqual(vars, expected) {
+			t.Errorf("Unexpected vars (Path: %s, Expected: %v, Actual: %v)", path, expected, vars)
+		}
+	}
+}
+
+func TestAnyAndMatch(t *testing.T) {
+	r := Classic()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method))
+	}
+
+	r.Any("/proxy", handler)
+	r.Match([]string{http.MethodGet, http.MethodPost}, "/login", handler)
+
+	tests := []struct {
+		method     string
+		path       string
+		statusCode int
+	}{
+		{http.MethodGet, "/proxy", http.StatusOK},
+		{http.MethodPatch, "/proxy", http.StatusOK},
+		{http.MethodDelete, "/proxy", http.StatusOK},
+		{http.MethodGet, "/login", http.StatusOK},
+		{http.MethodPost, "/login", http.StatusOK},
+		{http.MethodPut, "/login", http.StatusMethodNotAllowed},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+
+		if w.Code != test.statusCode {
+			t.Errorf("Unexpected status code (Method: %s, Path: %s, Code: %d)", test.method, test.path, w.Code)
+		}
+
+		if w.Code == http.StatusOK && w.Body.String() != test.method {
+			t.Errorf("Unexpected body (%s)", w.Body.String())
+		}
+	}
+
+	if route := r.Match([]string{"GGET"}, "/invalid", handler); !route.HasError() {
+		t.Error("Expected a error")
+	}
+
+	if route := r.Match(nil, "/invalid", handler); !route.HasError() {
+		t.Error("Expected a error")
+	}
+}
+
+func TestRouteMetaFromMiddleware(t *testing.T) {
+	r := Classic()
+
+	var scope interface{}
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			scope = RouteMeta(req, "scope")
+			next.ServeHTTP(w, req)
+		})
+	})
+	r.Get("/orders", func(w http.ResponseWriter, r *http.Request) {}).WithMeta("scope", "orders:read")
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	if scope != "orders:read" {
+		t.Errorf("Unexpected scope (%v)", scope)
+	}
+}
+
+func TestMatchRequest(t *testing.T) {
+	r := Classic()
+	if err := r.RegisterParamType("year", "[0-9]+", func(value string) (interface{}, error) {
+		return strconv.Atoi(value)
+	}); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	called := false
+	users := r.Get("/users/:id", func(w http.ResponseWriter, req *http.Request) { called = true })
+	r.Put("/users/:id", nil)
+	r.Post("/users/:id", nil)
+	r.Get("/about", nil)
+	r.Get("/reports/:year", nil)
+
+	match, ok := r.MatchRequest(httptest.NewRequest(http.MethodGet, "/Users/42", nil))
+	if !ok || match.Route != users || !reflect.DeepEqual(match.Vars, Vars{"id": "42"}) || match.Err != nil {
+		t.Errorf("Unexpected match (%+v)", match)
+	}
+	if called {
+		t.Error("Unexpected call of the handler")
+	}
+
+	if match, ok := r.MatchRequest(httptest.NewRequest(http.MethodGet, "/about", nil)); !ok || match.Vars != nil {
+		t.Errorf("Unexpected match (%+v)", match)
+	}
+
+	match, ok = r.MatchRequest(httptest.NewRequest(http.MethodDelete, "/users/42", nil))
+	if ok || match.Err != ErrMethodMismatch || !reflect.DeepEqual(match.AllowedMethods, []string{http.MethodGet, http.MethodPost, http.MethodPut}) {
+		t.Errorf("Unexpected match (%+v)", match)
+	}
+
+	if match, ok := r.MatchRequest(httptest.NewRequest(http.MethodGet, "/unknown", nil)); ok || match.Err != ErrNotFound || match.Route != nil {
+		t.Errorf("Unexpected match (%+v)", match)
+	}
+
+	match, ok = r.MatchRequest(httptest.NewRequest(http.MethodGet, "/reports/99999999999999999999", nil))
+	var bpe *BadParamError
+	if ok || match.Route == nil || !errors.As(match.Err, &bpe) {
+		t.Errorf("Unexpected match (%+v)", match)
+	}
+}
+
+func TestContentTypes(t *testing.T) {
+	r := Classic()
+	r.Post("/users", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "json")
+	}).ContentTypes("application/json")
+	r.Post("/users", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "form")
+	}).ContentTypes("application/x-www-form-urlencoded")
+
+	if ok, errs := r.HasErrors(); ok {
+		t.Fatalf("Unexpected errors (%v)", errs)
+	}
+
+	tests := map[string]string{
+		"application/json; charset=utf-8":   "json",
+		"application/x-www-form-urlencoded": "form",
+		"text/plain":                        "404 page not found\n",
+	}
+
+	for contentType, body := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/users", nil)
+		req.Header.Set("Content-Type", contentType)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Body.String() != body {
+			t.Errorf("Expected body %q, got %q", body, w.Body.String())
+		}
+	}
+}
+
+func TestSchemesBehindProxy(t *testing.T) {
+	r := Classic()
+	r.Get("/account", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "account")
+	}).Schemes("https")
+
+	req := httptest.NewRequest(http.MethodGet, "/account", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Unexpected status code (%d)", w.Code)
+	}
+
+	r.TrustForwardedProto = true
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Body.String() != "account" {
+		t.Errorf("Unexpected body (%s)", w.Body.String())
+	}
+
+	if req.URL.Scheme != "" {
+		t.Errorf("Unexpected change of the request (%s)", req.URL.Scheme)
+	}
+}
+
+func TestRedirectHTTPS(t *testing.T) {
+	r := Classic()
+	r.RedirectHTTPS = true
+	r.Get("/users", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "users")
+	})
+	r.Post("/users", nil)
+
+	public := r.PathPrefix("/public").Subrouter()
+	public.RedirectHTTPS = false
+	public.Get("/status", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "status")
+	})
+
+	tests := []struct {
+		method   string
+		target   string
+		proto    string
+		code     int
+		location string
+	}{
+		{http.MethodGet, "http://example.com:8080/users?page=2", "", http.StatusMovedPermanently, "https://example.com/users?page=2"},
+		{http.MethodPost, "/users", "", http.StatusPermanentRedirect, "https://example.com/users"},
+		{http.MethodGet, "/unknown", "", http.StatusMovedPermanently, "https://example.com/unknown"},
+		{http.MethodGet, "http://[::1]:80/users", "", http.StatusMovedPermanently, "https://[::1]/users"},
+		{http.MethodGet, "/public/status", "", http.StatusOK, ""},
+		{http.MethodGet, "/users", "https", http.StatusOK, ""},
+	}
+
+	r.TrustForwardedProto = true
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.target, nil)
+		if test.proto != "" {
+			req.Header.Set("X-Forwarded-Proto", test.proto)
+		}
+		req.URL.Scheme, req.URL.Host = "", ""
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != test.code || w.Header().Get("Location") != test.location {
+			t.Errorf("Unexpected response for %s %s (%d, %q)", test.method, test.target, w.Code, w.Header().Get("Location"))
+		}
+	}
+}
+
+func TestRemoteIPs(t *testing.T) {
+	r := Classic()
+	internal := r.PathPrefix("/internal").RemoteIPs("10.0.0.0/8").Subrouter()
+	internal.Get("/status", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "status")
+	})
+
+	tests := map[string]int{
+		"10.0.0.1:1234":    http.StatusOK,
+		"203.0.113.7:1234": http.StatusNotFound,
+	}
+
+	for remoteAddr, code := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/internal/status", nil)
+		req.RemoteAddr = remoteAddr
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != code {
+			t.Errorf("Expected status code %d for %s, got %d", code, remoteAddr, w.Code)
+		}
+	}
+
+	if route := r.Get("/admin", nil).RemoteIPs("10.0.0.0/x"); !route.HasError() {
+		t.Error("Expected an error")
+	}
+}
+
+func TestNotMatcher(t *testing.T) {
+	r := Classic()
+	r.Register(r.PathPrefix("/").Methods(http.MethodGet).HeadersPresent("X-Debug").AddMatcher(Not(MatcherFunc(func(req *http.Request) bool {
+		return strings.HasPrefix(req.URL.Path, "/static/")
+	}))).HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "debug")
+	}))
+
+	tests := map[string]string{
+		"/users":          "debug",
+		"/static/app.css": "404 page not found\n",
+	}
+
+	for path, body := range tests {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Debug", "1")
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Body.String() != body {
+			t.Errorf("Expected body %q, got %q", body, w.Body.String())
+		}
+	}
+}
+
+func TestRegisterRouteWhileServing(t *testing.T) {
+	r := Classic()
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "home")
+	})
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+				if w.Body.String() != "home" {
+					t.Errorf("Unexpected body (%s)", w.Body.String())
+					return
+				}
+			}
+		}()
+	}
+
+	plugins := r.PathPrefix("/plugins").Subrouter()
+	for i := 0; i < 50; i++ {
+		name := strconv.Itoa(i)
+		plugins.Register(plugins.NewRoute().Path("/"+name).Methods(http.MethodGet, http.MethodPost).HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			fmt.Fprint(w, name)
+		}))
+	}
+
+	close(done)
+	wg.Wait()
+
+	if ok, errs := r.HasErrors(); ok {
+		t.Fatalf("Unexpected errors (%v)", errs)
+	}
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, "/plugins/42", nil))
+		if w.Body.String() != "42" {
+			t.Errorf("Expected body 42 for %s, got %q", method, w.Body.String())
+		}
+	}
+}
+
+func TestRegisterRouteKeepsTable(t *testing.T) {
+	r := Classic()
+	r.Get("/a", nil)
+
+	before := r.table()
+	r.Get("/b", nil)
+
+	if len(before.routes[http.MethodGet]) != 1 {
+		t.Errorf("Published table was modified (%d routes)", len(before.routes[http.MethodGet]))
+	}
+	if len(r.table().routes[http.MethodGet]) != 2 {
+		t.Errorf("Route wasn't published (%d routes)", len(r.table().routes[http.MethodGet]))
+	}
+}
+
+func TestRemove(t *testing.T) {
+	r := Classic()
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "ok")
+	}
+	r.Match([]string{http.MethodGet, http.MethodPost}, "/plugins/stats", handler).Name("plugin-stats")
+	route := r.Get("/plugins/info", handler)
+	r.Get("/", handler)
+
+	before := r.table()
+
+	if err := r.Remove("plugin-stats"); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+	if err := r.Remove(route); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{http.MethodGet, "/plugins/stats", http.StatusNotFound},
+		{http.MethodPost, "/plugins/stats", http.StatusNotFound},
+		{http.MethodGet, "/plugins/info", http.StatusNotFound},
+		{http.MethodGet, "/", http.StatusOK},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+		if w.Code != test.code {
+			t.Errorf("Expected %d for %s %s, got %d", test.code, test.method, test.path, w.Code)
+		}
+	}
+
+	if len(before.routes[http.MethodGet]) != 3 {
+		t.Errorf("Published table was modified (%d routes)", len(before.routes[http.MethodGet]))
+	}
+
+	if _, err := r.URL("plugin-stats"); err == nil {
+		t.Error("Removed route is still named")
+	}
+
+	// the route can be registered again
+	r.Register(r.NewRoute().Path("/plugins/info").Methods(http.MethodGet).HandlerFunc(handler))
+	if ok, errs := r.HasErrors(); ok {
+		t.Errorf("Unexpected errors (%v)", errs)
+	}
+}
+
+func TestRemoveFail(t *testing.T) {
+	r := Classic()
+	r.Get("/", nil)
+
+	if err := r.Remove("missing"); err == nil || err.Error() != `mux: route "missing" not found` {
+		t.Errorf("Unexpected error (%v)", err)
+	}
+	if err := r.Remove(r.NewRoute().Path("/other")); err == nil || err.Error() != "mux: route /other not found" {
+		t.Errorf("Unexpected error (%v)", err)
+	}
+	if err := r.Remove(42); err == nil {
+		t.Error("Expected an error for an int")
+	}
+}
+
+func TestDisableRoute(t *testing.T) {
+	r := Classic()
+	handler := func(body string) func(w http.ResponseWriter, req *http.Request) {
+		return func(w http.ResponseWriter, req *http.Request) {
+			fmt.Fprint(w, body)
+		}
+	}
+	search := r.Get("/search", handler("search"))
+	r.Get("/#(search|find)", handler("fallback"))
+	export := r.Get("/export", handler("export"))
+
+	search.Disable()
+	export.Disable()
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/search", http.StatusOK, "fallback"},
+		{"/export", http.StatusNotFound, "404 page not found\n"},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if w.Code != test.code || w.Body.String() != test.body {
+			t.Errorf("Expected %d %q for %s, got %d %q", test.code, test.body, test.path, w.Code, w.Body.String())
+		}
+	}
+
+	if !export.IsDisabled() {
+		t.Error("Route isn't disabled")
+	}
+
+	export.Enable()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export", nil))
+	if w.Body.String() != "export" {
+		t.Errorf("Unexpected body (%s)", w.Body.String())
+	}
+}
+
+func TestDisabledUnavailable(t *testing.T) {
+	r := Classic()
+	r.Get("/public", nil).Disable()
+
+	api := r.PathPrefix("/api").Subrouter()
+	api.DisabledUnavailable = true
+	api.Get("/users/:id", nil).Disable()
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{http.MethodGet, "/api/users/42", http.StatusServiceUnavailable},
+		{http.MethodGet, "/api/groups/42", http.StatusNotFound},
+		{http.MethodPost, "/api/users/42", http.StatusNotFound},
+		{http.MethodGet, "/public", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+		if w.Code != test.code {
+			t.Errorf("Expected %d for %s %s, got %d", test.code, test.method, test.path, w.Code)
+		}
+	}
+}
+
+func TestTraceDisabledRoute(t *testing.T) {
+	r := Classic()
+	r.Trace = true
+	r.Get("/users", nil).Disable()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	if trace := w.Header().Get(TraceHeader); trace != "GET /users: disabled" {
+		t.Errorf("Unexpected trace (%s)", trace)
+	}
+}
+
+func TestHandleErrors(t *testing.T) {
+	testHandler := func(w http.ResponseWriter, r *http.Request) {}
+
+	paths := []string{
+		"/article/#([a-z]",
+		"/articles/{id:[0-9}",
+		"/static/*",
+		"api",
+	}
+
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			r := Classic()
+
+			if route, err := r.HandleFunc(http.MethodGet, path, testHandler); err == nil || route == nil {
+				t.Errorf("Expected a error (Route: %v)", route)
+			}
+
+			if _, err := r.Handle(http.MethodGet, path, http.HandlerFunc(testHandler)); err == nil {
+				t.Error("Expected a error")
+			}
+
+			defer func() {
+				if recover() == nil {
+					t.Error("Expected a panic")
+				}
+			}()
+			r.MustHandleFunc(http.MethodGet, path, testHandler)
+		})
+	}
+}
+
+func TestMustHandle(t *testing.T) {
+	r := Classic()
+
+	route := r.MustHandle(http.MethodGet, "/api/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	if route.HasError() || route.GetPath() != "/api/users/:id" {
+		t.Errorf("Unexpected route (%v)", route.GetError())
+	}
+}
+
+func TestHasErrors(t *testing.T) {
+	routeA := &Route{
+		err: errors.New("Bad route"),
+	}
+	routeB := &Route{
+		err: errors.New("Bad method"),
+	}
+
+	r := &Router{}
+	r.store = newRouteStore(map[string]routes{http.MethodGet: {routeA, routeB}})
+
+	if ok, errors := r.HasErrors(); !ok || 0 == len(errors) {
+		t.Errorf("Has no errros (Status is %v, How many errors ? %v)", ok, len(errors))
+	}
+}
+
+func TestRouteConflicts(t *testing.T) {
+	testHandler := func(w http.ResponseWriter, r *http.Request) {}
+
+	tests := []struct {
+		title    string
+		conflict bool
+		register func(r *Router) RouteInterface
+	}{
+		{
+			title:    "Same static path",
+			conflict: true,
+			register: func(r *Router) RouteInterface {
+				r.Get("/users", testHandler)
+				return r.Get("/users", testHandler)
+			},
+		},
+		{
+			title:    "Same path with other var names",
+			conflict: true,
+			register: func(r *Router) RouteInterface {
+				r.Get("/users/:id", testHandler)
+				return r.Get("/users/:name", testHandler)
+			},
+		},
+		{
+			title:    "Same path and host",
+			conflict: true,
+			register: func(r *Router) RouteInterface {
+				r.Register(r.NewRoute().Host("{tenant}.example.com").Path("/users").Methods(http.MethodGet).HandlerFunc(testHandler))
+				return r.Register(r.NewRoute().Host("{tenant}.example.com").Path("/users").Methods(http.MethodGet, http.MethodPost).HandlerFunc(testHandler))
+			},
+		},
+		{
+			title:    "Same path and other method",
+			conflict: false,
+			register: func(r *Router) RouteInterface {
+				r.Get("/users", testHandler)
+				return r.Post("/users", testHandler)
+			},
+		},
+		{
+			title:    "Same path and other typed var",
+			conflict: false,
+			register: func(r *Router) RouteInterface {
+				r.Get("/users/:number", testHandler)
+				return r.Get("/users/:string", testHandler)
+			},
+		},
+		{
+			title:    "Same path and additional matcher",
+			conflict: false,
+			register: func(r *Router) RouteInterface {
+				r.Register(r.NewRoute().Path("/users").Methods(http.MethodGet).HeadersPresent("Authorization").HandlerFunc(testHandler))
+				return r.Get("/users", testHandler)
+			},
+		},
+		{
+			title:    "Same path and custom matcher",
+			conflict: false,
+			register: func(r *Router) RouteInterface {
+				route := r.NewRoute().Path("/users").Methods(http.MethodGet).HandlerFunc(testHandler)
+				route.AddMatcher(MatcherFunc(func(*http.Request) bool { return true }))
+				r.Register(route)
+
+				route = r.NewRoute().Path("/users").Methods(http.MethodGet).HandlerFunc(testHandler)
+				route.AddMatcher(MatcherFunc(func(*http.Request) bool { return true }))
+				return r.Register(route)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.title, func(t *testing.T) {
+			r := Classic()
+			route := test.register(r)
+
+			if route.HasError() != test.conflict {
+				t.Errorf("Unexpected conflict (%v)", route.GetError())
+			}
+
+			if ok, _ := r.HasErrors(); ok != test.conflict {
+				t.Errorf("Unexpected router errors (%v)", ok)
+			}
+		})
+	}
+}
+
+func TestSortsRoutes(t *testing.T) {
+
+	kinds := []int{0, 2, 1, 2, 1, 2, 2, 1, 0}
+
+	rs := routes{}
+
+	for _, v := range kinds {
+		route := &Route{
+			kind: v,
+		}
+
+		rs = append(rs, route)
+	}
+
+	r := &Router{}
+	r.store = newRouteStore(map[string]routes{http.MethodGet: rs})
 	r.SortRoutes()
 
-	routes := r.routes[http.MethodGet]
+	routes := r.table().routes[http.MethodGet]
 
 	if routes[len(routes)-1].Kind() != kindNormalPath || routes[len(routes)-3].Kind() != kindVarsPath || routes[0].Kind() != kindRegexPath {
 		t.Errorf("Sort of routes is bad")
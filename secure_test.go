@@ -0,0 +1,61 @@
+package mux
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	r := Classic()
+	r.Use(SecurityHeaders(SecurityOptions{HSTSIncludeSubdomains: true, ContentSecurityPolicy: "default-src 'self';"}))
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {})
+	r.Get("/embed", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Frame-Options", "SAMEORIGIN")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.TLS = &tls.ConnectionState{}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expected := map[string]string{
+		"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "DENY",
+		"Referrer-Policy":           "strict-origin-when-cross-origin",
+		"Content-Security-Policy":   "default-src 'self'; frame-ancestors 'none'",
+	}
+	for name, value := range expected {
+		if got := w.Header().Get(name); got != value {
+			t.Errorf("Unexpected %s (%s)", name, got)
+		}
+	}
+
+	// no HSTS over HTTP, the handler overrides a header
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/embed", nil))
+	if w.Header().Get("Strict-Transport-Security") != "" || w.Header().Get("X-Frame-Options") != "SAMEORIGIN" {
+		t.Errorf("Unexpected headers (%v)", w.Header())
+	}
+}
+
+func TestSecurityHeadersOmitted(t *testing.T) {
+	r := Classic()
+	r.Use(SecurityHeaders(SecurityOptions{HSTSMaxAge: -1, FrameOptions: "-", ReferrerPolicy: "no-referrer"}))
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.TLS = &tls.ConnectionState{}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	h := w.Header()
+	if h.Get("Strict-Transport-Security") != "" || h.Get("X-Frame-Options") != "" || h.Get("Content-Security-Policy") != "" {
+		t.Errorf("Unexpected headers (%v)", h)
+	}
+	if h.Get("Referrer-Policy") != "no-referrer" || h.Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("Unexpected headers (%v)", h)
+	}
+}
@@ -0,0 +1,145 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ServeOptions configures Router.Serve.
+type ServeOptions struct {
+	// Server is the server to run, e.g. with timeouts or a TLS config, its
+	// Addr and Handler are set by Serve. It defaults to a server with a
+	// ReadHeaderTimeout of 10 seconds.
+	Server *http.Server
+	// H2C serves HTTP/2 without TLS (h2c) besides HTTP/1, e.g. for gRPC-web
+	// or internal HTTP/2 clients. The server detects the HTTP/2 connection
+	// preface of clients with prior knowledge. The HTTP/1.1 Upgrade to h2c
+	// is deprecated by RFC 9113 and not supported, such requests are served
+	// with HTTP/1.1.
+	H2C bool
+	// Listener is the listener to serve, instead of listening on addr.
+	Listener net.Listener
+	// Context stops the server like a signal when it is done.
+	Context context.Context
+	// Signals are the signals which stop the server, they default to
+	// os.Interrupt and SIGTERM.
+	Signals []os.Signal
+	// ShutdownTimeout is the time the in-flight requests get to finish on
+	// shutdown, it defaults to 30 seconds. The remaining connections are
+	// closed after it.
+	ShutdownTimeout time.Duration
+	// Health is set ready once the server listens, and not ready when it
+	// shuts down (see Health.SetReady).
+	Health *Health
+	// DrainDelay is the time between clearing the ready flag of Health and
+	// closing the listener, so load balancers notice the failing readiness
+	// checks and stop sending requests.
+	DrainDelay time.Duration
+	// OnShutdown are called in order after the server stopped, e.g. to
+	// close databases. Each gets a context with the ShutdownTimeout.
+	OnShutdown []func(ctx context.Context) error
+}
+
+// Serve serves the router on the TCP address addr until a signal arrives,
+// and shuts down gracefully then, e.g.:
+//
+//     err := r.Serve(":8080", mux.ServeOptions{
+//         Health:     health,
+//         DrainDelay: 5 * time.Second,
+//         OnShutdown: []func(context.Context) error{closeDatabase},
+//     })
+//
+// On shutdown it stops accepting connections, waits for the in-flight
+// requests up to the ShutdownTimeout and runs the shutdown hooks in order.
+// It returns nil after a clean shutdown, otherwise the errors of the routes,
+// the server, the shutdown and the hooks.
+func (r *Router) Serve(addr string, opts ServeOptions) error {
+	if ok, errs := r.HasErrors(); ok {
+		return errors.Join(errs...)
+	}
+	r.SortRoutes()
+
+	if opts.Server == nil {
+		opts.Server = &http.Server{ReadHeaderTimeout: 10 * time.Second}
+	}
+	if opts.Context == nil {
+		opts.Context = context.Background()
+	}
+	if len(opts.Signals) == 0 {
+		opts.Signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	if opts.ShutdownTimeout <= 0 {
+		opts.ShutdownTimeout = 30 * time.Second
+	}
+
+	server := opts.Server
+	server.Addr = addr
+	server.Handler = r
+	if opts.H2C {
+		if server.Protocols == nil {
+			server.Protocols = new(http.Protocols)
+			server.Protocols.SetHTTP1(true)
+			server.Protocols.SetHTTP2(true)
+		}
+		server.Protocols.SetUnencryptedHTTP2(true)
+	}
+
+	ln := opts.Listener
+	if ln == nil {
+		var err error
+		if ln, err = net.Listen("tcp", addr); err != nil {
+			return err
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(opts.Context, opts.Signals...)
+	defer stop()
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(ln)
+	}()
+	if opts.Health != nil {
+		opts.Health.SetReady(true)
+	}
+	r.logger().LogAttrs(ctx, slog.LevelInfo, "mux: serving", slog.String("addr", ln.Addr().String()))
+
+	var errs []error
+	select {
+	case err := <-served:
+		errs = append(errs, err)
+	case <-ctx.Done():
+		r.logger().LogAttrs(context.Background(), slog.LevelInfo, "mux: shutting down")
+		if opts.Health != nil {
+			opts.Health.SetReady(false)
+			time.Sleep(opts.DrainDelay)
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), opts.ShutdownTimeout)
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			// the deadline passed, the remaining connections are closed
+			errs = append(errs, err, server.Close())
+		}
+		cancel()
+		if err := <-served; err != http.ErrServerClosed {
+			errs = append(errs, err)
+		}
+	}
+	if opts.Health != nil {
+		opts.Health.SetReady(false)
+	}
+
+	for _, hook := range opts.OnShutdown {
+		hookCtx, cancel := context.WithTimeout(context.Background(), opts.ShutdownTimeout)
+		errs = append(errs, hook(hookCtx))
+		cancel()
+	}
+	return errors.Join(errs...)
+}
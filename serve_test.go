@@ -0,0 +1,212 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServe(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started, release := make(chan struct{}), make(chan struct{})
+
+	r := Classic()
+	r.Get("/slow", func(w http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("done"))
+	})
+
+	health := NewHealth(HealthOptions{})
+	ctx, cancel := context.WithCancel(context.Background())
+	var hooks []string
+	served := make(chan error, 1)
+	go func() {
+		served <- r.Serve("", ServeOptions{
+			Listener: ln,
+			Context:  ctx,
+			Health:   health,
+			OnShutdown: []func(context.Context) error{
+				func(ctx context.Context) error {
+					hooks = append(hooks, "first")
+					return nil
+				},
+				func(ctx context.Context) error {
+					hooks = append(hooks, "second")
+					return nil
+				},
+			},
+		})
+	}()
+
+	type result struct {
+		body string
+		err  error
+	}
+	response := make(chan result, 1)
+	go func() {
+		res, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			response <- result{err: err}
+			return
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		response <- result{string(body), err}
+	}()
+
+	<-started
+	if !health.Ready() {
+		t.Error("Unexpected readiness")
+	}
+	cancel()
+
+	// the listener is closed while the request is in flight
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			break
+		}
+		conn.Close()
+		if time.Now().After(deadline) {
+			t.Fatal("Unexpected open listener")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if health.Ready() {
+		t.Error("Unexpected readiness on shutdown")
+	}
+
+	select {
+	case err := <-served:
+		t.Fatalf("Unexpected return before the request finished (%v)", err)
+	default:
+	}
+
+	close(release)
+	if res := <-response; res.err != nil || res.body != "done" {
+		t.Errorf("Unexpected response (%v %q)", res.err, res.body)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Unexpected error (%v)", err)
+	}
+	if len(hooks) != 2 || hooks[0] != "first" || hooks[1] != "second" {
+		t.Errorf("Unexpected hooks (%v)", hooks)
+	}
+}
+
+func TestServeShutdownTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+
+	r := Classic()
+	r.Get("/stuck", func(w http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+	})
+
+	hookErr := errors.New("close failed")
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- r.Serve("", ServeOptions{
+			Listener:        ln,
+			Context:         ctx,
+			ShutdownTimeout: 50 * time.Millisecond,
+			OnShutdown: []func(context.Context) error{
+				func(ctx context.Context) error { return hookErr },
+			},
+		})
+	}()
+	go http.Get("http://" + ln.Addr().String() + "/stuck")
+
+	<-started
+	cancel()
+	err = <-served
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, hookErr) {
+		t.Errorf("Unexpected error (%v)", err)
+	}
+}
+
+func TestServeRouteErrors(t *testing.T) {
+	r := Classic()
+	r.Get("/users/{id:[}", func(w http.ResponseWriter, req *http.Request) {})
+
+	if err := r.Serve("127.0.0.1:0", ServeOptions{}); err == nil {
+		t.Error("Expected an error for an invalid route")
+	}
+}
+
+func TestServeH2C(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := Classic()
+	r.Get("/proto", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(req.Proto))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- r.Serve("", ServeOptions{Listener: ln, Context: ctx, H2C: true})
+	}()
+	defer func() {
+		cancel()
+		if err := <-served; err != nil {
+			t.Errorf("Unexpected error (%v)", err)
+		}
+	}()
+
+	h2c := &http.Transport{Protocols: new(http.Protocols)}
+	h2c.Protocols.SetUnencryptedHTTP2(true)
+	defer h2c.CloseIdleConnections()
+
+	clients := map[string]*http.Client{
+		"HTTP/2.0": {Transport: h2c},
+		"HTTP/1.1": {Transport: &http.Transport{}},
+	}
+	for proto, client := range clients {
+		res, err := client.Get("http://" + ln.Addr().String() + "/proto")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		if string(body) != proto || res.Proto != proto {
+			t.Errorf("Unexpected protocol (%s %s), expected %s", body, res.Proto, proto)
+		}
+		client.CloseIdleConnections()
+	}
+
+	// the Upgrade to h2c is ignored, the request is served with HTTP/1.1
+	req, _ := http.NewRequest(http.MethodGet, "http://"+ln.Addr().String()+"/proto", nil)
+	req.Header.Set("Connection", "Upgrade, HTTP2-Settings")
+	req.Header.Set("Upgrade", "h2c")
+	req.Header.Set("HTTP2-Settings", "AAMAAABkAARAAAAAAAIAAAAA")
+	client := &http.Client{Transport: &http.Transport{}}
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	client.CloseIdleConnections()
+	if res.StatusCode != http.StatusOK || string(body) != "HTTP/1.1" {
+		t.Errorf("Unexpected upgrade response (%d %s)", res.StatusCode, body)
+	}
+}
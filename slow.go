@@ -0,0 +1,104 @@
+package mux
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// SlowRequestOptions configures the SlowRequests middleware.
+type SlowRequestOptions struct {
+	// Threshold is the duration above which a request is logged, it
+	// defaults to a second.
+	Threshold time.Duration
+	// StackThreshold is the duration after which the stack of the goroutine
+	// serving a request still in progress is logged, to see where it hangs.
+	// It is disabled if it is zero.
+	StackThreshold time.Duration
+}
+
+// SlowRequests returns a middleware which logs the requests taking longer
+// than the threshold to the structured logger of the router (see
+// Router.Log) at slog.LevelWarn, with the attributes of RouteAttrs, the
+// route variables and the duration, e.g.:
+//
+//     r.Use(mux.SlowRequests(mux.SlowRequestOptions{
+//         Threshold:      500 * time.Millisecond,
+//         StackThreshold: 5 * time.Second,
+//     }))
+//
+// Once a request runs longer than the stack threshold, the stack of its
+// goroutine is logged at once, while the request is still in progress.
+func SlowRequests(opts SlowRequestOptions) MiddlewareFunc {
+	if opts.Threshold <= 0 {
+		opts.Threshold = time.Second
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+
+			if opts.StackThreshold > 0 {
+				// the variables are copied, as the router reuses them once
+				// the handler returns
+				vars := GetVars(req)
+				id := goroutineID()
+				timer := time.AfterFunc(opts.StackThreshold, func() {
+					attrs := append(RouteAttrs(req),
+						slog.Any("vars", vars),
+						slog.Duration("duration", time.Since(start)),
+						slog.String("stack", goroutineStack(id)),
+					)
+					requestLogger(req).LogAttrs(req.Context(), slog.LevelWarn, "mux: slow request stack", attrs...)
+				})
+				defer timer.Stop()
+			}
+
+			next.ServeHTTP(w, req)
+
+			if duration := time.Since(start); duration > opts.Threshold {
+				attrs := append(RouteAttrs(req),
+					slog.Any("vars", GetVars(req)),
+					slog.Duration("duration", duration),
+				)
+				requestLogger(req).LogAttrs(req.Context(), slog.LevelWarn, "mux: slow request", attrs...)
+			}
+		})
+	}
+}
+
+// goroutineID returns the ID of the current goroutine, as listed in stack
+// traces.
+func goroutineID() string {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	// goroutine 42 [running]:
+	fields := strings.Fields(string(buf))
+	if len(fields) < 2 {
+		return ""
+	}
+	return fields[1]
+}
+
+// goroutineStack returns the stack of the goroutine with the ID id, empty
+// if it doesn't exist anymore.
+func goroutineStack(id string) string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	for _, stack := range strings.Split(string(buf), "\n\n") {
+		if strings.HasPrefix(stack, "goroutine "+id+" ") {
+			return stack
+		}
+	}
+	return ""
+}
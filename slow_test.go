@@ -0,0 +1,66 @@
+package mux
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingLogger records the log entries.
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []string
+	attrs   []map[string]slog.Value
+	logged  chan string
+}
+
+func (l *recordingLogger) LogAttrs(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	values := map[string]slog.Value{}
+	for _, attr := range attrs {
+		values[attr.Key] = attr.Value
+	}
+	l.mu.Lock()
+	l.entries = append(l.entries, msg)
+	l.attrs = append(l.attrs, values)
+	l.mu.Unlock()
+	l.logged <- msg
+}
+
+func TestSlowRequests(t *testing.T) {
+	logger := &recordingLogger{logged: make(chan string, 10)}
+	r := Classic()
+	r.Log = logger
+	r.Use(SlowRequests(SlowRequestOptions{Threshold: 10 * time.Millisecond, StackThreshold: 20 * time.Millisecond}))
+	r.Get("/fast", func(w http.ResponseWriter, req *http.Request) {})
+	r.Get("/slow/:id", func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-logger.logged:
+		case <-time.After(5 * time.Second):
+			t.Error("Missing stack log")
+		}
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fast", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow/42", nil))
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if len(logger.entries) != 2 || logger.entries[0] != "mux: slow request stack" || logger.entries[1] != "mux: slow request" {
+		t.Fatalf("Unexpected log entries (%v)", logger.entries)
+	}
+
+	stack := logger.attrs[0]["stack"].String()
+	if !strings.HasPrefix(stack, "goroutine ") || !strings.Contains(stack, "TestSlowRequests") {
+		t.Errorf("Unexpected stack (%s)", stack)
+	}
+	for _, attrs := range logger.attrs {
+		if attrs["pattern"].String() != "/slow/:id" || attrs["vars"].Any().(Vars)["id"] != "42" || attrs["duration"].Duration() < 10*time.Millisecond {
+			t.Errorf("Unexpected attributes (%v)", attrs)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package mux
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Static serves the files of root below prefix for GET and HEAD requests,
+// e.g.:
+//
+//     r.Static("/assets/", http.Dir("./public"))
+//
+// serves ./public/css/app.css as /assets/css/app.css. The prefix is removed
+// from the path and the rest is cleaned before the file is opened, so a
+// request can't escape root with "..". A directory is served with its
+// index.html, directories aren't listed. Missing files are answered by the
+// NotFoundHandler of the router.
+//
+// Files are served with an ETag and their Last-Modified time, conditional
+// requests (If-None-Match, If-Modified-Since) are answered with 304 and
+// Range requests with the requested parts. The Cache-Control header is set
+// per mount with the CacheControl middleware, e.g.:
+//
+//     r.Static("/assets/", http.Dir("./public")).Use(mux.CacheControl("public, max-age=86400"))
+func (r *Router) Static(prefix string, root http.FileSystem) RouteInterface {
+	return r.registerStatic(r.NewRoute().PathPrefix(prefix), &staticHandler{root: root, router: r})
+}
+
+// StaticFS serves the files of fsys below prefix like Static, e.g. the
+// assets embedded into the binary:
+//
+//     //go:embed public
+//     var public embed.FS
+//
+//     assets, _ := fs.Sub(public, "public")
+//     r.StaticFS("/assets/", assets)
+//
+// The content type is derived from the extension of a file, or sniffed from
+// its content if the extension is unknown.
+func (r *Router) StaticFS(prefix string, fsys fs.FS) RouteInterface {
+	return r.Static(prefix, http.FS(fsys))
+}
+
+// SPA serves a single-page application below prefix like Static, but a
+// request for an unknown path is answered with the index file (e.g.
+// index.html), so the application can route it on the client:
+//
+//     r.Get("/api/users", usersHandler)
+//     r.SPA("/", http.Dir("./dist"), "index.html")
+//
+// The other routes of the router are matched first regardless of their
+// order, and a path whose route doesn't allow the method is answered with
+// 405 Method Not Allowed. Any other path below prefix without a file
+// extension gets the index, including unknown API paths like /api/nope, so
+// the SPA is best served below a prefix of its own. Paths with a file
+// extension (e.g. /app.js) don't fall back, a missing asset is not found.
+func (r *Router) SPA(prefix string, root http.FileSystem, index string) RouteInterface {
+	route := r.NewRoute().PathPrefix(prefix)
+	route.AddMatcher(spaMatcher{})
+
+	return r.registerStatic(route, &staticHandler{root: root, router: r, fallback: path.Clean("/" + index)})
+}
+
+// registerStatic registers route with handler for GET and HEAD requests.
+// The path prefix of the route is removed before handler opens the file.
+func (r *Router) registerStatic(route RouteInterface, handler *staticHandler) RouteInterface {
+	route.Handler(stripSegmentsHandler(strings.Count(strings.TrimSuffix(route.GetPath(), "/"), "/"), handler))
+
+	return r.registerRoute([]string{http.MethodGet, http.MethodHead}, route)
+}
+
+// staticHandler serves the files of a file system.
+type staticHandler struct {
+	root   http.FileSystem
+	router *Router
+	// fallback is the file served for unknown paths, see SPA.
+	fallback string
+	// etags caches the hashed ETags of the files without a modification
+	// time by name, they don't change.
+	etags sync.Map
+}
+
+func (h *staticHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	name := path.Clean("/" + req.URL.Path)
+
+	f, err := h.root.Open(name)
+	if err != nil && h.fallback != "" && errors.Is(err, fs.ErrNotExist) && path.Ext(name) == "" {
+		name = h.fallback
+		f, err = h.root.Open(name)
+	}
+	if err != nil {
+		h.serveError(w, req, err)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		h.serveError(w, req, err)
+		return
+	}
+
+	if info.IsDir() {
+		// relative links of the index need the trailing slash
+		if !strings.HasSuffix(req.URL.Path, "/") {
+			redirectPermanentTo(w, req, path.Base(req.URL.Path)+"/")
+			return
+		}
+
+		name = path.Join(name, "index.html")
+		index, err := h.root.Open(name)
+		if err != nil {
+			h.serveError(w, req, err)
+			return
+		}
+		defer index.Close()
+
+		if info, err = index.Stat(); err != nil || info.IsDir() {
+			h.router.notFoundHandler().ServeHTTP(w, req)
+			return
+		}
+		f = index
+	}
+
+	if w.Header().Get("ETag") == "" {
+		etag, err := h.etag(name, info, f)
+		if err != nil {
+			h.serveError(w, req, err)
+			return
+		}
+		w.Header().Set("ETag", etag)
+	}
+
+	http.ServeContent(w, req, info.Name(), info.ModTime(), f)
+}
+
+// etag returns a strong ETag of the file name: its modification time and
+// size, or a hash of its content if it has no modification time (e.g. the
+// files of an embed.FS), which is computed once.
+func (h *staticHandler) etag(name string, info fs.FileInfo, f http.File) (string, error) {
+	if !info.ModTime().IsZero() {
+		return `"` + strconv.FormatInt(info.ModTime().UnixNano(), 16) + "-" + strconv.FormatInt(info.Size(), 16) + `"`, nil
+	}
+	if etag, ok := h.etags.Load(name); ok {
+		return etag.(string), nil
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+	h.etags.Store(name, etag)
+	return etag, nil
+}
+
+// CacheControl returns a middleware which sets the Cache-Control header of
+// the responses with a status below 400, so errors (e.g. a missing file)
+// aren't cached. See Static.
+func CacheControl(value string) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(&cacheControlWriter{ResponseWriter: w, value: value}, req)
+		})
+	}
+}
+
+// cacheControlWriter sets the Cache-Control header when the status is
+// written.
+type cacheControlWriter struct {
+	http.ResponseWriter
+	value       string
+	wroteHeader bool
+}
+
+func (w *cacheControlWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if code < http.StatusBadRequest {
+			w.Header().Set("Cache-Control", w.value)
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *cacheControlWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the wrapped ResponseWriter, e.g. for http.ResponseController.
+func (w *cacheControlWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// serveError answers a request for a file which can't be opened.
+func (h *staticHandler) serveError(w http.ResponseWriter, req *http.Request, err error) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		h.router.notFoundHandler().ServeHTTP(w, req)
+	case errors.Is(err, fs.ErrPermission):
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+	default:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// spaMatcher matches only the requests of the fallback match (see
+// Router.matchFallback), so the routes of an SPA yield to the others.
+type spaMatcher struct{}
+
+func (m spaMatcher) Match(r *http.Request) bool {
+	return contextGet(r, fallbackKey) != nil
+}
+
+func (m spaMatcher) Rank() Rank {
+	return RankScheme
+}
+
+func (m spaMatcher) Cost() Cost {
+	return CostCustom
+}
+
+// matchFallback matches the SPA routes against a request which no other
+// route matches regardless of its method.
+func (r *Router) matchFallback(req *http.Request) RouteInterface {
+	return r.triggerMatching(contextSet(req, fallbackKey, true))
+}
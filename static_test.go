@@ -0,0 +1,244 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func staticDir(t *testing.T) string {
+	dir := t.TempDir()
+	files := map[string]string{
+		"index.html":      "home",
+		"css/app.css":     "body {}",
+		"docs/index.html": "docs",
+		"empty/.keep":     "",
+	}
+	for name, content := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(filepath.Dir(dir), "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestStatic(t *testing.T) {
+	r := Classic()
+	r.SkipClean = true
+	r.Static("/assets/", http.Dir(staticDir(t)))
+
+	tests := []struct {
+		method   string
+		path     string
+		code     int
+		body     string
+		location string
+	}{
+		{http.MethodGet, "/assets/css/app.css", http.StatusOK, "body {}", ""},
+		{http.MethodHead, "/assets/css/app.css", http.StatusOK, "", ""},
+		{http.MethodGet, "/assets/", http.StatusOK, "home", ""},
+		{http.MethodGet, "/assets/docs/", http.StatusOK, "docs", ""},
+		{http.MethodGet, "/assets/docs", http.StatusMovedPermanently, "", "docs/"},
+		{http.MethodGet, "/assets/empty/", http.StatusNotFound, "404 page not found\n", ""},
+		{http.MethodGet, "/assets/missing.js", http.StatusNotFound, "404 page not found\n", ""},
+		{http.MethodGet, "/assets/../secret.txt", http.StatusNotFound, "404 page not found\n", ""},
+		{http.MethodGet, "/assets/css/../../secret.txt", http.StatusNotFound, "404 page not found\n", ""},
+		{http.MethodPost, "/assets/css/app.css", http.StatusMethodNotAllowed, "Method Not Allowed\n", ""},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, "/", nil)
+		req.URL.Path = test.path
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != test.code || w.Body.String() != test.body || w.Header().Get("Location") != test.location {
+			t.Errorf("Expected %d %q for %s %s, got %d %q (%s)", test.code, test.body, test.method, test.path, w.Code, w.Body.String(), w.Header().Get("Location"))
+		}
+	}
+}
+
+func TestStaticContentType(t *testing.T) {
+	r := Classic()
+	r.Static("/assets", http.Dir(staticDir(t)))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/assets/css/app.css", nil))
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/css; charset=utf-8" {
+		t.Errorf("Unexpected content type (%s)", ct)
+	}
+}
+
+func TestStaticFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":        {Data: []byte("<!doctype html><title>home</title>")},
+		"js/app.js":         {Data: []byte("console.log(1)")},
+		"data/report":       {Data: []byte("%PDF-1.4")},
+		"nested/index.html": {Data: []byte("nested")},
+	}
+
+	r := Classic()
+	r.StaticFS("/", fsys)
+
+	tests := []struct {
+		path        string
+		code        int
+		contentType string
+	}{
+		{"/", http.StatusOK, "text/html; charset=utf-8"},
+		{"/js/app.js", http.StatusOK, "text/javascript; charset=utf-8"},
+		{"/data/report", http.StatusOK, "application/pdf"},
+		{"/nested/", http.StatusOK, "text/html; charset=utf-8"},
+		{"/missing", http.StatusNotFound, "text/plain; charset=utf-8"},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+		if w.Code != test.code || w.Header().Get("Content-Type") != test.contentType {
+			t.Errorf("Expected %d %q for %s, got %d %q", test.code, test.contentType, test.path, w.Code, w.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestSPA(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html": {Data: []byte("app")},
+		"app.js":     {Data: []byte("js")},
+	}
+
+	r := Classic()
+	r.SPA("/", http.FS(fsys), "index.html")
+	r.Get("/api/users", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("users"))
+	})
+	r.Post("/api/orders", nil)
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{http.MethodGet, "/", http.StatusOK, "app"},
+		{http.MethodGet, "/app.js", http.StatusOK, "js"},
+		{http.MethodGet, "/users/42/settings", http.StatusOK, "app"},
+		{http.MethodGet, "/api/users", http.StatusOK, "users"},
+		{http.MethodGet, "/api/orders", http.StatusMethodNotAllowed, "Method Not Allowed\n"},
+		// unknown API paths fall back like the other paths
+		{http.MethodGet, "/api/nope", http.StatusOK, "app"},
+		{http.MethodGet, "/missing.js", http.StatusNotFound, "404 page not found\n"},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+
+		if w.Code != test.code || w.Body.String() != test.body {
+			t.Errorf("Expected %d %q for %s %s, got %d %q", test.code, test.body, test.method, test.path, w.Code, w.Body.String())
+		}
+	}
+
+	if match, ok := r.MatchRequest(httptest.NewRequest(http.MethodGet, "/users/42", nil)); !ok || match.Route.GetPath() != "/" {
+		t.Errorf("Unexpected match of the fallback (%v)", match.Err)
+	}
+
+	// the SPA of a subrouter yields to the routes of the parent
+	r = Classic()
+	r.PathPrefix("/app").Subrouter().SPA("/", http.FS(fsys), "index.html")
+	r.Get("/app/api/users", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("users"))
+	})
+	for path, body := range map[string]string{"/app/settings": "app", "/app/api/users": "users"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Body.String() != body {
+			t.Errorf("Unexpected body of %s (%q)", path, w.Body.String())
+		}
+	}
+}
+
+func TestStaticConditional(t *testing.T) {
+	modTime := time.Date(2024, time.March, 2, 22, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"app.css":      {Data: []byte("body { color: red }"), ModTime: modTime},
+		"embedded.css": {Data: []byte("body {}")},
+	}
+
+	r := Classic()
+	r.StaticFS("/assets/", fsys).Use(CacheControl("public, max-age=86400"))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/assets/app.css", nil))
+
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" || w.Header().Get("Last-Modified") != modTime.Format(http.TimeFormat) {
+		t.Fatalf("Unexpected response (%d, %v)", w.Code, w.Header())
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "public, max-age=86400" {
+		t.Errorf("Unexpected Cache-Control (%s)", cc)
+	}
+
+	tests := []struct {
+		header string
+		value  string
+		code   int
+		body   string
+	}{
+		{"If-None-Match", etag, http.StatusNotModified, ""},
+		{"If-None-Match", `"other"`, http.StatusOK, "body { color: red }"},
+		{"If-Modified-Since", modTime.Format(http.TimeFormat), http.StatusNotModified, ""},
+		{"If-Modified-Since", modTime.Add(-time.Hour).Format(http.TimeFormat), http.StatusOK, "body { color: red }"},
+		{"Range", "bytes=0-3", http.StatusPartialContent, "body"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/assets/app.css", nil)
+		req.Header.Set(test.header, test.value)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != test.code || w.Body.String() != test.body {
+			t.Errorf("Expected %d %q for %s: %s, got %d %q", test.code, test.body, test.header, test.value, w.Code, w.Body.String())
+		}
+	}
+
+	// files without a modification time get a hash as ETag
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/assets/embedded.css", nil))
+
+	if etag := w.Header().Get("ETag"); etag != `"62368a1a29259b30bac235c0e75dc700"` {
+		t.Errorf("Unexpected ETag (%s)", etag)
+	}
+
+	// the hash is computed once
+	fsys["embedded.css"].Data = []byte("body { margin: 0 }")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/assets/embedded.css", nil))
+
+	if etag := w.Header().Get("ETag"); etag != `"62368a1a29259b30bac235c0e75dc700"` {
+		t.Errorf("ETag is hashed again (%s)", etag)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/assets/missing.css", nil))
+
+	if cc := w.Header().Get("Cache-Control"); w.Code != http.StatusNotFound || cc != "" {
+		t.Errorf("Unexpected response (%d, %s)", w.Code, cc)
+	}
+}
@@ -0,0 +1,108 @@
+package mux
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// RouteStats counts the requests of a router per route, see Router.Stats. It
+// implements expvar.Var, so it can be published for quick inspection in
+// production:
+//
+//     r.Stats = mux.NewRouteStats()
+//     expvar.Publish("routes", r.Stats)
+//
+// The routes are identified by their path template, e.g. /users/:id, the
+// routes of a template registered for different methods are counted
+// together.
+type RouteStats struct {
+	mu       sync.Mutex
+	routes   map[string]*RouteHits
+	notFound uint64
+}
+
+// RouteHits are the counters of a route.
+type RouteHits struct {
+	// Matches counts the requests which matched the route.
+	Matches uint64 `json:"matches"`
+	// MethodNotAllowed counts the requests answered with 405 Method Not
+	// Allowed, whose path matched the route but not their method.
+	MethodNotAllowed uint64 `json:"method_not_allowed"`
+}
+
+// StatsSnapshot is a copy of the counters of RouteStats.
+type StatsSnapshot struct {
+	// Routes are the counters by path template.
+	Routes map[string]RouteHits `json:"routes"`
+	// NotFound counts the requests without a matching route answered with
+	// 404 Not Found.
+	NotFound uint64 `json:"not_found"`
+}
+
+// NewRouteStats returns empty route counters.
+func NewRouteStats() *RouteStats {
+	return &RouteStats{routes: map[string]*RouteHits{}}
+}
+
+// Snapshot returns a copy of the current counters.
+func (s *RouteStats) Snapshot() StatsSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := StatsSnapshot{Routes: make(map[string]RouteHits, len(s.routes)), NotFound: s.notFound}
+	for pattern, hits := range s.routes {
+		snapshot.Routes[pattern] = *hits
+	}
+	return snapshot
+}
+
+// String returns the snapshot of the counters as JSON, as required by
+// expvar.Var.
+func (s *RouteStats) String() string {
+	b, _ := json.Marshal(s.Snapshot())
+	return string(b)
+}
+
+// hits returns the counters of a route, the caller must hold the lock.
+func (s *RouteStats) hits(route RouteInterface) *RouteHits {
+	pattern := route.GetPath()
+	hits := s.routes[pattern]
+	if hits == nil {
+		hits = &RouteHits{}
+		s.routes[pattern] = hits
+	}
+	return hits
+}
+
+func (s *RouteStats) countMatch(route RouteInterface) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hits(route).Matches++
+}
+
+func (s *RouteStats) countMethodNotAllowed(route RouteInterface) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hits(route).MethodNotAllowed++
+}
+
+func (s *RouteStats) countNotFound() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notFound++
+}
+
+// countMethodNotAllowed counts a 405 response for the route of the first
+// allowed method, if the router has stats.
+func (r *Router) countMethodNotAllowed(req *http.Request, allowed []string) {
+	if r.Stats == nil {
+		return
+	}
+	methodReq := new(http.Request)
+	*methodReq = *req
+	methodReq.Method = allowed[0]
+	if route := r.matchMethod(allowed[0], methodReq); route != nil {
+		r.Stats.countMethodNotAllowed(route)
+	}
+}
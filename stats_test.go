@@ -0,0 +1,57 @@
+package mux
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRouteStats(t *testing.T) {
+	r := Classic()
+	r.Stats = NewRouteStats()
+	r.Get("/users/:id", func(w http.ResponseWriter, req *http.Request) {})
+	r.Post("/users/:id", func(w http.ResponseWriter, req *http.Request) {})
+	api := r.PathPrefix("/api").Subrouter()
+	api.Get("/status", func(w http.ResponseWriter, req *http.Request) {})
+
+	requests := []struct {
+		method, path string
+	}{
+		{http.MethodGet, "/users/1"},
+		{http.MethodPost, "/users/2"},
+		{http.MethodDelete, "/users/3"},
+		{http.MethodGet, "/api/status"},
+		{http.MethodPut, "/api/status"},
+		{http.MethodGet, "/missing"},
+		{http.MethodGet, "/missing"},
+	}
+	for _, req := range requests {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	expected := StatsSnapshot{
+		Routes: map[string]RouteHits{
+			"/users/:id":  {Matches: 2, MethodNotAllowed: 1},
+			"/api/status": {Matches: 1, MethodNotAllowed: 1},
+		},
+		NotFound: 2,
+	}
+	if snapshot := r.Stats.Snapshot(); !reflect.DeepEqual(snapshot, expected) {
+		t.Errorf("Unexpected snapshot (%+v)", snapshot)
+	}
+
+	// the snapshot is a copy
+	r.Stats.Snapshot().Routes["/users/:id"] = RouteHits{}
+	if r.Stats.Snapshot().Routes["/users/:id"].Matches != 2 {
+		t.Error("Unexpected change of the counters")
+	}
+
+	var v expvar.Var = r.Stats
+	var published StatsSnapshot
+	if err := json.Unmarshal([]byte(v.String()), &published); err != nil || !reflect.DeepEqual(published, expected) {
+		t.Errorf("Unexpected expvar value %s (%v)", v.String(), err)
+	}
+}
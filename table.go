@@ -0,0 +1,103 @@
+package mux
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// routeTable is a snapshot of the registered routes. A table is never
+// changed once it is published, so requests match against it without
+// locking.
+type routeTable struct {
+	// routes to be matched per method, in order.
+	routes map[string]routes
+	// indexes resolve the candidate routes of a path per method.
+	indexes map[string]*lazyIndex
+}
+
+// index returns the route index of a method, nil if there are no routes.
+func (t *routeTable) index(method string) *routeIndex {
+	if idx, found := t.indexes[method]; found {
+		return idx.get()
+	}
+	return nil
+}
+
+// lazyIndex builds the route index of a method on its first use, so
+// registering many routes doesn't rebuild the index for every route.
+type lazyIndex struct {
+	once   sync.Once
+	routes routes
+	idx    *routeIndex
+}
+
+func (l *lazyIndex) get() *routeIndex {
+	l.once.Do(func() {
+		l.idx = newRouteIndex(l.routes)
+	})
+	return l.idx
+}
+
+// routeStore holds the current route table of a router and its subrouters.
+// Routes can be registered while the router serves requests: a change
+// copies the table and atomically swaps it (copy-on-write), so requests
+// keep matching against the previous table until the swap.
+type routeStore struct {
+	// mu serializes the changes.
+	mu      sync.Mutex
+	current atomic.Value
+}
+
+func newRouteStore(rs map[string]routes) *routeStore {
+	s := &routeStore{}
+	t := &routeTable{routes: map[string]routes{}, indexes: map[string]*lazyIndex{}}
+	for method, v := range rs {
+		t.routes[method] = v
+		t.indexes[method] = &lazyIndex{routes: v}
+	}
+	s.current.Store(t)
+	return s
+}
+
+// load returns the current table.
+func (s *routeStore) load() *routeTable {
+	return s.current.Load().(*routeTable)
+}
+
+// update applies fn to a copy of the current table and publishes the copy.
+// fn must replace the routes of a method instead of modifying them in place,
+// except for appending as the previous tables don't see appended routes.
+// The index of every changed method has to be reset with setRoutes.
+func (s *routeStore) update(fn func(t *routeTable)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.load()
+	t := &routeTable{
+		routes:  make(map[string]routes, len(current.routes)),
+		indexes: make(map[string]*lazyIndex, len(current.indexes)),
+	}
+	for method, v := range current.routes {
+		t.routes[method] = v
+	}
+	for method, idx := range current.indexes {
+		t.indexes[method] = idx
+	}
+
+	fn(t)
+	s.current.Store(t)
+}
+
+// setRoutes replaces the routes of a method and resets its index.
+func (t *routeTable) setRoutes(method string, rs routes) {
+	t.routes[method] = rs
+	t.indexes[method] = &lazyIndex{routes: rs}
+}
+
+// table returns the current route table of the router.
+func (r *Router) table() *routeTable {
+	if r.store == nil {
+		return &routeTable{}
+	}
+	return r.store.load()
+}
@@ -0,0 +1,504 @@
+package mux
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+)
+
+// ParamParseFunc converts the value of a route variable to its type, see
+// Router.RegisterParamType.
+type ParamParseFunc func(value string) (interface{}, error)
+
+// paramType is the type of a route variable.
+type paramType struct {
+	pattern string
+	// parse is optional, the value is kept as string without it.
+	parse ParamParseFunc
+}
+
+// paramTypes maps the names of the variable types to their type.
+type paramTypes map[string]paramType
+
+// varTypes holds the built-in variable types. A variable with the name of a
+// type (e.g. :number) is stored under its prefixed name (e.g. ":number"),
+// any other name is a named variable.
+var varTypes = paramTypes{
+	"number": {pattern: "[0-9]{1,}"},
+	"string": {pattern: "[a-zA-Z]{1,}"},
+}
+
+// namedVarPattern is the regular expression used for named variables.
+const namedVarPattern = "[^/]+"
+
+// catchAllVarPattern is the regular expression used for catch-all variables.
+const catchAllVarPattern = ".*"
+
+// hostVarPattern is the regular expression used for variables of a host.
+const hostVarPattern = "[^.]+"
+
+// pathTemplate is the compiled form of a path (or host) which contains
+// variables.
+type pathTemplate struct {
+	regex *regexp.Regexp
+	// names of the variables in order of appearance.
+	names []string
+	// groups holds the capture group of each variable.
+	groups []int
+	// tokens are used to build a path out of variables.
+	tokens []templateToken
+	// segments holds the path segment of each variable if every variable
+	// takes a whole segment, which allows to extract them without the regex.
+	segments []int
+	// catchAll is set if the last variable takes the rest of the path.
+	catchAll bool
+}
+
+// templateToken is either a literal or a variable of a template.
+type templateToken struct {
+	literal string
+	// name of the variable, empty for literals and wildcards.
+	name string
+	// regex validates the value of the variable.
+	regex *regexp.Regexp
+	// optional variables may be omitted.
+	optional bool
+	// slash is written before the value of an optional variable.
+	slash bool
+	// wildcard matches anything and can't be built.
+	wildcard bool
+}
+
+// compilePathTemplate compiles a path like /user/:id/posts/:slug,
+// /user/{id}/posts/{slug}, /articles/{id:[0-9a-f]{8}}, /reports/:year/:month?
+// or /static/*filepath to a regex and records the name of each variable
+// alongside its capture group. A {name} matches a segment like :name.
+// The variable types default to the built-in types if types is nil.
+func compilePathTemplate(path string, types paramTypes) (*pathTemplate, error) {
+	return compilePath(path, false, types)
+}
+
+// compilePathPrefixTemplate compiles a path prefix like /users/:id which
+// matches the path itself and all paths below it.
+func compilePathPrefixTemplate(prefix string, types paramTypes) (*pathTemplate, error) {
+	return compilePath(prefix, true, types)
+}
+
+func compilePath(path string, prefix bool, types paramTypes) (*pathTemplate, error) {
+	if types == nil {
+		types = varTypes
+	}
+
+	b := newTemplateBuilder()
+	segments := []int{}
+	wholeSegments, catchAll := true, false
+
+	for i := 0; i < len(path); {
+		var name, varPattern string
+		var end int
+		customPattern := false
+
+		switch {
+		case path[i] == ':':
+			end = i + 1
+			for end < len(path) && isVarNameChar(path[end]) {
+				end++
+			}
+
+			name = path[i+1 : end]
+			if name == "" {
+				return nil, fmt.Errorf("mux: variable name is missing at offset %d in %q", i, path)
+			}
+
+			if typ, typed := types[name]; typed {
+				varPattern = typ.pattern
+				name = ":" + name
+			} else {
+				varPattern = namedVarPattern
+			}
+		case path[i] == '{':
+			var err error
+			name, varPattern, end, err = parseBracedVar(path, i, namedVarPattern)
+			if err != nil {
+				return nil, err
+			}
+			customPattern = varPattern != namedVarPattern
+		case isCatchAllStart(path, i):
+			end = i + 1
+			for end < len(path) && isVarNameChar(path[end]) {
+				end++
+			}
+
+			name = path[i+1 : end]
+			if name == "" {
+				return nil, fmt.Errorf("mux: variable name is missing at offset %d in %q", i, path)
+			}
+
+			if end != len(path) {
+				return nil, fmt.Errorf("mux: catch-all variable %q must be at the end of %q", name, path)
+			}
+			varPattern = catchAllVarPattern
+			catchAll = true
+		default:
+			end = i + 1
+			for end < len(path) && path[end] != ':' && path[end] != '{' && !isCatchAllStart(path, end) {
+				end++
+			}
+			b.literal(path[i:end])
+			i = end
+			continue
+		}
+
+		group := b.varGroup(name, varPattern)
+
+		// a custom pattern may match a slash
+		if customPattern || (i > 0 && path[i-1] != '/') || (end < len(path) && path[end] != '/') {
+			wholeSegments = false
+		}
+		segments = append(segments, strings.Count(path[:i], "/"))
+
+		// An optional var (e.g. /:month?) makes the whole segment optional,
+		// so the preceding slash is moved into the optional group.
+		if end < len(path) && path[end] == '?' {
+			token := &b.tokens[len(b.tokens)-1]
+			token.optional = true
+
+			if i > 0 && path[i-1] == '/' {
+				b.pattern.Truncate(b.pattern.Len() - 1)
+				literal := &b.tokens[len(b.tokens)-2]
+				literal.literal = literal.literal[:len(literal.literal)-1]
+				token.slash = true
+				group = "/" + group
+			}
+			group = "(?:" + group + ")?"
+			end++
+		}
+
+		b.pattern.WriteString(group)
+		i = end
+	}
+
+	if prefix {
+		if strings.HasSuffix(path, "/") {
+			b.pattern.WriteString(".*")
+		} else {
+			b.pattern.WriteString("(?:/.*)?")
+		}
+	}
+
+	t, err := b.build()
+	if err != nil {
+		return nil, err
+	}
+
+	if wholeSegments {
+		t.segments = segments
+		t.catchAll = catchAll
+	}
+
+	return t, nil
+}
+
+// compileHostTemplate compiles a host like {tenant}.example.com or
+// *.example.com to a regex. A "*" label matches any single label without
+// capturing it, a variable matches a single label by default. The literal
+// parts are lowercased.
+func compileHostTemplate(host string) (*pathTemplate, error) {
+	b := newTemplateBuilder()
+
+	for i := 0; i < len(host); {
+		switch {
+		case host[i] == '{':
+			name, varPattern, end, err := parseBracedVar(host, i, hostVarPattern)
+			if err != nil {
+				return nil, err
+			}
+
+			b.pattern.WriteString(b.varGroup(name, varPattern))
+			i = end
+		case isWildcardLabel(host, i):
+			b.pattern.WriteString(hostVarPattern)
+			b.tokens = append(b.tokens, templateToken{wildcard: true})
+			i++
+		default:
+			end := i + 1
+			for end < len(host) && host[end] != '{' && !isWildcardLabel(host, end) {
+				end++
+			}
+			b.literal(strings.ToLower(host[i:end]))
+			i = end
+		}
+	}
+
+	return b.build()
+}
+
+// isWildcardLabel returns true if the label at offset i of host is "*".
+func isWildcardLabel(host string, i int) bool {
+	return host[i] == '*' && (i == 0 || host[i-1] == '.') && (i+1 == len(host) || host[i+1] == '.')
+}
+
+// templateBuilder builds the regex of a template.
+type templateBuilder struct {
+	pattern bytes.Buffer
+	names   []string
+	seen    map[string]struct{}
+	// counts holds the number of duplicates of each name.
+	counts map[string]int
+	tokens []templateToken
+}
+
+func newTemplateBuilder() *templateBuilder {
+	return &templateBuilder{
+		names:  []string{},
+		seen:   map[string]struct{}{},
+		counts: map[string]int{},
+	}
+}
+
+// literal writes a literal part of the template.
+func (b *templateBuilder) literal(literal string) {
+	b.pattern.WriteString(regexp.QuoteMeta(literal))
+	b.tokens = append(b.tokens, templateToken{literal: literal})
+}
+
+// varGroup records the name of a variable and returns its capture group.
+// A duplicate name gets the number of its duplicates appended, e.g.
+// /:number/:number/:number has the vars ":number", ":number1" and
+// ":number2". The number is increased until the name is unused.
+func (b *templateBuilder) varGroup(name string, pattern string) string {
+	base := name
+	for _, found := b.seen[name]; found; _, found = b.seen[name] {
+		b.counts[base]++
+		name = base + strconv.Itoa(b.counts[base])
+	}
+	b.seen[name] = struct{}{}
+
+	group := fmt.Sprintf("(?P<v%d>%s)", len(b.names), pattern)
+	b.names = append(b.names, name)
+	b.tokens = append(b.tokens, templateToken{
+		name:  name,
+		regex: regexp.MustCompile("^(?:" + pattern + ")$"),
+	})
+
+	return group
+}
+
+// build compiles the regex of the template.
+func (b *templateBuilder) build() (*pathTemplate, error) {
+	regex, err := regexp.Compile("^" + b.pattern.String() + "$")
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]int, len(b.names))
+	for k := range b.names {
+		groups[k] = regex.SubexpIndex("v" + strconv.Itoa(k))
+	}
+
+	return &pathTemplate{
+		regex:  regex,
+		names:  b.names,
+		groups: groups,
+		tokens: b.tokens,
+	}, nil
+}
+
+// parseBracedVar parses a variable like {id:[0-9]+} which starts at offset
+// start of path. It returns the name, the pattern and the offset after the
+// closing brace. If the pattern is omitted defaultPattern is used, if that is
+// empty as well the pattern is required. The pattern is compiled to report
+// errors early.
+func parseBracedVar(path string, start int, defaultPattern string) (name string, pattern string, end int, err error) {
+	level := 0
+	for end = start; end < len(path); end++ {
+		switch path[end] {
+		case '\\':
+			end++
+		case '{':
+			level++
+		case '}':
+			level--
+		}
+
+		if level == 0 {
+			break
+		}
+	}
+
+	if level != 0 {
+		return "", "", 0, fmt.Errorf("mux: unbalanced braces at offset %d in %q", start, path)
+	}
+
+	parts := strings.SplitN(path[start+1:end], ":", 2)
+	name = parts[0]
+	if name == "" {
+		return "", "", 0, fmt.Errorf("mux: variable name is missing at offset %d in %q", start, path)
+	}
+
+	pattern = defaultPattern
+	if len(parts) == 2 {
+		pattern = parts[1]
+	}
+
+	if pattern == "" {
+		return "", "", 0, fmt.Errorf("mux: pattern of variable %q is missing in %q", name, path)
+	}
+
+	if _, err := regexp.Compile(pattern); err != nil {
+		return "", "", 0, fmt.Errorf("mux: pattern of variable %q is invalid: %s", name, err.Error())
+	}
+
+	return name, pattern, end + 1, nil
+}
+
+// isCatchAllStart returns true if a catch-all variable starts at offset i
+// of path. A catch-all variable is only allowed at the start of a segment.
+func isCatchAllStart(path string, i int) bool {
+	return path[i] == '*' && i > 0 && path[i-1] == '/'
+}
+
+// matchesSlash returns true if the regex may match a slash.
+func matchesSlash(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return true
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			if r == '/' {
+				return true
+			}
+		}
+	case syntax.OpCharClass:
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			if re.Rune[i] <= '/' && '/' <= re.Rune[i+1] {
+				return true
+			}
+		}
+	}
+
+	for _, sub := range re.Sub {
+		if matchesSlash(sub) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isVarNameChar returns true if c is allowed inside a variable name.
+func isVarNameChar(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// ignoreCase makes the template match paths regardless of their case. The
+// literals used to build a path keep their case.
+func (t *pathTemplate) ignoreCase() {
+	t.regex = ignoreCase(t.regex)
+}
+
+// ignoreCase returns a case-insensitive copy of regex.
+func ignoreCase(regex *regexp.Regexp) *regexp.Regexp {
+	return regexp.MustCompile("(?i)" + regex.String())
+}
+
+// match returns true if the path matches the template.
+func (t *pathTemplate) match(path string) bool {
+	return t.regex.MatchString(path)
+}
+
+// extractVars adds the variables of path to p. If every variable takes a
+// whole segment they are sliced out of the path without allocating, so the
+// path must match the template.
+func (t *pathTemplate) extractVars(path string, p *params) {
+	if t.segments != nil {
+		t.extractSegments(path, p)
+		return
+	}
+
+	match := t.regex.FindStringSubmatchIndex(path)
+	if match == nil {
+		return
+	}
+
+	for k, name := range t.names {
+		group := t.groups[k]
+		if match[2*group] < 0 {
+			continue
+		}
+		p.set(name, path[match[2*group]:match[2*group+1]])
+	}
+}
+
+// extractSegments adds the variables of path to p by their segment.
+func (t *pathTemplate) extractSegments(path string, p *params) {
+	k, segment, start := 0, 0, 0
+
+	for i := 0; i <= len(path) && k < len(t.names); i++ {
+		if i < len(path) && path[i] != '/' {
+			continue
+		}
+
+		if t.segments[k] == segment {
+			if t.catchAll && k == len(t.names)-1 {
+				p.set(t.names[k], path[start:])
+			} else {
+				p.set(t.names[k], path[start:i])
+			}
+			k++
+		}
+
+		segment++
+		start = i + 1
+	}
+}
+
+// hasWildcard returns true if the template contains a wildcard.
+func (t *pathTemplate) hasWildcard() bool {
+	for _, token := range t.tokens {
+		if token.wildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// build returns the path (or host) of the template with the variables
+// replaced by values. The values are validated against the pattern of
+// their variable.
+func (t *pathTemplate) build(values map[string]string) (string, error) {
+	var buf bytes.Buffer
+
+	for _, token := range t.tokens {
+		if token.wildcard {
+			return "", fmt.Errorf("mux: can't build a wildcard")
+		}
+
+		if token.regex == nil {
+			buf.WriteString(token.literal)
+			continue
+		}
+
+		value, found := values[token.name]
+		if !found {
+			if token.optional {
+				continue
+			}
+			return "", fmt.Errorf("mux: value of variable %q is missing", token.name)
+		}
+
+		if !token.regex.MatchString(value) {
+			return "", fmt.Errorf("mux: value %q of variable %q does not match %q", value, token.name, token.regex.String())
+		}
+
+		if token.slash {
+			buf.WriteString("/")
+		}
+		buf.WriteString(value)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,249 @@
+package mux
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestCompilePathTemplate(t *testing.T) {
+
+	tests := []struct {
+		title    string
+		template string
+		path     string
+		vars     Vars
+	}{
+		{
+			title:    "Typed var",
+			template: "/user/:number",
+			path:     "/user/1",
+			vars:     Vars{":number": "1"},
+		},
+		{
+			title:    "Named vars",
+			template: "/users/:id/posts/:slug",
+			path:     "/users/42/posts/hello-world",
+			vars:     Vars{"id": "42", "slug": "hello-world"},
+		},
+		{
+			title:    "Named and typed vars",
+			template: "/users/:id/comment/:number",
+			path:     "/users/donutloop/comment/7",
+			vars:     Vars{"id": "donutloop", ":number": "7"},
+		},
+		{
+			title:    "Many typed vars",
+			template: "/:string/:number/:string/:number/:string",
+			path:     "/a/1/b/2/c",
+			vars:     Vars{":string": "a", ":number": "1", ":string1": "b", ":number1": "2", ":string2": "c"},
+		},
+		{
+			title:    "Duplicate named vars",
+			template: "/:id/:id1/:id",
+			path:     "/a/b/c",
+			vars:     Vars{"id": "a", "id1": "b", "id2": "c"},
+		},
+		{
+			title:    "Adjacent typed vars in a segment",
+			template: "/:string:number/:number",
+			path:     "/abc123/7",
+			vars:     Vars{":string": "abc", ":number": "123", ":number1": "7"},
+		},
+		{
+			title:    "Adjacent braced vars in a segment",
+			template: "/{major:[0-9]+}.{minor:[0-9]+}/{name}",
+			path:     "/1.12/docs",
+			vars:     Vars{"major": "1", "minor": "12", "name": "docs"},
+		},
+		{
+			title:    "Braced vars",
+			template: "/users/{id}/posts/{slug}",
+			path:     "/users/42/posts/hello-world",
+			vars:     Vars{"id": "42", "slug": "hello-world"},
+		},
+		{
+			title:    "Braced var inside a segment",
+			template: "/files/{name}.{ext:[a-z]+}",
+			path:     "/files/report.final.pdf",
+			vars:     Vars{"name": "report.final", "ext": "pdf"},
+		},
+		{
+			title:    "Var with regex constraint",
+			template: "/articles/{id:[0-9a-f]{8}}",
+			path:     "/articles/0a1b2c3d",
+			vars:     Vars{"id": "0a1b2c3d"},
+		},
+		{
+			title:    "Vars with regex constraint and capture groups",
+			template: "/articles/{lang:(en|de)}/{id:[0-9]+}",
+			path:     "/articles/de/12",
+			vars:     Vars{"lang": "de", "id": "12"},
+		},
+		{
+			title:    "Catch-all var",
+			template: "/static/*filepath",
+			path:     "/static/css/main.css",
+			vars:     Vars{"filepath": "css/main.css"},
+		},
+		{
+			title:    "Empty catch-all var",
+			template: "/static/*filepath",
+			path:     "/static/",
+			vars:     Vars{"filepath": ""},
+		},
+		{
+			title:    "Named and catch-all var",
+			template: "/proxy/:service/*rest",
+			path:     "/proxy/users/api/v1/users",
+			vars:     Vars{"service": "users", "rest": "api/v1/users"},
+		},
+		{
+			title:    "Optional var (present)",
+			template: "/reports/:year/:month?",
+			path:     "/reports/2024/05",
+			vars:     Vars{"year": "2024", "month": "05"},
+		},
+		{
+			title:    "Optional var (missing)",
+			template: "/reports/:year/:month?",
+			path:     "/reports/2024",
+			vars:     Vars{"year": "2024"},
+		},
+		{
+			title:    "Optional var with regex constraint",
+			template: "/reports/{year:[0-9]{4}}/{month:[0-9]{2}}?",
+			path:     "/reports/2024",
+			vars:     Vars{"year": "2024"},
+		},
+		{
+			title:    "Literal with regex meta characters",
+			template: "/api/v1.0/:id",
+			path:     "/api/v1.0/1",
+			vars:     Vars{"id": "1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s (Template: %s, Path: %s)", test.title, test.template, test.path), func(t *testing.T) {
+			template, err := compilePathTemplate(test.template, nil)
+			if err != nil {
+				t.Fatalf("Unexpected error (%s)", err.Error())
+			}
+
+			if !template.match(test.path) {
+				t.Fatalf("Unexpected not matched path")
+			}
+
+			p := params{}
+			template.extractVars(test.path, &p)
+			vars := p.vars()
+
+			if !reflect.DeepEqual(test.vars, vars) {
+				t.Errorf("Unexpected vars (Expected: %v, Actual: %v)", test.vars, vars)
+			}
+		})
+	}
+}
+
+func TestCompilePathTemplateFail(t *testing.T) {
+	templates := []string{
+		"/user/:/comment",
+		"/articles/{id:[0-9a-f]{8}",
+		"/articles/{:[0-9]+}",
+		"/articles/{id:}",
+		"/articles/{id:[0-9}",
+		"/static/*",
+		"/static/*filepath/edit",
+	}
+
+	for _, template := range templates {
+		if _, err := compilePathTemplate(template, nil); err == nil {
+			t.Errorf("Expected a error (%s)", template)
+		}
+	}
+}
+
+func TestPathTemplateNotMatch(t *testing.T) {
+	template, _ := compilePathTemplate("/api/v1.0/:id", nil)
+
+	for _, path := range []string{"/api/v100/1", "/api/v1.0/1/2", "/api/v1.0/"} {
+		if template.match(path) {
+			t.Errorf("Unexpected matched path (%s)", path)
+		}
+	}
+
+	template, _ = compilePathTemplate("/reports/:year/:month?", nil)
+
+	for _, path := range []string{"/reports/", "/reports/2024/", "/reports/2024/05/01"} {
+		if template.match(path) {
+			t.Errorf("Unexpected matched path (%s)", path)
+		}
+	}
+
+	template, _ = compilePathTemplate("/articles/{id:[0-9a-f]{8}}", nil)
+
+	for _, path := range []string{"/articles/0a1b2c3", "/articles/0a1b2c3d4", "/articles/0a1b2c3z"} {
+		if template.match(path) {
+			t.Errorf("Unexpected matched path (%s)", path)
+		}
+	}
+}
+
+func TestPathTemplateBuild(t *testing.T) {
+
+	tests := []struct {
+		template string
+		values   map[string]string
+		path     string
+	}{
+		{template: "/users/:id/posts/:slug", values: map[string]string{"id": "42", "slug": "hello"}, path: "/users/42/posts/hello"},
+		{template: "/user/:number/:number", values: map[string]string{":number": "1", ":number1": "2"}, path: "/user/1/2"},
+		{template: "/articles/{id:[0-9a-f]{8}}", values: map[string]string{"id": "0a1b2c3d"}, path: "/articles/0a1b2c3d"},
+		{template: "/reports/:year/:month?", values: map[string]string{"year": "2024", "month": "05"}, path: "/reports/2024/05"},
+		{template: "/reports/:year/:month?", values: map[string]string{"year": "2024"}, path: "/reports/2024"},
+		{template: "/static/*filepath", values: map[string]string{"filepath": "css/main.css"}, path: "/static/css/main.css"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Template: %s", test.template), func(t *testing.T) {
+			template, _ := compilePathTemplate(test.template, nil)
+
+			path, err := template.build(test.values)
+			if err != nil {
+				t.Fatalf("Unexpected error (%s)", err.Error())
+			}
+
+			if path != test.path {
+				t.Errorf("Unexpected path (%s)", path)
+			}
+
+			if !template.match(path) {
+				t.Errorf("Unexpected not matched path (%s)", path)
+			}
+		})
+	}
+}
+
+func TestPathTemplateBuildFail(t *testing.T) {
+
+	tests := []struct {
+		template string
+		values   map[string]string
+	}{
+		{template: "/users/:id", values: map[string]string{}},
+		{template: "/users/:id", values: map[string]string{"id": "4/2"}},
+		{template: "/user/:number", values: map[string]string{":number": "donutloop"}},
+		{template: "/articles/{id:[0-9a-f]{8}}", values: map[string]string{"id": "0a1b"}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Template: %s", test.template), func(t *testing.T) {
+			template, _ := compilePathTemplate(test.template, nil)
+
+			if _, err := template.build(test.values); err == nil {
+				t.Error("Expected a error")
+			}
+		})
+	}
+}
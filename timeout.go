@@ -0,0 +1,141 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Timeout returns a middleware which cancels the context of a request after
+// d and answers it with code, 503 Service Unavailable if zero, e.g. 504
+// Gateway Timeout for a route calling a slow dependency:
+//
+//     r.Get("/quotes", quotesHandler).Use(mux.Timeout(2*time.Second, http.StatusGatewayTimeout))
+//
+// The handler runs in its own goroutine and should return once the context
+// is canceled, the cause is context.DeadlineExceeded (see context.Cause).
+// Its writes fail with http.ErrHandlerTimeout from then on. A handler which
+// started writing its response before the deadline can't be answered with
+// the timeout status anymore, its context is canceled and the middleware
+// waits for it to return. A panic of the handler is passed on to
+// the request goroutine.
+func Timeout(d time.Duration, code int) MiddlewareFunc {
+	if code == 0 {
+		code = http.StatusServiceUnavailable
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			// the context is canceled once the response is settled, so the
+			// handler can't start writing in between
+			ctx, cancel := context.WithCancelCause(req.Context())
+			defer cancel(context.Canceled)
+			timer := time.NewTimer(d)
+			defer timer.Stop()
+
+			tw := &timeoutWriter{w: w, header: http.Header{}}
+			// the router reuses the params once the middleware returns,
+			// while the handler may still run
+			handlerReq := detachParams(req.WithContext(ctx))
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if rv := recover(); rv != nil {
+						panicked <- rv
+					}
+				}()
+				next.ServeHTTP(tw, handlerReq)
+				tw.WriteHeader(http.StatusOK)
+				close(done)
+			}()
+
+			select {
+			case <-done:
+			case rv := <-panicked:
+				panic(rv)
+			case <-timer.C:
+				tw.mu.Lock()
+				if !tw.wroteHeader {
+					tw.timedOut = true
+					tw.mu.Unlock()
+					cancel(context.DeadlineExceeded)
+					http.Error(w, http.StatusText(code), code)
+					return
+				}
+				tw.mu.Unlock()
+				cancel(context.DeadlineExceeded)
+
+				select {
+				case <-done:
+				case rv := <-panicked:
+					panic(rv)
+				}
+			}
+		})
+	}
+}
+
+// timeoutWriter passes the response of a handler on until it times out.
+// The header is separate, so the handler can't modify the timeout response.
+type timeoutWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+
+	mu          sync.Mutex
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.writeHeader(code)
+}
+
+// writeHeader writes the header with the lock held.
+func (w *timeoutWriter) writeHeader(code int) {
+	if w.timedOut || w.wroteHeader {
+		return
+	}
+
+	h := w.w.Header()
+	for key, values := range w.header {
+		h[key] = append([]string(nil), values...)
+	}
+	w.w.WriteHeader(code)
+	if code < 100 || code > 199 || code == http.StatusSwitchingProtocols {
+		w.wroteHeader = true
+	}
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	w.writeHeader(http.StatusOK)
+	return w.w.Write(b)
+}
+
+// Flush sends the buffered data to the client unless the handler timed out.
+func (w *timeoutWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut {
+		return
+	}
+	w.writeHeader(http.StatusOK)
+	if f, ok := w.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,99 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	canceled := make(chan error, 1)
+	written := make(chan error, 1)
+
+	r := Classic()
+	r.Get("/fast", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Handler", "fast")
+		w.Write([]byte("ok"))
+	}).Use(Timeout(time.Second, 0))
+	r.Get("/slow", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Handler", "slow")
+		<-req.Context().Done()
+		canceled <- context.Cause(req.Context())
+		_, err := w.Write([]byte("late"))
+		written <- err
+	}).Use(Timeout(10*time.Millisecond, http.StatusGatewayTimeout))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "ok" || w.Header().Get("X-Handler") != "fast" {
+		t.Errorf("Unexpected response (%d, %s, %v)", w.Code, w.Body.String(), w.Header())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if w.Code != http.StatusGatewayTimeout || w.Header().Get("X-Handler") != "" {
+		t.Errorf("Unexpected response (%d, %v)", w.Code, w.Header())
+	}
+	if err := <-canceled; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Unexpected context error (%v)", err)
+	}
+	if err := <-written; err != http.ErrHandlerTimeout {
+		t.Errorf("Unexpected write error (%v)", err)
+	}
+	if w.Body.String() != "Gateway Timeout\n" {
+		t.Errorf("Unexpected body (%q)", w.Body.String())
+	}
+}
+
+func TestTimeoutStarted(t *testing.T) {
+	r := Classic()
+	r.Get("/stream", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("partial"))
+		<-req.Context().Done()
+		w.Write([]byte(" rest"))
+	}).Use(Timeout(10*time.Millisecond, 0))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "partial rest" {
+		t.Errorf("Unexpected response (%d, %s)", w.Code, w.Body.String())
+	}
+}
+
+func TestTimeoutPanic(t *testing.T) {
+	r := Classic()
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	}).Use(Timeout(time.Second, 0))
+
+	defer func() {
+		if rv := recover(); rv != "boom" {
+			t.Errorf("Unexpected panic (%v)", rv)
+		}
+	}()
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestTimeoutParams(t *testing.T) {
+	params := make(chan string, 1)
+
+	r := Classic()
+	r.Get("/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+		// the router released its params when the middleware returned
+		time.Sleep(10 * time.Millisecond)
+		params <- Param(req, "id")
+	}).Use(Timeout(10*time.Millisecond, 0))
+	r.Get("/other/:id", func(w http.ResponseWriter, req *http.Request) {})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	// reuses the pooled params
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/other/7", nil))
+
+	if id := <-params; id != "42" {
+		t.Errorf("Unexpected param (%q)", id)
+	}
+}
@@ -0,0 +1,263 @@
+package mux
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tracer starts the server spans of the Tracing middleware. NewTracer
+// returns a tracer which propagates W3C trace context, adapters to
+// OpenTelemetry or other tracing libraries implement it with their tracer.
+type Tracer interface {
+	// Start starts a span named name for req, as a child of the trace
+	// context in ctx or the request headers. The returned context carries
+	// the span.
+	Start(ctx context.Context, name string, req *http.Request) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	SetAttributes(attrs ...slog.Attr)
+	RecordError(err error)
+	// SetStatus sets the HTTP status of the response, a 5xx status marks
+	// the span as failed.
+	SetStatus(code int)
+	End()
+}
+
+// Tracing returns a middleware which starts a server span for every
+// request, so the router takes part in distributed traces, e.g.:
+//
+//     r.Use(mux.Tracing(mux.NewTracer(exportSpan)))
+//
+// The span is named after the method and the path template of the matched
+// route, e.g. "GET /users/:id", and has the OpenTelemetry HTTP attributes
+// (http.request.method, http.route, url.path, ...). The status of the
+// response is recorded when the handler returns, a panic is recorded as
+// error with the status 500. Handlers get the span with CurrentSpan, e.g. to
+// record errors.
+func Tracing(tracer Tracer) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			name := req.Method
+			attrs := []slog.Attr{
+				slog.String("http.request.method", req.Method),
+				slog.String("url.path", req.URL.Path),
+				slog.String("url.scheme", requestScheme(req)),
+				slog.String("client.address", ClientIP(req)),
+			}
+			if route := CurrentRoute(req); route != nil {
+				name += " " + route.GetPath()
+				attrs = append(attrs, slog.String("http.route", route.GetPath()))
+			}
+			if ua := req.UserAgent(); ua != "" {
+				attrs = append(attrs, slog.String("user_agent.original", ua))
+			}
+
+			ctx, span := tracer.Start(req.Context(), name, req)
+			span.SetAttributes(attrs...)
+
+			sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+			panicked := true
+			defer func() {
+				code := sw.code
+				if panicked {
+					code = http.StatusInternalServerError
+					span.RecordError(errors.New("mux: handler panicked"))
+				}
+				span.SetAttributes(slog.Int("http.response.status_code", code))
+				span.SetStatus(code)
+				span.End()
+			}()
+
+			next.ServeHTTP(sw, req.WithContext(context.WithValue(ctx, spanKey, span)))
+			panicked = false
+		})
+	}
+}
+
+// CurrentSpan returns the span of the Tracing middleware for the current
+// request, nil if there is none.
+func CurrentSpan(r *http.Request) Span {
+	if rv := contextGet(r, spanKey); rv != nil {
+		return rv.(Span)
+	}
+	return nil
+}
+
+// SpanData is a span of the tracer of NewTracer. The IDs are hex encoded.
+type SpanData struct {
+	Name         string
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	// TraceState is the tracestate header of the parent, passed on
+	// unchanged.
+	TraceState string
+	Sampled    bool
+	Start      time.Time
+	End        time.Time
+	Attributes []slog.Attr
+	Status     int
+	Errors     []error
+}
+
+// NewTracer returns a tracer which continues the trace of the W3C
+// traceparent header of a request, or starts a new one. The finished spans
+// are passed to export, e.g. to log them or send them to a collector.
+//
+// InjectTrace adds the trace context of its spans to outgoing requests, the
+// proxies of the router do so automatically.
+func NewTracer(export func(span *SpanData)) Tracer {
+	return tracer{export: export}
+}
+
+type tracer struct {
+	export func(span *SpanData)
+}
+
+func (t tracer) Start(ctx context.Context, name string, req *http.Request) (context.Context, Span) {
+	span := &traceSpan{
+		export: t.export,
+		data: SpanData{
+			Name:    name,
+			SpanID:  newTraceID(8),
+			Sampled: true,
+			Start:   time.Now(),
+		},
+	}
+
+	if parent, ok := ctx.Value(spanKey).(*traceSpan); ok {
+		span.data.TraceID = parent.data.TraceID
+		span.data.ParentSpanID = parent.data.SpanID
+		span.data.TraceState = parent.data.TraceState
+		span.data.Sampled = parent.data.Sampled
+	} else if traceID, spanID, sampled, ok := parseTraceparent(req.Header.Get("traceparent")); ok {
+		span.data.TraceID = traceID
+		span.data.ParentSpanID = spanID
+		span.data.TraceState = req.Header.Get("tracestate")
+		span.data.Sampled = sampled
+	} else {
+		span.data.TraceID = newTraceID(16)
+	}
+	return context.WithValue(ctx, spanKey, span), span
+}
+
+// traceSpan is a span of the tracer of NewTracer.
+type traceSpan struct {
+	export func(span *SpanData)
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+func (s *traceSpan) SetAttributes(attrs ...slog.Attr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attributes = append(s.data.Attributes, attrs...)
+}
+
+func (s *traceSpan) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Errors = append(s.data.Errors, err)
+}
+
+func (s *traceSpan) SetStatus(code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Status = code
+}
+
+// End ends the span and exports it if it is sampled. Only the first call
+// has an effect.
+func (s *traceSpan) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	if data.Sampled && s.export != nil {
+		s.export(&data)
+	}
+}
+
+// traceparent returns the traceparent header of the span.
+func (s *traceSpan) traceparent() string {
+	flags := "00"
+	if s.data.Sampled {
+		flags = "01"
+	}
+	return "00-" + s.data.TraceID + "-" + s.data.SpanID + "-" + flags
+}
+
+// InjectTrace sets the traceparent and tracestate headers of an outgoing
+// request to the span of the tracer of NewTracer in ctx, so the called
+// service continues the trace, e.g.:
+//
+//     out, _ := http.NewRequestWithContext(req.Context(), http.MethodGet, url, nil)
+//     mux.InjectTrace(out.Context(), out.Header)
+//
+// It does nothing if ctx has no such span.
+func InjectTrace(ctx context.Context, header http.Header) {
+	span, ok := ctx.Value(spanKey).(*traceSpan)
+	if !ok {
+		return
+	}
+	header.Set("traceparent", span.traceparent())
+	if span.data.TraceState != "" {
+		header.Set("tracestate", span.data.TraceState)
+	} else {
+		header.Del("tracestate")
+	}
+}
+
+// parseTraceparent parses a W3C traceparent header, e.g.
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+func parseTraceparent(value string) (traceID, spanID string, sampled, ok bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	// future versions may append fields
+	if len(parts) < 4 || len(parts[0]) != 2 || !isLowerHex(parts[0]) || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return "", "", false, false
+	}
+	if !isTraceID(parts[1], 32) || !isTraceID(parts[2], 16) || !isLowerHex(parts[3]) || len(parts[3]) != 2 {
+		return "", "", false, false
+	}
+	flags, _ := hex.DecodeString(parts[3])
+	return parts[1], parts[2], flags[0]&1 == 1, true
+}
+
+// isTraceID returns true for a valid trace or span ID of n lowercase hex
+// digits, which must not be all zero.
+func isTraceID(id string, n int) bool {
+	return len(id) == n && isLowerHex(id) && strings.Trim(id, "0") != ""
+}
+
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// newTraceID returns a random ID of n bytes, hex encoded.
+func newTraceID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
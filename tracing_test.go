@@ -0,0 +1,123 @@
+package mux
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestTracing(t *testing.T) {
+	var mu sync.Mutex
+	var spans []*SpanData
+	tracer := NewTracer(func(span *SpanData) {
+		mu.Lock()
+		defer mu.Unlock()
+		spans = append(spans, span)
+	})
+
+	r := Classic()
+	r.Use(Recovery(nil, nil))
+	r.Use(Tracing(tracer))
+	r.Get("/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		CurrentSpan(req).RecordError(errors.New("lookup failed"))
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	r.Get("/panic", func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("tracestate", "vendor=1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if len(spans) != 2 {
+		t.Fatalf("Unexpected spans (%v)", spans)
+	}
+
+	span := spans[0]
+	if span.Name != "GET /users/:id" || span.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" ||
+		span.ParentSpanID != "00f067aa0ba902b7" || span.TraceState != "vendor=1" || len(span.SpanID) != 16 {
+		t.Errorf("Unexpected span (%+v)", span)
+	}
+	if span.Status != http.StatusBadGateway || len(span.Errors) != 1 || span.End.Before(span.Start) {
+		t.Errorf("Unexpected span (%+v)", span)
+	}
+	attrs := map[string]string{}
+	for _, attr := range span.Attributes {
+		attrs[attr.Key] = attr.Value.String()
+	}
+	if attrs["http.route"] != "/users/:id" || attrs["url.path"] != "/users/1" || attrs["http.response.status_code"] != "502" {
+		t.Errorf("Unexpected attributes (%v)", attrs)
+	}
+
+	span = spans[1]
+	if span.Name != "GET /panic" || span.Status != http.StatusInternalServerError || len(span.Errors) != 1 ||
+		len(span.TraceID) != 32 || span.ParentSpanID != "" {
+		t.Errorf("Unexpected span (%+v)", span)
+	}
+}
+
+func TestTracingNotSampled(t *testing.T) {
+	exported := false
+	r := Classic()
+	r.Use(Tracing(NewTracer(func(span *SpanData) { exported = true })))
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if exported {
+		t.Error("Unexpected export of an unsampled span")
+	}
+}
+
+func TestTracingProxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(req.Header.Get("traceparent")))
+	}))
+	defer backend.Close()
+
+	var span *SpanData
+	r := Classic()
+	r.Use(Tracing(NewTracer(func(s *SpanData) { span = s })))
+	r.Proxy("/api/*path", backend.URL)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if span == nil || w.Body.String() != "00-4bf92f3577b34da6a3ce929d0e0e4736-"+span.SpanID+"-01" {
+		t.Errorf("Unexpected traceparent (%s)", w.Body.String())
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		value string
+		ok    bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if _, _, _, ok := parseTraceparent(test.value); ok != test.ok {
+			t.Errorf("Unexpected result for %q (%v)", test.value, ok)
+		}
+	}
+	if id := newTraceID(16); !isTraceID(id, 32) {
+		t.Errorf("Unexpected trace ID (%s)", id)
+	}
+}
@@ -1,6 +1,9 @@
 package mux
 
-import "net/http"
+import (
+	"net/http"
+	"sort"
+)
 
 //Validator validates the incomming value against a valid value/s
 type Validator interface {
@@ -27,6 +30,16 @@ var methods = map[string]struct{}{
 	http.MethodConnect: {},
 }
 
+// standardMethods returns the standard methods in sorted order.
+func standardMethods() []string {
+	sorted := make([]string, 0, len(methods))
+	for method := range methods {
+		sorted = append(sorted, method)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
 func (v MethodValidator) Validate(r RouteInterface) error {
 
 	if _, found := v[r.GetMethodName()]; !found {
@@ -47,7 +60,7 @@ func (v pathValidator) Validate(r RouteInterface) error {
 
 	foundPathMatcher := false
 	for _, m := range r.GetMatchers() {
-		if m.Rank() == rankPath {
+		if m.Rank() == RankPath {
 			foundPathMatcher = true
 		}
 	}
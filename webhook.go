@@ -0,0 +1,194 @@
+package mux
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureOptions configures the VerifySignature middleware. GitHubSignature,
+// StripeSignature and SlackSignature return the options of common webhook
+// senders.
+type SignatureOptions struct {
+	// Secrets are the keys of the HMAC, a signature made with any of them is
+	// valid, e.g. while a secret is rotated.
+	Secrets [][]byte
+	// Hash is the hash of the HMAC, it defaults to sha256.New.
+	Hash func() hash.Hash
+	// Signatures returns the hex encoded signatures of a request and its
+	// timestamp in unix seconds, empty if it has none. It is required.
+	Signatures func(req *http.Request) (signatures []string, timestamp string)
+	// Payload returns the signed payload of a request, it defaults to the
+	// body.
+	Payload func(timestamp string, body []byte) []byte
+	// Tolerance is the maximum age of a timestamp, it defaults to 5
+	// minutes. It limits the replay of intercepted requests.
+	Tolerance time.Duration
+	// MaxBytes limits the body, it defaults to 1 MiB. Larger requests are
+	// answered with 413 Request Entity Too Large.
+	MaxBytes int64
+	// Clock returns the current time, it defaults to time.Now.
+	Clock func() time.Time
+}
+
+// VerifySignature returns a middleware which verifies the HMAC signature of
+// the request body, e.g. of a webhook:
+//
+//     r.Post("/webhooks/github", githubHandler).Use(mux.VerifySignature(mux.GitHubSignature(secret)))
+//
+// The body is buffered and provided to the handler again. Requests without
+// a valid signature, or whose timestamp is outside the tolerance, are
+// answered with 401 Unauthorized. The signatures are compared in constant
+// time.
+func VerifySignature(opts SignatureOptions) MiddlewareFunc {
+	if opts.Hash == nil {
+		opts.Hash = sha256.New
+	}
+	if opts.Payload == nil {
+		opts.Payload = func(timestamp string, body []byte) []byte {
+			return body
+		}
+	}
+	if opts.Tolerance <= 0 {
+		opts.Tolerance = 5 * time.Minute
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = 1 << 20
+	}
+	if opts.Clock == nil {
+		opts.Clock = time.Now
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var body []byte
+			if req.Body != nil {
+				var err error
+				body, err = io.ReadAll(http.MaxBytesReader(w, req.Body, opts.MaxBytes))
+				if err != nil {
+					var mbe *http.MaxBytesError
+					if errors.As(err, &mbe) {
+						tooLarge(w)
+						return
+					}
+					http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+					return
+				}
+			}
+
+			if !opts.verify(req, body) {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			req.ContentLength = int64(len(body))
+			req.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// verify returns true if one of the signatures of the request is valid.
+func (opts SignatureOptions) verify(req *http.Request, body []byte) bool {
+	signatures, timestamp := opts.Signatures(req)
+
+	if timestamp != "" {
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return false
+		}
+		age := opts.Clock().Sub(time.Unix(seconds, 0))
+		if age > opts.Tolerance || age < -opts.Tolerance {
+			return false
+		}
+	}
+
+	payload := opts.Payload(timestamp, body)
+	valid := false
+	for _, secret := range opts.Secrets {
+		mac := hmac.New(opts.Hash, secret)
+		mac.Write(payload)
+		expected := mac.Sum(nil)
+
+		for _, signature := range signatures {
+			decoded, err := hex.DecodeString(strings.TrimSpace(signature))
+			if err == nil && hmac.Equal(decoded, expected) {
+				valid = true
+			}
+		}
+	}
+	return valid
+}
+
+// GitHubSignature returns the options to verify the X-Hub-Signature-256
+// header of GitHub webhooks.
+func GitHubSignature(secret string) SignatureOptions {
+	return SignatureOptions{
+		Secrets: [][]byte{[]byte(secret)},
+		Signatures: func(req *http.Request) ([]string, string) {
+			signature, found := strings.CutPrefix(req.Header.Get("X-Hub-Signature-256"), "sha256=")
+			if !found {
+				return nil, ""
+			}
+			return []string{signature}, ""
+		},
+	}
+}
+
+// StripeSignature returns the options to verify the Stripe-Signature header
+// of Stripe webhooks, whose timestamp is signed with the body.
+func StripeSignature(secret string) SignatureOptions {
+	return SignatureOptions{
+		Secrets: [][]byte{[]byte(secret)},
+		Signatures: func(req *http.Request) ([]string, string) {
+			var signatures []string
+			var timestamp string
+			for _, element := range strings.Split(req.Header.Get("Stripe-Signature"), ",") {
+				key, value, _ := strings.Cut(strings.TrimSpace(element), "=")
+				switch key {
+				case "t":
+					timestamp = value
+				case "v1":
+					signatures = append(signatures, value)
+				}
+			}
+			if timestamp == "" {
+				return nil, ""
+			}
+			return signatures, timestamp
+		},
+		Payload: func(timestamp string, body []byte) []byte {
+			return append([]byte(timestamp+"."), body...)
+		},
+	}
+}
+
+// SlackSignature returns the options to verify the X-Slack-Signature and
+// X-Slack-Request-Timestamp headers of Slack requests.
+func SlackSignature(secret string) SignatureOptions {
+	return SignatureOptions{
+		Secrets: [][]byte{[]byte(secret)},
+		Signatures: func(req *http.Request) ([]string, string) {
+			signature, found := strings.CutPrefix(req.Header.Get("X-Slack-Signature"), "v0=")
+			timestamp := req.Header.Get("X-Slack-Request-Timestamp")
+			if !found || timestamp == "" {
+				return nil, ""
+			}
+			return []string{signature}, timestamp
+		},
+		Payload: func(timestamp string, body []byte) []byte {
+			return append([]byte("v0:"+timestamp+":"), body...)
+		},
+	}
+}
@@ -0,0 +1,116 @@
+package mux
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func hmacHex(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func webhookRouter(opts SignatureOptions) *Router {
+	r := Classic()
+	r.Post("/webhook", func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		w.Write(body)
+	}).Use(VerifySignature(opts))
+	return r
+}
+
+func TestVerifySignatureGitHub(t *testing.T) {
+	r := webhookRouter(GitHubSignature("s3cret"))
+	body := `{"action":"opened"}`
+
+	tests := map[string]int{
+		"sha256=" + hmacHex("s3cret", body): http.StatusOK,
+		"sha256=" + hmacHex("other", body):  http.StatusUnauthorized,
+		hmacHex("s3cret", body):             http.StatusUnauthorized,
+		"":                                  http.StatusUnauthorized,
+	}
+
+	for signature, code := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+		req.Header.Set("X-Hub-Signature-256", signature)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != code {
+			t.Errorf("Unexpected status for %q (%d)", signature, w.Code)
+		}
+		if code == http.StatusOK && w.Body.String() != body {
+			t.Errorf("Unexpected body passed on (%s)", w.Body.String())
+		}
+	}
+}
+
+func TestVerifySignatureTimestamp(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := "token=x&command=/deploy"
+
+	opts := SlackSignature("s3cret")
+	opts.Clock = func() time.Time { return now }
+	r := webhookRouter(opts)
+
+	for age, code := range map[time.Duration]int{0: http.StatusOK, 4 * time.Minute: http.StatusOK, 6 * time.Minute: http.StatusUnauthorized, -6 * time.Minute: http.StatusUnauthorized} {
+		timestamp := strconv.FormatInt(now.Add(-age).Unix(), 10)
+		req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+		req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+		req.Header.Set("X-Slack-Signature", "v0="+hmacHex("s3cret", "v0:"+timestamp+":"+body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != code {
+			t.Errorf("Unexpected status for age %s (%d)", age, w.Code)
+		}
+	}
+}
+
+func TestVerifySignatureStripe(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := `{"type":"charge.succeeded"}`
+
+	opts := StripeSignature("whsec")
+	opts.Secrets = append(opts.Secrets, []byte("rotated"))
+	opts.Clock = func() time.Time { return now }
+	r := webhookRouter(opts)
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	for header, code := range map[string]int{
+		"t=" + timestamp + ",v1=" + hmacHex("whsec", timestamp+"."+body):                                                 http.StatusOK,
+		"t=" + timestamp + ",v1=" + hmacHex("old", timestamp+"."+body) + ",v1=" + hmacHex("rotated", timestamp+"."+body): http.StatusOK,
+		"v1=" + hmacHex("whsec", timestamp+"."+body):                                                                     http.StatusUnauthorized,
+		"t=" + timestamp + ",v1=" + hmacHex("whsec", body):                                                               http.StatusUnauthorized,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+		req.Header.Set("Stripe-Signature", header)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != code {
+			t.Errorf("Unexpected status for %q (%d)", header, w.Code)
+		}
+	}
+}
+
+func TestVerifySignatureTooLarge(t *testing.T) {
+	opts := GitHubSignature("s3cret")
+	opts.MaxBytes = 4
+	r := webhookRouter(opts)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader("too large")))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Unexpected status (%d)", w.Code)
+	}
+}